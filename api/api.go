@@ -1,8 +1,10 @@
 package api
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
+	"strings"
 
 	ssi "github.com/nuts-foundation/go-did"
 	"github.com/nuts-foundation/nuts-registry-admin-demo/domain/sp"
@@ -12,6 +14,12 @@ import (
 	"github.com/nuts-foundation/nuts-registry-admin-demo/domain"
 	"github.com/nuts-foundation/nuts-registry-admin-demo/domain/credentials"
 	"github.com/nuts-foundation/nuts-registry-admin-demo/domain/customers"
+	"github.com/nuts-foundation/nuts-registry-admin-demo/domain/directory"
+	"github.com/nuts-foundation/nuts-registry-admin-demo/domain/jobs"
+	"github.com/nuts-foundation/nuts-registry-admin-demo/domain/reconcile"
+	"github.com/nuts-foundation/nuts-registry-admin-demo/domain/rollout"
+	"github.com/nuts-foundation/nuts-registry-admin-demo/domain/trust"
+	"github.com/nuts-foundation/nuts-registry-admin-demo/domain/vctemplates"
 )
 
 type Wrapper struct {
@@ -19,6 +27,12 @@ type Wrapper struct {
 	SPService         sp.Service
 	CustomerService   customers.Service
 	CredentialService credentials.Service
+	RolloutService    *rollout.Service
+	ReconcileService  *reconcile.Service
+	VCTemplateService vctemplates.Service
+	TrustService      trust.Service
+	DirectoryService  directory.Service
+	Jobs              *jobs.Queue
 }
 
 func (w Wrapper) IssueVC(ctx echo.Context) error {
@@ -27,6 +41,14 @@ func (w Wrapper) IssueVC(ctx echo.Context) error {
 		return err
 	}
 
+	if err := w.resolveIssuerCustomer(&request); err != nil {
+		return err
+	}
+
+	if err := w.VCTemplateService.ValidateSubject(request.Type, request.CredentialSubject); err != nil {
+		return vcTemplateErrorResponse(err)
+	}
+
 	issuedVC, err := w.CredentialService.Issue(request)
 	if err != nil {
 		return err
@@ -34,52 +56,74 @@ func (w Wrapper) IssueVC(ctx echo.Context) error {
 	return ctx.JSON(http.StatusOK, *issuedVC)
 }
 
-func (w Wrapper) GetVCTemplates(ctx echo.Context) error {
-	result := []domain.VCTemplate{
-		{
-			Context:          "https://nuts.nl/credentials/v1",
-			Type:             "NutsOrganizationCredential",
-			PublishToNetwork: true,
-			Visibility:       "public",
-			CredentialSubject: map[string]interface{}{
-				"organization": map[string]interface{}{
-					"name": "<Name of the organization>",
-					"city": "<Locality of the organization>",
-				},
-			},
-		},
-		{
-			Context:          "https://nuts.nl/credentials/v1",
-			Type:             "NutsAuthorizationCredential",
-			PublishToNetwork: true,
-			Visibility:       "private",
-			CredentialSubject: map[string]interface{}{
-				"resources": []map[string]interface{}{
-					{
-						"path":        "/DocumentReference/f2aeec97-fc0d-42bf-8ca7-0548192d4231",
-						"operations":  []string{"read"},
-						"userContext": true,
-					},
-				},
-				"purposeOfUse": "eOverdracht",
-				"subject":      "urn:oid:2.16.840.1.113883.2.4.6.3:123456780",
-			},
-		},
-		{
-			Context:          "https://kik-v.nl/context/v1.json",
-			Type:             "ValidatedQueryCredential",
-			PublishToNetwork: true,
-			Visibility:       "private",
-			CredentialSubject: map[string]interface{}{
-				"validatedQuery": map[string]interface{}{
-					"profile":  "https://kik-v2.gitlab.io/uitwisselprofielen/uitwisselprofiel-odb/",
-					"ontology": "http://ontology.ontotext.com/publishing",
-					"sparql":   "<SPARQL query to be performed>",
-				},
-			},
-		},
-	}
-	return ctx.JSON(http.StatusOK, result)
+func (w Wrapper) PreviewVC(ctx echo.Context) error {
+	request := domain.IssueVCRequest{}
+	if err := ctx.Bind(&request); err != nil {
+		return err
+	}
+	if err := w.resolveIssuerCustomer(&request); err != nil {
+		return err
+	}
+
+	template, err := w.VCTemplateService.Get(request.Type)
+	if errors.Is(err, vctemplates.ErrNotFound) {
+		template = nil
+	} else if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	preview, err := w.CredentialService.Preview(request, template)
+	if err != nil {
+		return err
+	}
+	var subjectErr vctemplates.SubjectError
+	if err := w.VCTemplateService.ValidateSubject(request.Type, request.CredentialSubject); errors.As(err, &subjectErr) {
+		for _, field := range subjectErr.Fields {
+			field.Field = "/credentialSubject/0" + strings.TrimSuffix(field.Field, "/")
+			preview.Problems = append(preview.Problems, field)
+		}
+		preview.Valid = false
+	} else if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	return ctx.JSON(http.StatusOK, preview)
+}
+
+// resolveIssuerCustomer sets the issuer of the request to the DID of the issuing customer, if given.
+func (w Wrapper) resolveIssuerCustomer(request *domain.IssueVCRequest) error {
+	if request.IssuerCustomerId == nil {
+		return nil
+	}
+	issuer, err := w.customerIssuer(*request.IssuerCustomerId)
+	if err != nil {
+		return err
+	}
+	if len(request.Issuer) > 0 && request.Issuer != issuer {
+		return echo.NewHTTPError(http.StatusBadRequest, "issuer and issuerCustomerId refer to different DIDs")
+	}
+	request.Issuer = issuer
+	return nil
+}
+
+// customerIssuer returns the DID of the customer for issuing credentials on behalf of the customer,
+// after verifying the service provider controls it.
+func (w Wrapper) customerIssuer(customerID int) (string, error) {
+	serviceProvider, err := w.SPService.Get()
+	if err != nil {
+		return "", err
+	}
+	if serviceProvider == nil {
+		return "", echo.NewHTTPError(http.StatusBadRequest, "service provider not configured")
+	}
+	issuer, err := w.CustomerService.IssuerDID(customerID, serviceProvider.Id)
+	switch {
+	case errors.Is(err, customers.ErrNotFound):
+		return "", echo.NewHTTPError(http.StatusNotFound, err.Error())
+	case errors.Is(err, customers.ErrNoDID), errors.Is(err, customers.ErrNotController):
+		return "", echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	case err != nil:
+		return "", err
+	}
+	return issuer, nil
 }
 
 func (w Wrapper) CheckSession(ctx echo.Context) error {
@@ -111,14 +155,6 @@ func (w Wrapper) GetCustomers(ctx echo.Context) error {
 		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 	}
 
-	for i, c := range allCustomers {
-		credentialsForCustomer, err := w.CredentialService.GetOrganizationCredentials(c)
-		if err != nil {
-			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
-		}
-		allCustomers[i].Active = len(credentialsForCustomer) > 0
-	}
-
 	response := domain.CustomersResponse{}
 	for _, c := range allCustomers {
 		response = append(response, c)
@@ -175,40 +211,46 @@ func (w Wrapper) UpdateCustomer(ctx echo.Context, id int) error {
 		c.Name = req.Name
 		c.City = req.City
 		c.Domain = req.Domain
+		c.Active = req.Active
 		if err := w.CredentialService.ManageNutsOrgCredential(c, req.Active); err != nil {
 			return nil, err
 		}
 		return &c, nil
 	})
+	if errors.Is(err, customers.ErrNotFound) {
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	}
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return err
 	}
 	return ctx.JSON(http.StatusOK, customer)
 }
 
 func (w Wrapper) GetCustomer(ctx echo.Context, id int) error {
 	customer, err := w.CustomerService.Repository.FindByID(id)
-	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
-	}
-	if customer == nil {
+	if errors.Is(err, customers.ErrNotFound) {
 		return ctx.NoContent(http.StatusNotFound)
 	}
-
-	credentialsForCustomer, err := w.CredentialService.GetOrganizationCredentials(*customer)
 	if err != nil {
 		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 	}
 
-	customer.Active = len(credentialsForCustomer) > 0
 	return ctx.JSON(http.StatusOK, customer)
 }
 
-func (w Wrapper) GetCredentialIssuers(ctx echo.Context) error {
-	res, err := w.CredentialService.GetCredentialIssuers([]string{"NutsOrganizationCredential"})
+func (w Wrapper) GetCredentialIssuers(ctx echo.Context, params GetCredentialIssuersParams) error {
+	requestedType := ""
+	if params.Type != nil {
+		requestedType = *params.Type
+	}
+	credentialTypes, err := w.CredentialService.IssuerCredentialTypes(requestedType, w.VCTemplateService.All)
 	if err != nil {
 		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 	}
+	res, err := w.CredentialService.GetCredentialIssuers(credentialTypes)
+	if err != nil {
+		return err
+	}
 	return ctx.JSON(http.StatusOK, res)
 }
 
@@ -216,28 +258,18 @@ func (w Wrapper) UpdateCredentialIssuer(ctx echo.Context, CredentialType string,
 	var request = struct {
 		Trusted bool
 	}{}
-	ctx.Bind(&request)
+	if err := ctx.Bind(&request); err != nil {
+		return err
+	}
 	id, err := ssi.ParseURI(didStr)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err)
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 	}
 	issuerTrust, err := w.CredentialService.ManageIssuerTrust(CredentialType, *id, request.Trusted)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err)
-	}
-	return ctx.JSON(http.StatusOK, issuerTrust)
-}
-
-func (w Wrapper) SearchOrganizations(ctx echo.Context) error {
-	params := domain.SearchOrganizationsJSONBody{}
-	if err := ctx.Bind(&params); err != nil {
 		return err
 	}
-	result, err := w.CredentialService.SearchOrganizations(params.Name, params.City)
-	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err)
-	}
-	return ctx.JSON(http.StatusOK, result)
+	return ctx.JSON(http.StatusOK, issuerTrust)
 }
 
 func (w Wrapper) GetServicesForCustomer(ctx echo.Context, customerID int) error {
@@ -259,12 +291,70 @@ func (w Wrapper) EnableCustomerService(ctx echo.Context, customerID int) error {
 		return err
 	}
 
+	if req.Endpoints != nil && len(*req.Endpoints) > 0 {
+		if err := w.enableDedicatedService(customerID, req.Type, *req.Endpoints); err != nil {
+			return err
+		}
+		return ctx.NoContent(http.StatusNoContent)
+	}
 	if err := w.CustomerService.EnableService(customerID, req.Did, req.Type); err != nil {
 		return err
 	}
 	return ctx.NoContent(http.StatusNoContent)
 }
 
+func (w Wrapper) UpdateCustomerService(ctx echo.Context, customerID int, serviceType string) error {
+	req := domain.UpdateCustomerServiceJSONRequestBody{}
+	if err := ctx.Bind(&req); err != nil {
+		return err
+	}
+	dedicated := req.Endpoints != nil && len(*req.Endpoints) > 0
+	if dedicated {
+		if err := customers.ValidateEndpointURLs(*req.Endpoints); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+	}
+
+	serviceProvider, err := w.SPService.Get()
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	if serviceProvider == nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "service provider not configured")
+	}
+
+	err = w.CustomerService.ReplaceService(customerID, serviceType, func() error {
+		if dedicated {
+			return w.enableDedicatedService(customerID, serviceType, *req.Endpoints)
+		}
+		return w.CustomerService.EnableService(customerID, serviceProvider.Id, serviceType)
+	})
+	if err != nil {
+		return err
+	}
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+// enableDedicatedService enables a service for a customer with a compound service on the customer's DID document.
+// References which aren't overridden by the customer's endpoints are taken from the service provider's compound service of the same type.
+func (w Wrapper) enableDedicatedService(customerID int, serviceType string, endpoints domain.DedicatedEndpoints) error {
+	services, err := w.SPService.GetServices()
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	var sharedReferences map[string]interface{}
+	for _, service := range services {
+		if service.Name == serviceType {
+			sharedReferences = service.ServiceEndpoint
+		}
+	}
+	err = w.CustomerService.EnableDedicatedService(customerID, serviceType, sharedReferences, endpoints)
+	if errors.Is(err, customers.ErrInvalidEndpoint) {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return err
+}
+
 func (w Wrapper) DisableCustomerService(ctx echo.Context, customerID int, serviceType string) error {
 	if err := w.CustomerService.DisableService(customerID, serviceType); err != nil {
 		return err
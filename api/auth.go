@@ -3,11 +3,13 @@ package api
 import (
 	"crypto/ecdsa"
 	"log"
+	"sync"
 	"time"
 
 	"github.com/lestrrat-go/jwx/jwa"
 	"github.com/lestrrat-go/jwx/jwt"
 	"github.com/lestrrat-go/jwx/jwt/openid"
+	"github.com/nuts-foundation/nuts-registry-admin-demo/domain/users"
 )
 
 type UserAccount struct {
@@ -17,23 +19,43 @@ type UserAccount struct {
 
 type auth struct {
 	sessionKey   *ecdsa.PrivateKey
-	userAccounts []UserAccount
+	userAccounts *userAccounts
+	users        users.Service
 }
 
-func NewAuth(key *ecdsa.PrivateKey, userAccounts []UserAccount) auth {
+// userAccounts contains the accounts from the config, which are replaced when the config is reloaded.
+type userAccounts struct {
+	mutex    sync.RWMutex
+	accounts []UserAccount
+}
+
+// NewAuth returns the authentication of the web interface, accepting the given accounts and the accounts managed by the users service.
+func NewAuth(key *ecdsa.PrivateKey, accounts []UserAccount, users users.Service) auth {
 	return auth{
 		sessionKey:   key,
-		userAccounts: userAccounts,
+		userAccounts: &userAccounts{accounts: accounts},
+		users:        users,
 	}
 }
 
+// SetUserAccounts replaces the accepted accounts, besides the accounts managed by the users service.
+// Existing sessions stay valid.
+func (auth auth) SetUserAccounts(accounts []UserAccount) {
+	auth.userAccounts.mutex.Lock()
+	defer auth.userAccounts.mutex.Unlock()
+	auth.userAccounts.accounts = accounts
+}
+
 func (auth auth) CheckCredentials(username, password string) bool {
-	for _, account := range auth.userAccounts {
+	auth.userAccounts.mutex.RLock()
+	accounts := auth.userAccounts.accounts
+	auth.userAccounts.mutex.RUnlock()
+	for _, account := range accounts {
 		if account.Username == username && account.Password == password {
 			return true
 		}
 	}
-	return false
+	return auth.users.CheckCredentials(username, password)
 }
 
 func (auth auth) CreateJWT(email string) ([]byte, error) {
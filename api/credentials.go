@@ -0,0 +1,138 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/nuts-foundation/nuts-registry-admin-demo/domain"
+	"github.com/nuts-foundation/nuts-registry-admin-demo/domain/credentials"
+	"github.com/nuts-foundation/nuts-registry-admin-demo/domain/customers"
+)
+
+func (w Wrapper) GetExpiringCredentials(ctx echo.Context, params GetExpiringCredentialsParams) error {
+	days := w.CredentialService.RenewalDays
+	if params.Days != nil {
+		days = *params.Days
+	}
+	result, err := w.CredentialService.ExpiringCredentials(days)
+	if errors.Is(err, credentials.ErrInvalidPeriod) {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, result)
+}
+
+func (w Wrapper) RenewCredentials(ctx echo.Context) error {
+	job, err := w.Jobs.Submit(credentials.RenewCredentialsJobType, struct{}{})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	return ctx.JSON(http.StatusAccepted, job)
+}
+
+func (w Wrapper) GetCustomerCredentials(ctx echo.Context, customerID int) error {
+	customer, err := w.CustomerService.Repository.FindByID(customerID)
+	if errors.Is(err, customers.ErrNotFound) {
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	}
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	result, err := w.CredentialService.CustomerCredentials(*customer)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, result)
+}
+
+func (w Wrapper) RevokeCustomerCredential(ctx echo.Context, customerID int, credentialID string) error {
+	customer, err := w.CustomerService.Repository.FindByID(customerID)
+	if errors.Is(err, customers.ErrNotFound) {
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	}
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	err = w.CredentialService.RevokeCustomerCredential(*customer, credentialID)
+	switch {
+	case errors.Is(err, credentials.ErrCredentialNotFound):
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	case errors.Is(err, credentials.ErrNotRevocable):
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	case errors.Is(err, credentials.ErrAlreadyRevoked):
+		return echo.NewHTTPError(http.StatusConflict, err.Error())
+	case err != nil:
+		return err
+	}
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+func (w Wrapper) GetCustomerAuthorizations(ctx echo.Context, customerID int) error {
+	customer, err := w.CustomerService.Repository.FindByID(customerID)
+	if errors.Is(err, customers.ErrNotFound) {
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	}
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	result, err := w.CredentialService.CustomerAuthorizations(*customer)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, result)
+}
+
+func (w Wrapper) IssueAuthorization(ctx echo.Context, customerID int) error {
+	request := domain.AuthorizationRequest{}
+	if err := ctx.Bind(&request); err != nil {
+		return err
+	}
+	customer, err := w.CustomerService.Repository.FindByID(customerID)
+	if errors.Is(err, customers.ErrNotFound) {
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	}
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	// Authorizations are issued by the customer, so the service provider must control its DID
+	if _, err := w.customerIssuer(customerID); err != nil {
+		return err
+	}
+	result, err := w.CredentialService.IssueAuthorization(*customer, request)
+	switch {
+	case errors.Is(err, credentials.ErrInvalidAuthorization), errors.Is(err, credentials.ErrUnknownOrganization), errors.Is(err, credentials.ErrCustomerWithoutDID):
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	case err != nil:
+		return err
+	}
+	return ctx.JSON(http.StatusOK, result)
+}
+
+func (w Wrapper) SearchVCs(ctx echo.Context) error {
+	request := domain.SearchVCsRequest{}
+	if err := ctx.Bind(&request); err != nil {
+		return err
+	}
+	result, err := w.CredentialService.SearchVCs(request)
+	if errors.Is(err, credentials.ErrInvalidSearch) {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, result)
+}
+
+func (w Wrapper) RevokeVCs(ctx echo.Context) error {
+	request := domain.RevokeVCsRequest{}
+	if err := ctx.Bind(&request); err != nil {
+		return err
+	}
+	if len(request.Ids) == 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "ids must be given")
+	}
+	return ctx.JSON(http.StatusOK, w.CredentialService.RevokeVCs(request.Ids))
+}
@@ -0,0 +1,37 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/nuts-foundation/nuts-registry-admin-demo/domain"
+	"github.com/nuts-foundation/nuts-registry-admin-demo/domain/directory"
+)
+
+func (w Wrapper) SearchOrganizationDirectory(ctx echo.Context, params SearchOrganizationDirectoryParams) error {
+	result, err := w.DirectoryService.Search(params)
+	if errors.Is(err, directory.ErrInvalidQuery) {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, result)
+}
+
+func (w Wrapper) SearchOrganizations(ctx echo.Context) error {
+	params := domain.SearchOrganizationsJSONBody{}
+	if err := ctx.Bind(&params); err != nil {
+		return err
+	}
+	result, indexUpdated, err := w.DirectoryService.SearchOrganizations(params.Name, params.City)
+	if err != nil {
+		return err
+	}
+	if indexUpdated != nil {
+		ctx.Response().Header().Set("X-Index-Updated", indexUpdated.Format(time.RFC3339))
+	}
+	return ctx.JSON(http.StatusOK, result)
+}
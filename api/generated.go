@@ -23,8 +23,14 @@ type ServerInterface interface {
 	// (PUT /web/private/credential/{type}/issuer/{did})
 	UpdateCredentialIssuer(ctx echo.Context, pType string, did string) error
 
+	// (GET /web/private/credentials/expiring)
+	GetExpiringCredentials(ctx echo.Context, params GetExpiringCredentialsParams) error
+
 	// (GET /web/private/credentials/issuers)
-	GetCredentialIssuers(ctx echo.Context) error
+	GetCredentialIssuers(ctx echo.Context, params GetCredentialIssuersParams) error
+
+	// (POST /web/private/credentials/renew)
+	RenewCredentials(ctx echo.Context) error
 
 	// (GET /web/private/customers)
 	GetCustomers(ctx echo.Context) error
@@ -32,12 +38,27 @@ type ServerInterface interface {
 	// (POST /web/private/customers)
 	ConnectCustomer(ctx echo.Context) error
 
+	// (POST /web/private/customers/activate)
+	ActivateCustomers(ctx echo.Context) error
+
 	// (GET /web/private/customers/{id})
 	GetCustomer(ctx echo.Context, id int) error
 
 	// (PUT /web/private/customers/{id})
 	UpdateCustomer(ctx echo.Context, id int) error
 
+	// (GET /web/private/customers/{id}/authorizations)
+	GetCustomerAuthorizations(ctx echo.Context, id int) error
+
+	// (POST /web/private/customers/{id}/authorizations)
+	IssueAuthorization(ctx echo.Context, id int) error
+
+	// (GET /web/private/customers/{id}/credentials)
+	GetCustomerCredentials(ctx echo.Context, id int) error
+
+	// (DELETE /web/private/customers/{id}/credentials/{credentialId})
+	RevokeCustomerCredential(ctx echo.Context, id int, credentialId string) error
+
 	// (GET /web/private/customers/{id}/services)
 	GetServicesForCustomer(ctx echo.Context, id int) error
 
@@ -47,15 +68,39 @@ type ServerInterface interface {
 	// (DELETE /web/private/customers/{id}/services/{type})
 	DisableCustomerService(ctx echo.Context, id int, pType string) error
 
+	// (PUT /web/private/customers/{id}/services/{type})
+	UpdateCustomerService(ctx echo.Context, id int, pType string) error
+
+	// (GET /web/private/jobs)
+	GetJobs(ctx echo.Context) error
+
+	// (GET /web/private/jobs/{id})
+	GetJob(ctx echo.Context, id string) error
+
 	// (POST /web/private/organizations)
 	SearchOrganizations(ctx echo.Context) error
 
+	// (GET /web/private/organizations/directory)
+	SearchOrganizationDirectory(ctx echo.Context, params SearchOrganizationDirectoryParams) error
+
+	// (GET /web/private/reconcile)
+	GetReconcileReport(ctx echo.Context) error
+
+	// (POST /web/private/reconcile)
+	RepairDrift(ctx echo.Context) error
+
 	// (GET /web/private/service-provider)
 	GetServiceProvider(ctx echo.Context) error
 
 	// (PUT /web/private/service-provider)
 	UpdateServiceProvider(ctx echo.Context) error
 
+	// (GET /web/private/service-provider/catalog)
+	GetServiceCatalog(ctx echo.Context) error
+
+	// (POST /web/private/service-provider/catalog/{name}/apply)
+	ApplyServiceTemplate(ctx echo.Context, name string) error
+
 	// (GET /web/private/service-provider/endpoints)
 	GetEndpoints(ctx echo.Context) error
 
@@ -65,17 +110,74 @@ type ServerInterface interface {
 	// (DELETE /web/private/service-provider/endpoints/{id})
 	DeleteEndpoint(ctx echo.Context, id string) error
 
+	// (POST /web/private/service-provider/nutscomm-sync)
+	SyncNutsCommService(ctx echo.Context) error
+
 	// (GET /web/private/service-provider/services)
 	GetServices(ctx echo.Context) error
 
 	// (POST /web/private/service-provider/services)
 	AddService(ctx echo.Context) error
 
+	// (GET /web/private/service-provider/services/broken-references)
+	GetBrokenServiceReferences(ctx echo.Context) error
+
+	// (DELETE /web/private/service-provider/services/{id})
+	DeleteService(ctx echo.Context, id string, params DeleteServiceParams) error
+
+	// (PUT /web/private/service-provider/services/{id})
+	UpdateService(ctx echo.Context, id string) error
+
+	// (POST /web/private/services/{type}/rollout)
+	StartRollout(ctx echo.Context, pType string) error
+
+	// (GET /web/private/services/{type}/rollout/{id})
+	GetRollout(ctx echo.Context, pType string, id string) error
+
+	// (POST /web/private/services/{type}/rollout/{id}/resume)
+	ResumeRollout(ctx echo.Context, pType string, id string) error
+
+	// (GET /web/private/trust/policies)
+	GetTrustPolicies(ctx echo.Context) error
+
+	// (POST /web/private/trust/policies)
+	ApplyTrustPolicy(ctx echo.Context, params ApplyTrustPolicyParams) error
+
+	// (GET /web/private/trust/policies/{version})
+	GetTrustPolicy(ctx echo.Context, version int) error
+
+	// (POST /web/private/trust/policies/{version}/rollback)
+	RollbackTrustPolicy(ctx echo.Context, version int, params RollbackTrustPolicyParams) error
+
 	// (POST /web/private/vc)
 	IssueVC(ctx echo.Context) error
 
+	// (POST /web/private/vc/preview)
+	PreviewVC(ctx echo.Context) error
+
+	// (POST /web/private/vc/revoke)
+	RevokeVCs(ctx echo.Context) error
+
+	// (POST /web/private/vc/search)
+	SearchVCs(ctx echo.Context) error
+
 	// (GET /web/private/vc/templates)
 	GetVCTemplates(ctx echo.Context) error
+
+	// (POST /web/private/vc/templates)
+	CreateVCTemplate(ctx echo.Context) error
+
+	// (DELETE /web/private/vc/templates/{type})
+	DeleteVCTemplate(ctx echo.Context, pType string) error
+
+	// (GET /web/private/vc/templates/{type})
+	GetVCTemplate(ctx echo.Context, pType string) error
+
+	// (PUT /web/private/vc/templates/{type})
+	UpdateVCTemplate(ctx echo.Context, pType string) error
+
+	// (POST /web/private/vc/templates/{type}/issue)
+	IssueVCFromTemplate(ctx echo.Context, pType string) error
 }
 
 // ServerInterfaceWrapper converts echo contexts to parameters.
@@ -125,12 +227,48 @@ func (w *ServerInterfaceWrapper) UpdateCredentialIssuer(ctx echo.Context) error
 	return err
 }
 
+// GetExpiringCredentials converts echo context to params.
+func (w *ServerInterfaceWrapper) GetExpiringCredentials(ctx echo.Context) error {
+	var err error
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params GetExpiringCredentialsParams
+	// ------------- Optional query parameter "days" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "days", ctx.QueryParams(), &params.Days)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter days: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshalled arguments
+	err = w.Handler.GetExpiringCredentials(ctx, params)
+	return err
+}
+
 // GetCredentialIssuers converts echo context to params.
 func (w *ServerInterfaceWrapper) GetCredentialIssuers(ctx echo.Context) error {
 	var err error
 
+	// Parameter object where we will unmarshal all parameters from the context
+	var params GetCredentialIssuersParams
+	// ------------- Optional query parameter "type" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "type", ctx.QueryParams(), &params.Type)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter type: %s", err))
+	}
+
 	// Invoke the callback with all the unmarshalled arguments
-	err = w.Handler.GetCredentialIssuers(ctx)
+	err = w.Handler.GetCredentialIssuers(ctx, params)
+	return err
+}
+
+// RenewCredentials converts echo context to params.
+func (w *ServerInterfaceWrapper) RenewCredentials(ctx echo.Context) error {
+	var err error
+
+	// Invoke the callback with all the unmarshalled arguments
+	err = w.Handler.RenewCredentials(ctx)
 	return err
 }
 
@@ -152,6 +290,15 @@ func (w *ServerInterfaceWrapper) ConnectCustomer(ctx echo.Context) error {
 	return err
 }
 
+// ActivateCustomers converts echo context to params.
+func (w *ServerInterfaceWrapper) ActivateCustomers(ctx echo.Context) error {
+	var err error
+
+	// Invoke the callback with all the unmarshalled arguments
+	err = w.Handler.ActivateCustomers(ctx)
+	return err
+}
+
 // GetCustomer converts echo context to params.
 func (w *ServerInterfaceWrapper) GetCustomer(ctx echo.Context) error {
 	var err error
@@ -184,6 +331,78 @@ func (w *ServerInterfaceWrapper) UpdateCustomer(ctx echo.Context) error {
 	return err
 }
 
+// GetCustomerAuthorizations converts echo context to params.
+func (w *ServerInterfaceWrapper) GetCustomerAuthorizations(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "id" -------------
+	var id int
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "id", runtime.ParamLocationPath, ctx.Param("id"), &id)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshalled arguments
+	err = w.Handler.GetCustomerAuthorizations(ctx, id)
+	return err
+}
+
+// IssueAuthorization converts echo context to params.
+func (w *ServerInterfaceWrapper) IssueAuthorization(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "id" -------------
+	var id int
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "id", runtime.ParamLocationPath, ctx.Param("id"), &id)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshalled arguments
+	err = w.Handler.IssueAuthorization(ctx, id)
+	return err
+}
+
+// GetCustomerCredentials converts echo context to params.
+func (w *ServerInterfaceWrapper) GetCustomerCredentials(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "id" -------------
+	var id int
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "id", runtime.ParamLocationPath, ctx.Param("id"), &id)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshalled arguments
+	err = w.Handler.GetCustomerCredentials(ctx, id)
+	return err
+}
+
+// RevokeCustomerCredential converts echo context to params.
+func (w *ServerInterfaceWrapper) RevokeCustomerCredential(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "id" -------------
+	var id int
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "id", runtime.ParamLocationPath, ctx.Param("id"), &id)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
+	}
+
+	// ------------- Path parameter "credentialId" -------------
+	var credentialId string
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "credentialId", runtime.ParamLocationPath, ctx.Param("credentialId"), &credentialId)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter credentialId: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshalled arguments
+	err = w.Handler.RevokeCustomerCredential(ctx, id, credentialId)
+	return err
+}
+
 // GetServicesForCustomer converts echo context to params.
 func (w *ServerInterfaceWrapper) GetServicesForCustomer(ctx echo.Context) error {
 	var err error
@@ -240,6 +459,55 @@ func (w *ServerInterfaceWrapper) DisableCustomerService(ctx echo.Context) error
 	return err
 }
 
+// UpdateCustomerService converts echo context to params.
+func (w *ServerInterfaceWrapper) UpdateCustomerService(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "id" -------------
+	var id int
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "id", runtime.ParamLocationPath, ctx.Param("id"), &id)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
+	}
+
+	// ------------- Path parameter "type" -------------
+	var pType string
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "type", runtime.ParamLocationPath, ctx.Param("type"), &pType)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter type: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshalled arguments
+	err = w.Handler.UpdateCustomerService(ctx, id, pType)
+	return err
+}
+
+// GetJobs converts echo context to params.
+func (w *ServerInterfaceWrapper) GetJobs(ctx echo.Context) error {
+	var err error
+
+	// Invoke the callback with all the unmarshalled arguments
+	err = w.Handler.GetJobs(ctx)
+	return err
+}
+
+// GetJob converts echo context to params.
+func (w *ServerInterfaceWrapper) GetJob(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "id" -------------
+	var id string
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "id", runtime.ParamLocationPath, ctx.Param("id"), &id)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshalled arguments
+	err = w.Handler.GetJob(ctx, id)
+	return err
+}
+
 // SearchOrganizations converts echo context to params.
 func (w *ServerInterfaceWrapper) SearchOrganizations(ctx echo.Context) error {
 	var err error
@@ -249,6 +517,77 @@ func (w *ServerInterfaceWrapper) SearchOrganizations(ctx echo.Context) error {
 	return err
 }
 
+// SearchOrganizationDirectory converts echo context to params.
+func (w *ServerInterfaceWrapper) SearchOrganizationDirectory(ctx echo.Context) error {
+	var err error
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params SearchOrganizationDirectoryParams
+	// ------------- Optional query parameter "query" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "query", ctx.QueryParams(), &params.Query)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter query: %s", err))
+	}
+
+	// ------------- Optional query parameter "city" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "city", ctx.QueryParams(), &params.City)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter city: %s", err))
+	}
+
+	// ------------- Optional query parameter "trust" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "trust", ctx.QueryParams(), &params.Trust)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter trust: %s", err))
+	}
+
+	// ------------- Optional query parameter "service" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "service", ctx.QueryParams(), &params.Service)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter service: %s", err))
+	}
+
+	// ------------- Optional query parameter "page" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "page", ctx.QueryParams(), &params.Page)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter page: %s", err))
+	}
+
+	// ------------- Optional query parameter "pageSize" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "pageSize", ctx.QueryParams(), &params.PageSize)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter pageSize: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshalled arguments
+	err = w.Handler.SearchOrganizationDirectory(ctx, params)
+	return err
+}
+
+// GetReconcileReport converts echo context to params.
+func (w *ServerInterfaceWrapper) GetReconcileReport(ctx echo.Context) error {
+	var err error
+
+	// Invoke the callback with all the unmarshalled arguments
+	err = w.Handler.GetReconcileReport(ctx)
+	return err
+}
+
+// RepairDrift converts echo context to params.
+func (w *ServerInterfaceWrapper) RepairDrift(ctx echo.Context) error {
+	var err error
+
+	// Invoke the callback with all the unmarshalled arguments
+	err = w.Handler.RepairDrift(ctx)
+	return err
+}
+
 // GetServiceProvider converts echo context to params.
 func (w *ServerInterfaceWrapper) GetServiceProvider(ctx echo.Context) error {
 	var err error
@@ -267,6 +606,31 @@ func (w *ServerInterfaceWrapper) UpdateServiceProvider(ctx echo.Context) error {
 	return err
 }
 
+// GetServiceCatalog converts echo context to params.
+func (w *ServerInterfaceWrapper) GetServiceCatalog(ctx echo.Context) error {
+	var err error
+
+	// Invoke the callback with all the unmarshalled arguments
+	err = w.Handler.GetServiceCatalog(ctx)
+	return err
+}
+
+// ApplyServiceTemplate converts echo context to params.
+func (w *ServerInterfaceWrapper) ApplyServiceTemplate(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "name" -------------
+	var name string
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "name", runtime.ParamLocationPath, ctx.Param("name"), &name)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter name: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshalled arguments
+	err = w.Handler.ApplyServiceTemplate(ctx, name)
+	return err
+}
+
 // GetEndpoints converts echo context to params.
 func (w *ServerInterfaceWrapper) GetEndpoints(ctx echo.Context) error {
 	var err error
@@ -301,6 +665,15 @@ func (w *ServerInterfaceWrapper) DeleteEndpoint(ctx echo.Context) error {
 	return err
 }
 
+// SyncNutsCommService converts echo context to params.
+func (w *ServerInterfaceWrapper) SyncNutsCommService(ctx echo.Context) error {
+	var err error
+
+	// Invoke the callback with all the unmarshalled arguments
+	err = w.Handler.SyncNutsCommService(ctx)
+	return err
+}
+
 // GetServices converts echo context to params.
 func (w *ServerInterfaceWrapper) GetServices(ctx echo.Context) error {
 	var err error
@@ -319,6 +692,188 @@ func (w *ServerInterfaceWrapper) AddService(ctx echo.Context) error {
 	return err
 }
 
+// GetBrokenServiceReferences converts echo context to params.
+func (w *ServerInterfaceWrapper) GetBrokenServiceReferences(ctx echo.Context) error {
+	var err error
+
+	// Invoke the callback with all the unmarshalled arguments
+	err = w.Handler.GetBrokenServiceReferences(ctx)
+	return err
+}
+
+// DeleteService converts echo context to params.
+func (w *ServerInterfaceWrapper) DeleteService(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "id" -------------
+	var id string
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "id", runtime.ParamLocationPath, ctx.Param("id"), &id)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
+	}
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params DeleteServiceParams
+	// ------------- Optional query parameter "cascade" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "cascade", ctx.QueryParams(), &params.Cascade)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter cascade: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshalled arguments
+	err = w.Handler.DeleteService(ctx, id, params)
+	return err
+}
+
+// UpdateService converts echo context to params.
+func (w *ServerInterfaceWrapper) UpdateService(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "id" -------------
+	var id string
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "id", runtime.ParamLocationPath, ctx.Param("id"), &id)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshalled arguments
+	err = w.Handler.UpdateService(ctx, id)
+	return err
+}
+
+// StartRollout converts echo context to params.
+func (w *ServerInterfaceWrapper) StartRollout(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "type" -------------
+	var pType string
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "type", runtime.ParamLocationPath, ctx.Param("type"), &pType)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter type: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshalled arguments
+	err = w.Handler.StartRollout(ctx, pType)
+	return err
+}
+
+// GetRollout converts echo context to params.
+func (w *ServerInterfaceWrapper) GetRollout(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "type" -------------
+	var pType string
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "type", runtime.ParamLocationPath, ctx.Param("type"), &pType)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter type: %s", err))
+	}
+
+	// ------------- Path parameter "id" -------------
+	var id string
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "id", runtime.ParamLocationPath, ctx.Param("id"), &id)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshalled arguments
+	err = w.Handler.GetRollout(ctx, pType, id)
+	return err
+}
+
+// ResumeRollout converts echo context to params.
+func (w *ServerInterfaceWrapper) ResumeRollout(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "type" -------------
+	var pType string
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "type", runtime.ParamLocationPath, ctx.Param("type"), &pType)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter type: %s", err))
+	}
+
+	// ------------- Path parameter "id" -------------
+	var id string
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "id", runtime.ParamLocationPath, ctx.Param("id"), &id)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshalled arguments
+	err = w.Handler.ResumeRollout(ctx, pType, id)
+	return err
+}
+
+// GetTrustPolicies converts echo context to params.
+func (w *ServerInterfaceWrapper) GetTrustPolicies(ctx echo.Context) error {
+	var err error
+
+	// Invoke the callback with all the unmarshalled arguments
+	err = w.Handler.GetTrustPolicies(ctx)
+	return err
+}
+
+// ApplyTrustPolicy converts echo context to params.
+func (w *ServerInterfaceWrapper) ApplyTrustPolicy(ctx echo.Context) error {
+	var err error
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params ApplyTrustPolicyParams
+	// ------------- Optional query parameter "dryRun" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "dryRun", ctx.QueryParams(), &params.DryRun)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter dryRun: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshalled arguments
+	err = w.Handler.ApplyTrustPolicy(ctx, params)
+	return err
+}
+
+// GetTrustPolicy converts echo context to params.
+func (w *ServerInterfaceWrapper) GetTrustPolicy(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "version" -------------
+	var version int
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "version", runtime.ParamLocationPath, ctx.Param("version"), &version)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter version: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshalled arguments
+	err = w.Handler.GetTrustPolicy(ctx, version)
+	return err
+}
+
+// RollbackTrustPolicy converts echo context to params.
+func (w *ServerInterfaceWrapper) RollbackTrustPolicy(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "version" -------------
+	var version int
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "version", runtime.ParamLocationPath, ctx.Param("version"), &version)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter version: %s", err))
+	}
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params RollbackTrustPolicyParams
+	// ------------- Optional query parameter "dryRun" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "dryRun", ctx.QueryParams(), &params.DryRun)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter dryRun: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshalled arguments
+	err = w.Handler.RollbackTrustPolicy(ctx, version, params)
+	return err
+}
+
 // IssueVC converts echo context to params.
 func (w *ServerInterfaceWrapper) IssueVC(ctx echo.Context) error {
 	var err error
@@ -328,6 +883,33 @@ func (w *ServerInterfaceWrapper) IssueVC(ctx echo.Context) error {
 	return err
 }
 
+// PreviewVC converts echo context to params.
+func (w *ServerInterfaceWrapper) PreviewVC(ctx echo.Context) error {
+	var err error
+
+	// Invoke the callback with all the unmarshalled arguments
+	err = w.Handler.PreviewVC(ctx)
+	return err
+}
+
+// RevokeVCs converts echo context to params.
+func (w *ServerInterfaceWrapper) RevokeVCs(ctx echo.Context) error {
+	var err error
+
+	// Invoke the callback with all the unmarshalled arguments
+	err = w.Handler.RevokeVCs(ctx)
+	return err
+}
+
+// SearchVCs converts echo context to params.
+func (w *ServerInterfaceWrapper) SearchVCs(ctx echo.Context) error {
+	var err error
+
+	// Invoke the callback with all the unmarshalled arguments
+	err = w.Handler.SearchVCs(ctx)
+	return err
+}
+
 // GetVCTemplates converts echo context to params.
 func (w *ServerInterfaceWrapper) GetVCTemplates(ctx echo.Context) error {
 	var err error
@@ -337,6 +919,79 @@ func (w *ServerInterfaceWrapper) GetVCTemplates(ctx echo.Context) error {
 	return err
 }
 
+// CreateVCTemplate converts echo context to params.
+func (w *ServerInterfaceWrapper) CreateVCTemplate(ctx echo.Context) error {
+	var err error
+
+	// Invoke the callback with all the unmarshalled arguments
+	err = w.Handler.CreateVCTemplate(ctx)
+	return err
+}
+
+// DeleteVCTemplate converts echo context to params.
+func (w *ServerInterfaceWrapper) DeleteVCTemplate(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "type" -------------
+	var pType string
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "type", runtime.ParamLocationPath, ctx.Param("type"), &pType)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter type: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshalled arguments
+	err = w.Handler.DeleteVCTemplate(ctx, pType)
+	return err
+}
+
+// GetVCTemplate converts echo context to params.
+func (w *ServerInterfaceWrapper) GetVCTemplate(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "type" -------------
+	var pType string
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "type", runtime.ParamLocationPath, ctx.Param("type"), &pType)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter type: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshalled arguments
+	err = w.Handler.GetVCTemplate(ctx, pType)
+	return err
+}
+
+// UpdateVCTemplate converts echo context to params.
+func (w *ServerInterfaceWrapper) UpdateVCTemplate(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "type" -------------
+	var pType string
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "type", runtime.ParamLocationPath, ctx.Param("type"), &pType)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter type: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshalled arguments
+	err = w.Handler.UpdateVCTemplate(ctx, pType)
+	return err
+}
+
+// IssueVCFromTemplate converts echo context to params.
+func (w *ServerInterfaceWrapper) IssueVCFromTemplate(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "type" -------------
+	var pType string
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "type", runtime.ParamLocationPath, ctx.Param("type"), &pType)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter type: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshalled arguments
+	err = w.Handler.IssueVCFromTemplate(ctx, pType)
+	return err
+}
+
 // This is a simple interface which specifies echo.Route addition functions which
 // are present on both echo.Echo and echo.Group, since we want to allow using
 // either of them for path registration
@@ -368,23 +1023,57 @@ func RegisterHandlersWithBaseURL(router EchoRouter, si ServerInterface, baseURL
 	router.POST(baseURL+"/web/auth", wrapper.CreateSession)
 	router.GET(baseURL+"/web/private", wrapper.CheckSession)
 	router.PUT(baseURL+"/web/private/credential/:type/issuer/:did", wrapper.UpdateCredentialIssuer)
+	router.GET(baseURL+"/web/private/credentials/expiring", wrapper.GetExpiringCredentials)
 	router.GET(baseURL+"/web/private/credentials/issuers", wrapper.GetCredentialIssuers)
+	router.POST(baseURL+"/web/private/credentials/renew", wrapper.RenewCredentials)
 	router.GET(baseURL+"/web/private/customers", wrapper.GetCustomers)
 	router.POST(baseURL+"/web/private/customers", wrapper.ConnectCustomer)
+	router.POST(baseURL+"/web/private/customers/activate", wrapper.ActivateCustomers)
 	router.GET(baseURL+"/web/private/customers/:id", wrapper.GetCustomer)
 	router.PUT(baseURL+"/web/private/customers/:id", wrapper.UpdateCustomer)
+	router.GET(baseURL+"/web/private/customers/:id/authorizations", wrapper.GetCustomerAuthorizations)
+	router.POST(baseURL+"/web/private/customers/:id/authorizations", wrapper.IssueAuthorization)
+	router.GET(baseURL+"/web/private/customers/:id/credentials", wrapper.GetCustomerCredentials)
+	router.DELETE(baseURL+"/web/private/customers/:id/credentials/:credentialId", wrapper.RevokeCustomerCredential)
 	router.GET(baseURL+"/web/private/customers/:id/services", wrapper.GetServicesForCustomer)
 	router.POST(baseURL+"/web/private/customers/:id/services", wrapper.EnableCustomerService)
 	router.DELETE(baseURL+"/web/private/customers/:id/services/:type", wrapper.DisableCustomerService)
+	router.PUT(baseURL+"/web/private/customers/:id/services/:type", wrapper.UpdateCustomerService)
+	router.GET(baseURL+"/web/private/jobs", wrapper.GetJobs)
+	router.GET(baseURL+"/web/private/jobs/:id", wrapper.GetJob)
 	router.POST(baseURL+"/web/private/organizations", wrapper.SearchOrganizations)
+	router.GET(baseURL+"/web/private/organizations/directory", wrapper.SearchOrganizationDirectory)
+	router.GET(baseURL+"/web/private/reconcile", wrapper.GetReconcileReport)
+	router.POST(baseURL+"/web/private/reconcile", wrapper.RepairDrift)
 	router.GET(baseURL+"/web/private/service-provider", wrapper.GetServiceProvider)
 	router.PUT(baseURL+"/web/private/service-provider", wrapper.UpdateServiceProvider)
+	router.GET(baseURL+"/web/private/service-provider/catalog", wrapper.GetServiceCatalog)
+	router.POST(baseURL+"/web/private/service-provider/catalog/:name/apply", wrapper.ApplyServiceTemplate)
 	router.GET(baseURL+"/web/private/service-provider/endpoints", wrapper.GetEndpoints)
 	router.POST(baseURL+"/web/private/service-provider/endpoints", wrapper.RegisterEndpoint)
 	router.DELETE(baseURL+"/web/private/service-provider/endpoints/:id", wrapper.DeleteEndpoint)
+	router.POST(baseURL+"/web/private/service-provider/nutscomm-sync", wrapper.SyncNutsCommService)
 	router.GET(baseURL+"/web/private/service-provider/services", wrapper.GetServices)
 	router.POST(baseURL+"/web/private/service-provider/services", wrapper.AddService)
+	router.GET(baseURL+"/web/private/service-provider/services/broken-references", wrapper.GetBrokenServiceReferences)
+	router.DELETE(baseURL+"/web/private/service-provider/services/:id", wrapper.DeleteService)
+	router.PUT(baseURL+"/web/private/service-provider/services/:id", wrapper.UpdateService)
+	router.POST(baseURL+"/web/private/services/:type/rollout", wrapper.StartRollout)
+	router.GET(baseURL+"/web/private/services/:type/rollout/:id", wrapper.GetRollout)
+	router.POST(baseURL+"/web/private/services/:type/rollout/:id/resume", wrapper.ResumeRollout)
+	router.GET(baseURL+"/web/private/trust/policies", wrapper.GetTrustPolicies)
+	router.POST(baseURL+"/web/private/trust/policies", wrapper.ApplyTrustPolicy)
+	router.GET(baseURL+"/web/private/trust/policies/:version", wrapper.GetTrustPolicy)
+	router.POST(baseURL+"/web/private/trust/policies/:version/rollback", wrapper.RollbackTrustPolicy)
 	router.POST(baseURL+"/web/private/vc", wrapper.IssueVC)
+	router.POST(baseURL+"/web/private/vc/preview", wrapper.PreviewVC)
+	router.POST(baseURL+"/web/private/vc/revoke", wrapper.RevokeVCs)
+	router.POST(baseURL+"/web/private/vc/search", wrapper.SearchVCs)
 	router.GET(baseURL+"/web/private/vc/templates", wrapper.GetVCTemplates)
+	router.POST(baseURL+"/web/private/vc/templates", wrapper.CreateVCTemplate)
+	router.DELETE(baseURL+"/web/private/vc/templates/:type", wrapper.DeleteVCTemplate)
+	router.GET(baseURL+"/web/private/vc/templates/:type", wrapper.GetVCTemplate)
+	router.PUT(baseURL+"/web/private/vc/templates/:type", wrapper.UpdateVCTemplate)
+	router.POST(baseURL+"/web/private/vc/templates/:type/issue", wrapper.IssueVCFromTemplate)
 
 }
@@ -0,0 +1,55 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/nuts-foundation/nuts-registry-admin-demo/domain"
+	"github.com/nuts-foundation/nuts-registry-admin-demo/domain/credentials"
+	"github.com/nuts-foundation/nuts-registry-admin-demo/domain/customers"
+	"github.com/nuts-foundation/nuts-registry-admin-demo/domain/jobs"
+)
+
+func (w Wrapper) GetJobs(ctx echo.Context) error {
+	result, err := w.Jobs.List()
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	return ctx.JSON(http.StatusOK, result)
+}
+
+func (w Wrapper) GetJob(ctx echo.Context, id string) error {
+	job, err := w.Jobs.Get(id)
+	if errors.Is(err, jobs.ErrNotFound) {
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	}
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	return ctx.JSON(http.StatusOK, job)
+}
+
+// ActivateCustomers issues NutsOrganizationCredentials for the selected customers in the background.
+func (w Wrapper) ActivateCustomers(ctx echo.Context) error {
+	selector := domain.CustomerSelector{}
+	if err := ctx.Bind(&selector); err != nil {
+		return err
+	}
+	selected, err := w.CustomerService.SelectCustomers(selector)
+	if errors.Is(err, customers.ErrInvalidSelector) {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	customerIDs := make([]int, len(selected))
+	for i, customer := range selected {
+		customerIDs[i] = customer.Id
+	}
+	job, err := w.Jobs.Submit(credentials.ActivateCustomersJobType, credentials.ActivateCustomersJobPayload{CustomerIDs: customerIDs})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	return ctx.JSON(http.StatusAccepted, job)
+}
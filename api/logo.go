@@ -5,35 +5,68 @@ import (
 	"github.com/sirupsen/logrus"
 	"net/http"
 	"os"
+	"sync"
+	"time"
 )
 
 type LogoHandler struct {
-	FilePath    string
+	filePath    string
+	mutex       sync.Mutex
+	modTime     time.Time
 	contentType string
 	data        []byte
 }
 
+// NewLogoHandler returns a handler serving the logo at the given path. If the path is empty, no logo is served.
+func NewLogoHandler(filePath string) *LogoHandler {
+	return &LogoHandler{filePath: filePath}
+}
+
+// SetFilePath changes the logo which is served. If the path is empty, no logo is served.
+func (h *LogoHandler) SetFilePath(filePath string) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	if h.filePath != filePath {
+		h.filePath = filePath
+		h.data = nil
+	}
+}
+
 func (h *LogoHandler) Handle(context echo.Context) error {
-	if len(h.FilePath) == 0 {
-		// No logo configured
+	contentType, data := h.logo()
+	if len(data) == 0 {
+		// No logo configured, or unable to read the logo file
 		context.Response().WriteHeader(http.StatusNotFound)
 		return nil
 	}
-	// We cache the logo file. We don't need to worry about race conditions because if those happen,
-	// cached contentType and/or data is just overwritten with the same data. So no need for locking here.
-	var err error
-	if h.contentType == "" || len(h.data) == 0 {
-		h.data, err = os.ReadFile(h.FilePath)
-		if err != nil {
-			// Unable to read logo file
-			logrus.Errorf("Unable to read logo file (path=%s): %v", h.FilePath, err)
-			context.Response().WriteHeader(http.StatusNotFound)
-			return nil
-		}
-		h.contentType = http.DetectContentType(h.data)
-	}
-	context.Response().Header().Set("Content-Type", h.contentType)
+	context.Response().Header().Set("Content-Type", contentType)
 	context.Response().WriteHeader(http.StatusOK)
-	_, err = context.Response().Write(h.data)
+	_, err := context.Response().Write(data)
 	return err
 }
+
+// logo returns the content type and data of the logo. The logo file is cached until it's modified.
+func (h *LogoHandler) logo() (string, []byte) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	if len(h.filePath) == 0 {
+		return "", nil
+	}
+	info, err := os.Stat(h.filePath)
+	if err != nil {
+		logrus.Errorf("Unable to read logo file (path=%s): %v", h.filePath, err)
+		return "", nil
+	}
+	if len(h.data) > 0 && info.ModTime().Equal(h.modTime) {
+		return h.contentType, h.data
+	}
+	data, err := os.ReadFile(h.filePath)
+	if err != nil {
+		logrus.Errorf("Unable to read logo file (path=%s): %v", h.filePath, err)
+		return "", nil
+	}
+	h.data = data
+	h.modTime = info.ModTime()
+	h.contentType = http.DetectContentType(data)
+	return h.contentType, h.data
+}
@@ -0,0 +1,23 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+func (w Wrapper) GetReconcileReport(ctx echo.Context) error {
+	report, err := w.ReconcileService.Check()
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	return ctx.JSON(http.StatusOK, report)
+}
+
+func (w Wrapper) RepairDrift(ctx echo.Context) error {
+	job, err := w.ReconcileService.Repair()
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	return ctx.JSON(http.StatusAccepted, job)
+}
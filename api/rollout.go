@@ -0,0 +1,60 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/nuts-foundation/nuts-registry-admin-demo/domain"
+	"github.com/nuts-foundation/nuts-registry-admin-demo/domain/rollout"
+)
+
+func (w Wrapper) StartRollout(ctx echo.Context, serviceType string) error {
+	request := domain.RolloutRequest{}
+	if err := ctx.Bind(&request); err != nil {
+		return err
+	}
+	result, err := w.RolloutService.Start(serviceType, request)
+	if err != nil {
+		return rolloutErrorResponse(err)
+	}
+	return ctx.JSON(http.StatusAccepted, result)
+}
+
+func (w Wrapper) GetRollout(ctx echo.Context, serviceType string, id string) error {
+	result, err := w.RolloutService.Get(id)
+	if err != nil {
+		return rolloutErrorResponse(err)
+	}
+	if result.ServiceType != serviceType {
+		return echo.NewHTTPError(http.StatusNotFound, rollout.ErrNotFound.Error())
+	}
+	return ctx.JSON(http.StatusOK, result)
+}
+
+func (w Wrapper) ResumeRollout(ctx echo.Context, serviceType string, id string) error {
+	existing, err := w.RolloutService.Get(id)
+	if err != nil {
+		return rolloutErrorResponse(err)
+	}
+	if existing.ServiceType != serviceType {
+		return echo.NewHTTPError(http.StatusNotFound, rollout.ErrNotFound.Error())
+	}
+	result, err := w.RolloutService.Resume(id)
+	if err != nil {
+		return rolloutErrorResponse(err)
+	}
+	return ctx.JSON(http.StatusAccepted, result)
+}
+
+func rolloutErrorResponse(err error) error {
+	switch {
+	case errors.Is(err, rollout.ErrInvalidRequest):
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	case errors.Is(err, rollout.ErrNotFound):
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	case errors.Is(err, rollout.ErrRunning):
+		return echo.NewHTTPError(http.StatusConflict, err.Error())
+	}
+	return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+}
@@ -1,38 +1,21 @@
 package api
 
 import (
+	"errors"
 	"fmt"
-	"log"
 	"net/http"
-	"sync"
 
 	"github.com/labstack/echo/v4"
 	ssi "github.com/nuts-foundation/go-did"
 	"github.com/nuts-foundation/nuts-registry-admin-demo/domain"
+	"github.com/nuts-foundation/nuts-registry-admin-demo/domain/customers"
+	"github.com/nuts-foundation/nuts-registry-admin-demo/domain/sp"
+	"github.com/sirupsen/logrus"
 )
 
-func (w Wrapper) syncRegisterNutsCommService(spID string) error {
-	customers, err := w.CustomerService.Repository.All()
-	if err != nil {
-		return err
-	}
-
-	wc := sync.WaitGroup{}
-	wc.Add(len(customers))
-
-	for _, customer := range customers {
-		go func(id int) {
-			defer wc.Done()
-
-			if err := w.CustomerService.RegisterNutsCommService(id, spID); err != nil {
-				log.Printf("Couldn't register NutsComm endpoint on customer DID (id=%d): %v", id, err.Error())
-			}
-		}(customer.Id)
-	}
-
-	wc.Wait()
-
-	return nil
+// submitNutsCommSync submits a background job which registers the NutsComm service on all customers' DID documents.
+func (w Wrapper) submitNutsCommSync(spID string) (*domain.Job, error) {
+	return w.Jobs.Submit(customers.SyncNutsCommJobType, customers.SyncNutsCommJobPayload{ServiceProviderID: spID})
 }
 
 func (w Wrapper) GetServiceProvider(ctx echo.Context) error {
@@ -57,10 +40,15 @@ func (w Wrapper) UpdateServiceProvider(ctx echo.Context) error {
 	if err != nil {
 		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 	}
+	// The contact information of the service provider is shown when it's an issuer
+	if w.CredentialService.IssuerCache != nil {
+		w.CredentialService.IssuerCache.Invalidate(res.Id)
+	}
 
 	// Make sure NutsComm service is registered on customers' DID documents
-	if err := w.syncRegisterNutsCommService(serviceProvider.Id); err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	if _, err := w.submitNutsCommSync(res.Id); err != nil {
+		logrus.Errorf("Unable to submit NutsComm sync job (spID=%s): %v", res.Id, err)
+		return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("service provider is updated, but registering its NutsComm service for customers failed: %s", err))
 	}
 
 	return ctx.JSON(http.StatusOK, res)
@@ -83,15 +71,35 @@ func (w Wrapper) RegisterEndpoint(ctx echo.Context) error {
 		if err != nil {
 			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 		}
+		if sp == nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "service provider not configured")
+		}
 
-		if err := w.syncRegisterNutsCommService(sp.Id); err != nil {
-			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		if _, err := w.submitNutsCommSync(sp.Id); err != nil {
+			logrus.Errorf("Unable to submit NutsComm sync job (spID=%s): %v", sp.Id, err)
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("endpoint is registered, but registering it for customers failed: %s", err))
 		}
 	}
 
 	return ctx.NoContent(http.StatusCreated)
 }
 
+// SyncNutsCommService (re)registers the NutsComm service on all customers' DID documents in the background.
+func (w Wrapper) SyncNutsCommService(ctx echo.Context) error {
+	serviceProvider, err := w.SPService.Get()
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	if serviceProvider == nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "service provider not configured")
+	}
+	job, err := w.submitNutsCommSync(serviceProvider.Id)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	return ctx.JSON(http.StatusAccepted, job)
+}
+
 func (w Wrapper) DeleteEndpoint(ctx echo.Context, idStr string) error {
 	id, err := ssi.ParseURI(idStr)
 	if err != nil {
@@ -128,10 +136,147 @@ func (w Wrapper) GetServices(ctx echo.Context) error {
 
 func (w Wrapper) AddService(ctx echo.Context) error {
 	service := domain.ServiceProperties{}
-	ctx.Bind(&service)
+	if err := ctx.Bind(&service); err != nil {
+		return err
+	}
 	addedService, err := w.SPService.AddService(service)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return serviceErrorResponse(err)
 	}
 	return ctx.JSON(http.StatusOK, addedService)
 }
+
+func (w Wrapper) GetServiceCatalog(ctx echo.Context) error {
+	// make sure the response is always initialized to ensure [] instead of null json
+	response := make([]domain.ServiceTemplate, len(w.SPService.Catalog))
+	copy(response, w.SPService.Catalog)
+	return ctx.JSON(http.StatusOK, response)
+}
+
+func (w Wrapper) ApplyServiceTemplate(ctx echo.Context, name string) error {
+	request := domain.ApplyServiceTemplateRequest{}
+	if err := ctx.Bind(&request); err != nil {
+		return err
+	}
+	service, err := w.SPService.ApplyTemplate(name, request.Endpoints)
+	if err != nil {
+		return serviceErrorResponse(err)
+	}
+	return ctx.JSON(http.StatusOK, service)
+}
+
+func (w Wrapper) GetBrokenServiceReferences(ctx echo.Context) error {
+	brokenReferences, err := w.SPService.CheckServiceReferences()
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	return ctx.JSON(http.StatusOK, brokenReferences)
+}
+
+func (w Wrapper) UpdateService(ctx echo.Context, idStr string) error {
+	id, err := ssi.ParseURI(idStr)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Errorf("invalid service ID: %w", err))
+	}
+	service := domain.ServiceProperties{}
+	if err := ctx.Bind(&service); err != nil {
+		return err
+	}
+
+	current, err := w.SPService.GetService(*id)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	if current == nil {
+		return ctx.NoContent(http.StatusNotFound)
+	}
+	// Customers refer to the compound service by its type, renaming it would break those references
+	if current.Name != service.Name {
+		referringCustomers, err := w.findServiceReferences(current.Name)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+		if len(referringCustomers) > 0 {
+			return echo.NewHTTPError(http.StatusConflict, fmt.Sprintf("unable to rename compound service, it is referred to by customers: %v", customerIDs(referringCustomers)))
+		}
+	}
+
+	updatedService, err := w.SPService.UpdateService(*id, service)
+	if err != nil {
+		return serviceErrorResponse(err)
+	}
+	return ctx.JSON(http.StatusOK, updatedService)
+}
+
+func (w Wrapper) DeleteService(ctx echo.Context, idStr string, params DeleteServiceParams) error {
+	id, err := ssi.ParseURI(idStr)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Errorf("invalid service ID: %w", err))
+	}
+
+	current, err := w.SPService.GetService(*id)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	if current == nil {
+		return ctx.NoContent(http.StatusNotFound)
+	}
+
+	referringCustomers, err := w.findServiceReferences(current.Name)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	if len(referringCustomers) > 0 {
+		if params.Cascade == nil || !*params.Cascade {
+			return echo.NewHTTPError(http.StatusConflict, fmt.Sprintf("unable to delete compound service, it is referred to by customers: %v", customerIDs(referringCustomers)))
+		}
+		// The lookup above succeeded for every customer, so only removing a reference can fail halfway.
+		// In that case the customers from which the reference was already removed are reported, and the compound service is kept.
+		var changed []int
+		for _, customer := range referringCustomers {
+			if err := w.CustomerService.DisableService(customer.Id, current.Name); err != nil {
+				return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("unable to remove service reference from customer (id=%d), compound service was not deleted (references already removed from customers: %v): %s", customer.Id, changed, err))
+			}
+			changed = append(changed, customer.Id)
+		}
+	}
+
+	if err := w.SPService.DeleteService(*id); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+// findServiceReferences returns the customers that refer to the service provider's compound service of the given type.
+func (w Wrapper) findServiceReferences(serviceType string) ([]domain.Customer, error) {
+	serviceProvider, err := w.SPService.Get()
+	if err != nil {
+		return nil, err
+	}
+	if serviceProvider == nil {
+		return nil, nil
+	}
+	return w.CustomerService.FindServiceReferences(serviceProvider.Id, serviceType)
+}
+
+// serviceErrorResponse maps errors from adding or updating a compound service to an HTTP error.
+func serviceErrorResponse(err error) error {
+	var brokenReferencesErr sp.BrokenReferencesError
+	switch {
+	case errors.As(err, &brokenReferencesErr):
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	case errors.Is(err, sp.ErrTemplateNotFound):
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	case errors.Is(err, sp.ErrServiceExists):
+		return echo.NewHTTPError(http.StatusConflict, err.Error())
+	}
+	return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+}
+
+func customerIDs(customers []domain.Customer) []int {
+	result := make([]int, len(customers))
+	for i, customer := range customers {
+		result[i] = customer.Id
+	}
+	return result
+}
@@ -0,0 +1,62 @@
+package api
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/nuts-foundation/nuts-registry-admin-demo/domain/trust"
+)
+
+func (w Wrapper) GetTrustPolicies(ctx echo.Context) error {
+	result, err := w.TrustService.All()
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	return ctx.JSON(http.StatusOK, result)
+}
+
+func (w Wrapper) GetTrustPolicy(ctx echo.Context, version int) error {
+	result, err := w.TrustService.Get(version)
+	if err != nil {
+		return trustErrorResponse(err)
+	}
+	return ctx.JSON(http.StatusOK, result)
+}
+
+// ApplyTrustPolicy applies a trust policy, which is read from the body as YAML or JSON.
+func (w Wrapper) ApplyTrustPolicy(ctx echo.Context, params ApplyTrustPolicyParams) error {
+	data, err := io.ReadAll(ctx.Request().Body)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	policy, err := trust.ParsePolicy(data)
+	if err != nil {
+		return trustErrorResponse(err)
+	}
+	result, err := w.TrustService.Apply(*policy, params.DryRun != nil && *params.DryRun)
+	if err != nil {
+		return trustErrorResponse(err)
+	}
+	return ctx.JSON(http.StatusOK, result)
+}
+
+func (w Wrapper) RollbackTrustPolicy(ctx echo.Context, version int, params RollbackTrustPolicyParams) error {
+	result, err := w.TrustService.Rollback(version, params.DryRun != nil && *params.DryRun)
+	if err != nil {
+		return trustErrorResponse(err)
+	}
+	return ctx.JSON(http.StatusOK, result)
+}
+
+func trustErrorResponse(err error) error {
+	switch {
+	case errors.Is(err, trust.ErrInvalidPolicy):
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	case errors.Is(err, trust.ErrNotFound):
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	}
+	// Errors of the Nuts node are mapped to a status by the HTTP error handler
+	return err
+}
@@ -0,0 +1,13 @@
+package api
+
+import "github.com/nuts-foundation/nuts-registry-admin-demo/domain"
+
+// The server code is generated in this package while the types are generated in the domain package,
+// so the parameter types referred to by the generated server code are aliased here.
+
+type DeleteServiceParams = domain.DeleteServiceParams
+type GetCredentialIssuersParams = domain.GetCredentialIssuersParams
+type GetExpiringCredentialsParams = domain.GetExpiringCredentialsParams
+type ApplyTrustPolicyParams = domain.ApplyTrustPolicyParams
+type RollbackTrustPolicyParams = domain.RollbackTrustPolicyParams
+type SearchOrganizationDirectoryParams = domain.SearchOrganizationDirectoryParams
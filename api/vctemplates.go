@@ -0,0 +1,113 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/nuts-foundation/nuts-registry-admin-demo/domain"
+	"github.com/nuts-foundation/nuts-registry-admin-demo/domain/customers"
+	"github.com/nuts-foundation/nuts-registry-admin-demo/domain/vctemplates"
+)
+
+func (w Wrapper) GetVCTemplates(ctx echo.Context) error {
+	result, err := w.VCTemplateService.All()
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	return ctx.JSON(http.StatusOK, result)
+}
+
+func (w Wrapper) CreateVCTemplate(ctx echo.Context) error {
+	template := domain.VCTemplate{}
+	if err := ctx.Bind(&template); err != nil {
+		return err
+	}
+	if err := w.VCTemplateService.Create(template); err != nil {
+		return vcTemplateErrorResponse(err)
+	}
+	return ctx.JSON(http.StatusCreated, template)
+}
+
+func (w Wrapper) GetVCTemplate(ctx echo.Context, credentialType string) error {
+	template, err := w.VCTemplateService.Get(credentialType)
+	if err != nil {
+		return vcTemplateErrorResponse(err)
+	}
+	return ctx.JSON(http.StatusOK, template)
+}
+
+func (w Wrapper) UpdateVCTemplate(ctx echo.Context, credentialType string) error {
+	template := domain.VCTemplate{}
+	if err := ctx.Bind(&template); err != nil {
+		return err
+	}
+	if err := w.VCTemplateService.Update(credentialType, template); err != nil {
+		return vcTemplateErrorResponse(err)
+	}
+	return ctx.JSON(http.StatusOK, template)
+}
+
+func (w Wrapper) DeleteVCTemplate(ctx echo.Context, credentialType string) error {
+	if err := w.VCTemplateService.Delete(credentialType); err != nil {
+		return vcTemplateErrorResponse(err)
+	}
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+func (w Wrapper) IssueVCFromTemplate(ctx echo.Context, credentialType string) error {
+	request := domain.IssueVCFromTemplateRequest{}
+	if err := ctx.Bind(&request); err != nil {
+		return err
+	}
+	customer, err := w.CustomerService.Repository.FindByID(request.CustomerId)
+	if errors.Is(err, customers.ErrNotFound) {
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	}
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	serviceProvider, err := w.SPService.Get()
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	if serviceProvider == nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "service provider not configured")
+	}
+
+	issuer := serviceProvider.Id
+	if request.IssuerCustomerId != nil {
+		if issuer, err = w.customerIssuer(*request.IssuerCustomerId); err != nil {
+			return err
+		}
+	}
+
+	var parameters map[string]interface{}
+	if request.Parameters != nil {
+		parameters = *request.Parameters
+	}
+	issueRequest, err := w.VCTemplateService.IssueRequest(credentialType, issuer, *customer, parameters)
+	if err != nil {
+		return vcTemplateErrorResponse(err)
+	}
+	issuedVC, err := w.CredentialService.Issue(*issueRequest)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, *issuedVC)
+}
+
+func vcTemplateErrorResponse(err error) error {
+	var subjectErr vctemplates.SubjectError
+	switch {
+	case errors.As(err, &subjectErr):
+		return echo.NewHTTPError(http.StatusBadRequest, domain.CredentialSubjectError{Error: subjectErr.Error(), Fields: subjectErr.Fields})
+	case errors.Is(err, vctemplates.ErrInvalidTemplate), errors.Is(err, vctemplates.ErrUnresolvedPlaceholders), errors.Is(err, vctemplates.ErrCustomerWithoutDID):
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	case errors.Is(err, vctemplates.ErrNotFound):
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	case errors.Is(err, vctemplates.ErrExists):
+		return echo.NewHTTPError(http.StatusConflict, err.Error())
+	}
+	return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+}
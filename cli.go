@@ -0,0 +1,604 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/nuts-foundation/go-did/did"
+	"github.com/nuts-foundation/nuts-registry-admin-demo/domain"
+	"github.com/nuts-foundation/nuts-registry-admin-demo/domain/customers"
+	"github.com/nuts-foundation/nuts-registry-admin-demo/domain/users"
+	"github.com/spf13/pflag"
+	bolt "go.etcd.io/bbolt"
+)
+
+// dbLockTimeout is how long CLI commands wait for the database, which is locked while the server is running.
+const dbLockTimeout = 2 * time.Second
+
+const (
+	outputFlag  = "output"
+	outputTable = "table"
+	outputJSON  = "json"
+)
+
+// cliCommand is a CLI subcommand, which administers the registry without the web interface.
+type cliCommand struct {
+	// args describes the positional arguments, e.g. "<id>".
+	args        string
+	description string
+	// argCount is the number of positional arguments, or -1 for at least one.
+	argCount int
+	// flags adds the flags of the command to the flag set, if it has any.
+	flags func(f *pflag.FlagSet)
+	run   func(c *cli, f *pflag.FlagSet) error
+}
+
+// cliCommands contains the CLI subcommands by group and name, e.g. "customer" and "list".
+var cliCommands = map[string]map[string]cliCommand{
+	"customer": {
+		"list":       {description: "List the customers", run: (*cli).customerList},
+		"add":        {description: "Add a customer, creating its DID", flags: customerFlags(true), run: (*cli).customerAdd},
+		"update":     {args: "<id>", argCount: 1, description: "Update the name, city or domain of a customer", flags: customerFlags(false), run: (*cli).customerUpdate},
+		"activate":   {args: "<id>", argCount: 1, description: "Issue a NutsOrganizationCredential to a customer", run: (*cli).customerActivate},
+		"deactivate": {args: "<id>", argCount: 1, description: "Revoke the NutsOrganizationCredentials of a customer", run: (*cli).customerDeactivate},
+	},
+	"sp": {
+		"show":         {description: "Show the service provider", run: (*cli).spShow},
+		"set-endpoint": {args: "<grpc://host:port>", argCount: 1, description: "Set the Nuts node endpoint of the service provider and register it for all customers", run: (*cli).spSetEndpoint},
+	},
+	"vc": {
+		"issue":  {description: "Issue a credential", flags: vcIssueFlags, run: (*cli).vcIssue},
+		"revoke": {args: "<id>...", argCount: -1, description: "Revoke credentials", run: (*cli).vcRevoke},
+		"search": {description: "Search credentials", flags: vcSearchFlags, run: (*cli).vcSearch},
+	},
+	"trust": {
+		"list": {description: "List the trusted and untrusted issuers", flags: trustListFlags, run: (*cli).trustList},
+		"set":  {args: "<credential type> <issuer DID> trusted|untrusted", argCount: 3, description: "Trust or untrust an issuer", run: (*cli).trustSet},
+	},
+	"user": {
+		"add":    {args: "<username>", argCount: 1, description: "Add a user account, reading the password from stdin", run: (*cli).userAdd},
+		"passwd": {args: "<username>", argCount: 1, description: "Change the password of a user account, reading it from stdin", run: (*cli).userPasswd},
+	},
+}
+
+// cli contains the state of a CLI command: the config and the lazily created services.
+type cli struct {
+	config Config
+	output string
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+	db     *bolt.DB
+	svcs   *services
+}
+
+// runCLI runs the CLI subcommand in the arguments, e.g. "customer list", returning the exit code.
+func runCLI(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) < 2 {
+		printCLIUsage(stderr)
+		return 2
+	}
+	command, ok := cliCommands[args[0]][args[1]]
+	if !ok {
+		_, _ = fmt.Fprintf(stderr, "Unknown command: %s %s\n", args[0], args[1])
+		printCLIUsage(stderr)
+		return 2
+	}
+
+	flagset := newFlagSet(args[0] + " " + args[1])
+	flagset.StringP(outputFlag, "o", outputTable, "Output format: table or json")
+	if command.flags != nil {
+		command.flags(flagset)
+	}
+	flagset.Usage = func() {
+		_, _ = fmt.Fprintf(stderr, "Usage: %s %s [flags] %s\n%s\n\nFlags:\n%s", args[0], args[1], command.args, command.description, flagset.FlagUsages())
+	}
+	if err := flagset.Parse(args[2:]); errors.Is(err, pflag.ErrHelp) {
+		return 0
+	} else if err != nil {
+		_, _ = fmt.Fprintln(stderr, err)
+		flagset.Usage()
+		return 2
+	}
+	if (command.argCount >= 0 && flagset.NArg() != command.argCount) || (command.argCount < 0 && flagset.NArg() == 0) {
+		flagset.Usage()
+		return 2
+	}
+	output, _ := flagset.GetString(outputFlag)
+	if output != outputTable && output != outputJSON {
+		_, _ = fmt.Fprintf(stderr, "Invalid output format: %s\n", output)
+		return 2
+	}
+
+	config, errs := readConfig(flagset)
+	errs = append(errs, validateConfig(config)...)
+	if len(errs) > 0 {
+		_, _ = fmt.Fprintf(stderr, "Invalid config:\n%v\n", errors.Join(errs...))
+		return 1
+	}
+	applyLogLevel(config.LogLevel)
+
+	c := &cli{config: config, output: output, stdin: stdin, stdout: stdout, stderr: stderr}
+	defer c.close()
+	if err := command.run(c, flagset); err != nil {
+		_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+func printCLIUsage(writer io.Writer) {
+	_, _ = fmt.Fprintln(writer, "Usage: <command> <subcommand> [flags] [arguments]")
+	_, _ = fmt.Fprintln(writer, "Without a command, the server is started. Commands:")
+	tw := tabwriter.NewWriter(writer, 0, 4, 2, ' ', 0)
+	_, _ = fmt.Fprintf(tw, "  validate-config\t\tValidate the config and print it\n")
+	for _, group := range sortedKeys(cliCommands) {
+		for _, name := range sortedKeys(cliCommands[group]) {
+			command := cliCommands[group][name]
+			_, _ = fmt.Fprintf(tw, "  %s %s\t%s\t%s\n", group, name, command.args, command.description)
+		}
+	}
+	_ = tw.Flush()
+}
+
+// services returns the domain services, opening the database on first use.
+func (c *cli) services() (*services, error) {
+	if c.svcs != nil {
+		return c.svcs, nil
+	}
+	db, err := bolt.Open(c.config.DBFile, 0600, &bolt.Options{Timeout: dbLockTimeout})
+	if err != nil {
+		return nil, fmt.Errorf("unable to open database %s, stop the server when it's running: %w", c.config.DBFile, err)
+	}
+	c.db = db
+	c.svcs, err = newServices(c.config, db)
+	return c.svcs, err
+}
+
+func (c *cli) close() {
+	if c.db != nil {
+		_ = c.db.Close()
+	}
+}
+
+// print writes the value as JSON, or the rows as a table with the given header.
+func (c *cli) print(value interface{}, header []string, rows [][]string) error {
+	if c.output == outputJSON {
+		encoder := json.NewEncoder(c.stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(value)
+	}
+	tw := tabwriter.NewWriter(c.stdout, 0, 4, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, strings.Join(header, "\t"))
+	for _, row := range rows {
+		_, _ = fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	return tw.Flush()
+}
+
+func customerFlags(add bool) func(f *pflag.FlagSet) {
+	return func(f *pflag.FlagSet) {
+		if add {
+			f.Int("id", 0, "Internal ID of the customer (required)")
+		}
+		f.String("name", "", "Name of the customer")
+		f.String("city", "", "City of the customer")
+		f.String("domain", "", "Email domain of the customer's employees")
+	}
+}
+
+func (c *cli) customerList(_ *pflag.FlagSet) error {
+	svcs, err := c.services()
+	if err != nil {
+		return err
+	}
+	all, err := svcs.customers.Repository.All()
+	if err != nil {
+		return err
+	}
+	rows := make([][]string, len(all))
+	for i, customer := range all {
+		rows[i] = []string{strconv.Itoa(customer.Id), customer.Name, stringValue(customer.City), stringValue(customer.Domain), stringValue(customer.Did), strconv.FormatBool(customer.Active)}
+	}
+	return c.print(all, []string{"ID", "NAME", "CITY", "DOMAIN", "DID", "ACTIVE"}, rows)
+}
+
+func (c *cli) customerAdd(f *pflag.FlagSet) error {
+	id, _ := f.GetInt("id")
+	name, _ := f.GetString("name")
+	if id < 1 {
+		return errors.New("id must be > 0")
+	}
+	if len(name) == 0 {
+		return errors.New("name must be provided")
+	}
+	customer := domain.Customer{Id: id, Name: name, City: optionalString(f, "city"), Domain: optionalString(f, "domain")}
+
+	svcs, err := c.services()
+	if err != nil {
+		return err
+	}
+	spID, err := c.serviceProviderDID(svcs)
+	if err != nil {
+		return err
+	}
+	connected, err := svcs.customers.ConnectCustomer(customer, *spID)
+	if err != nil {
+		return err
+	}
+	// Make sure new customers refer to their vendor's NutsComm service
+	if err := svcs.customers.RegisterNutsCommService(connected.Id, spID.String()); err != nil {
+		return err
+	}
+	return c.printCustomer(*connected)
+}
+
+func (c *cli) customerUpdate(f *pflag.FlagSet) error {
+	id, err := strconv.Atoi(f.Arg(0))
+	if err != nil {
+		return fmt.Errorf("invalid customer ID: %w", err)
+	}
+	if name := optionalString(f, "name"); name != nil && len(*name) == 0 {
+		return errors.New("name must not be empty")
+	}
+	svcs, err := c.services()
+	if err != nil {
+		return err
+	}
+	customer, err := svcs.customers.Repository.Update(id, func(customer domain.Customer) (*domain.Customer, error) {
+		if f.Changed("name") {
+			customer.Name = *optionalString(f, "name")
+		}
+		if f.Changed("city") {
+			customer.City = optionalString(f, "city")
+		}
+		if f.Changed("domain") {
+			customer.Domain = optionalString(f, "domain")
+		}
+		return &customer, nil
+	})
+	if err != nil {
+		return err
+	}
+	return c.printCustomer(*customer)
+}
+
+func (c *cli) customerActivate(f *pflag.FlagSet) error {
+	return c.setCustomerActive(f.Arg(0), true)
+}
+
+func (c *cli) customerDeactivate(f *pflag.FlagSet) error {
+	return c.setCustomerActive(f.Arg(0), false)
+}
+
+func (c *cli) setCustomerActive(idArg string, active bool) error {
+	id, err := strconv.Atoi(idArg)
+	if err != nil {
+		return fmt.Errorf("invalid customer ID: %w", err)
+	}
+	svcs, err := c.services()
+	if err != nil {
+		return err
+	}
+	customer, err := svcs.customers.Repository.Update(id, func(customer domain.Customer) (*domain.Customer, error) {
+		customer.Active = active
+		if err := svcs.credentials.ManageNutsOrgCredential(customer, active); err != nil {
+			return nil, err
+		}
+		return &customer, nil
+	})
+	if err != nil {
+		return err
+	}
+	return c.printCustomer(*customer)
+}
+
+func (c *cli) printCustomer(customer domain.Customer) error {
+	return c.print(customer, []string{"ID", "NAME", "CITY", "DOMAIN", "DID", "ACTIVE"}, [][]string{
+		{strconv.Itoa(customer.Id), customer.Name, stringValue(customer.City), stringValue(customer.Domain), stringValue(customer.Did), strconv.FormatBool(customer.Active)},
+	})
+}
+
+func (c *cli) serviceProviderDID(svcs *services) (*did.DID, error) {
+	serviceProvider, err := svcs.sp.Get()
+	if err != nil {
+		return nil, fmt.Errorf("unable to fetch service provider ID: %w", err)
+	}
+	if serviceProvider == nil {
+		return nil, errors.New("service provider not configured")
+	}
+	spID, err := did.ParseDID(serviceProvider.Id)
+	if err != nil {
+		return nil, fmt.Errorf("service provider not correctly configured: DID is invalid: %w", err)
+	}
+	return spID, nil
+}
+
+func (c *cli) spShow(_ *pflag.FlagSet) error {
+	svcs, err := c.services()
+	if err != nil {
+		return err
+	}
+	serviceProvider, err := svcs.sp.Get()
+	if err != nil {
+		return err
+	}
+	if serviceProvider == nil {
+		return errors.New("service provider not configured")
+	}
+	return c.printServiceProvider(*serviceProvider)
+}
+
+func (c *cli) spSetEndpoint(f *pflag.FlagSet) error {
+	svcs, err := c.services()
+	if err != nil {
+		return err
+	}
+	serviceProvider, err := svcs.sp.Get()
+	if err != nil {
+		return err
+	}
+	if serviceProvider == nil {
+		return errors.New("service provider not configured")
+	}
+	serviceProvider.Endpoint = f.Arg(0)
+	updated, err := svcs.sp.CreateOrUpdate(*serviceProvider)
+	if err != nil {
+		return err
+	}
+	// Make sure NutsComm service is registered on customers' DID documents. There's no job queue, so it's done right away.
+	payload, _ := json.Marshal(customers.SyncNutsCommJobPayload{ServiceProviderID: updated.Id})
+	if err := svcs.customers.HandleSyncNutsCommJob(payload, func(processed int, total int) {
+		_, _ = fmt.Fprintf(c.stderr, "Registered NutsComm service for %d of %d customers\n", processed, total)
+	}); err != nil {
+		return fmt.Errorf("endpoint is set, but registering it for customers failed: %w", err)
+	}
+	return c.printServiceProvider(*updated)
+}
+
+func (c *cli) printServiceProvider(serviceProvider domain.ServiceProvider) error {
+	return c.print(serviceProvider, []string{"FIELD", "VALUE"}, [][]string{
+		{"id", serviceProvider.Id},
+		{"name", serviceProvider.Name},
+		{"email", serviceProvider.Email},
+		{"phone", serviceProvider.Phone},
+		{"website", serviceProvider.Website},
+		{"endpoint", serviceProvider.Endpoint},
+	})
+}
+
+func vcIssueFlags(f *pflag.FlagSet) {
+	f.String("type", "", "Type of the credential (required)")
+	f.String("subject", "", "Credential subject as JSON object, including the subject's DID as id (required)")
+	f.String("issuer", "", "DID of the issuer, defaults to the service provider")
+	f.Int("customer", 0, "Internal ID of the customer which issues the credential, instead of --issuer")
+	f.String("context", "", "JSON-LD context of the credential type, defaults to the Nuts context")
+	f.String("expiration", "", "Expiration date (RFC3339), defaults to the configured validity of the type")
+	f.String("visibility", "", "Visibility of the credential: public or private")
+}
+
+func (c *cli) vcIssue(f *pflag.FlagSet) error {
+	credentialType, _ := f.GetString("type")
+	issuer, _ := f.GetString("issuer")
+	subject, _ := f.GetString("subject")
+	request := domain.IssueVCRequest{
+		Type:           credentialType,
+		Issuer:         issuer,
+		Context:        optionalString(f, "context"),
+		ExpirationDate: optionalString(f, "expiration"),
+	}
+	if len(request.Type) == 0 {
+		return errors.New("type must be provided")
+	}
+	if err := json.Unmarshal([]byte(subject), &request.CredentialSubject); err != nil {
+		return fmt.Errorf("subject must be a JSON object: %w", err)
+	}
+	if f.Changed("visibility") {
+		visibility := domain.IssueVCRequestVisibility(*optionalString(f, "visibility"))
+		request.Visibility = &visibility
+	}
+	svcs, err := c.services()
+	if err != nil {
+		return err
+	}
+	spID, err := c.serviceProviderDID(svcs)
+	if err != nil {
+		return err
+	}
+	if f.Changed("customer") {
+		customerID, _ := f.GetInt("customer")
+		customerDID, err := svcs.customers.IssuerDID(customerID, spID.String())
+		if err != nil {
+			return err
+		}
+		if len(request.Issuer) > 0 && request.Issuer != customerDID {
+			return fmt.Errorf("issuer must be the customer's DID (%s) when issuing on behalf of a customer", customerDID)
+		}
+		request.Issuer = customerDID
+	} else if len(request.Issuer) == 0 {
+		request.Issuer = spID.String()
+	}
+	issued, err := svcs.credentials.Issue(request)
+	if err != nil {
+		return err
+	}
+	subjectDID, _ := request.CredentialSubject["id"].(string)
+	return c.print(issued, []string{"ID", "TYPE", "ISSUER", "SUBJECT"}, [][]string{
+		{issued.ID.String(), request.Type, issued.Issuer.String(), subjectDID},
+	})
+}
+
+func (c *cli) vcRevoke(f *pflag.FlagSet) error {
+	svcs, err := c.services()
+	if err != nil {
+		return err
+	}
+	results := svcs.credentials.RevokeVCs(f.Args())
+	rows := make([][]string, len(results))
+	failed := 0
+	for i, result := range results {
+		if !result.Revoked {
+			failed++
+		}
+		rows[i] = []string{result.Id, strconv.FormatBool(result.Revoked), stringValue(result.Error)}
+	}
+	if err := c.print(results, []string{"ID", "REVOKED", "ERROR"}, rows); err != nil {
+		return err
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d credentials couldn't be revoked", failed, len(results))
+	}
+	return nil
+}
+
+func vcSearchFlags(f *pflag.FlagSet) {
+	f.String("type", "", "Type of the credentials (required)")
+	f.String("issuer", "", "DID of the issuer")
+	f.String("subject", "", "DID of the subject")
+	f.String("context", "", "JSON-LD context of the credential type, defaults to the Nuts context")
+	f.Bool("trusted", false, "Only return credentials of trusted issuers")
+}
+
+func (c *cli) vcSearch(f *pflag.FlagSet) error {
+	trustedOnly, _ := f.GetBool("trusted")
+	allowUntrusted := !trustedOnly
+	credentialType, _ := f.GetString("type")
+	request := domain.SearchVCsRequest{
+		Type:                 credentialType,
+		Issuer:               optionalString(f, "issuer"),
+		Subject:              optionalString(f, "subject"),
+		Context:              optionalString(f, "context"),
+		AllowUntrustedIssuer: &allowUntrusted,
+	}
+	if len(request.Type) == 0 {
+		return errors.New("type must be provided")
+	}
+	svcs, err := c.services()
+	if err != nil {
+		return err
+	}
+	found, err := svcs.credentials.SearchVCs(request)
+	if err != nil {
+		return err
+	}
+	rows := make([][]string, len(found))
+	for i, curr := range found {
+		expires := ""
+		if curr.ExpirationDate != nil {
+			expires = curr.ExpirationDate.Format(time.RFC3339)
+		}
+		rows[i] = []string{curr.Id, curr.Type, curr.Issuer, curr.Subject, curr.IssuanceDate.Format(time.RFC3339), expires, strconv.FormatBool(curr.Revocation != nil)}
+	}
+	return c.print(found, []string{"ID", "TYPE", "ISSUER", "SUBJECT", "ISSUED", "EXPIRES", "REVOKED"}, rows)
+}
+
+func trustListFlags(f *pflag.FlagSet) {
+	f.String("type", "", "Credential type, defaults to the types of which the trusted issuers are managed")
+}
+
+func (c *cli) trustList(f *pflag.FlagSet) error {
+	svcs, err := c.services()
+	if err != nil {
+		return err
+	}
+	credentialType, _ := f.GetString("type")
+	credentialTypes, err := svcs.credentials.IssuerCredentialTypes(credentialType, svcs.vcTemplates.All)
+	if err != nil {
+		return err
+	}
+	issuers, err := svcs.credentials.GetCredentialIssuers(credentialTypes)
+	if err != nil {
+		return err
+	}
+	var rows [][]string
+	for _, credentialType := range sortedKeys(issuers.AdditionalProperties) {
+		for _, issuer := range issuers.AdditionalProperties[credentialType] {
+			rows = append(rows, []string{credentialType, issuer.ServiceProvider.Id, stringValue(issuer.OrganizationName), strconv.FormatBool(issuer.Trusted)})
+		}
+	}
+	return c.print(issuers, []string{"TYPE", "ISSUER", "ORGANIZATION", "TRUSTED"}, rows)
+}
+
+func (c *cli) trustSet(f *pflag.FlagSet) error {
+	credentialType, issuer := f.Arg(0), f.Arg(1)
+	var trusted bool
+	switch f.Arg(2) {
+	case "trusted":
+		trusted = true
+	case "untrusted":
+		trusted = false
+	default:
+		return fmt.Errorf("trust must be trusted or untrusted: %s", f.Arg(2))
+	}
+	if _, err := did.ParseDID(issuer); err != nil {
+		return fmt.Errorf("invalid issuer DID: %w", err)
+	}
+	svcs, err := c.services()
+	if err != nil {
+		return err
+	}
+	if err := svcs.credentials.SetIssuerTrust(credentialType, issuer, trusted); err != nil {
+		return err
+	}
+	change := domain.TrustPolicyChange{CredentialType: credentialType, Issuer: issuer, Trusted: trusted}
+	return c.print(change, []string{"TYPE", "ISSUER", "TRUSTED"}, [][]string{{credentialType, issuer, strconv.FormatBool(trusted)}})
+}
+
+func (c *cli) userAdd(f *pflag.FlagSet) error {
+	password, err := c.readPassword()
+	if err != nil {
+		return err
+	}
+	return c.users().Add(f.Arg(0), password)
+}
+
+func (c *cli) userPasswd(f *pflag.FlagSet) error {
+	password, err := c.readPassword()
+	if err != nil {
+		return err
+	}
+	return c.users().SetPassword(f.Arg(0), password)
+}
+
+// users returns the user accounts service, which doesn't need the database so it can be used while the server is running.
+func (c *cli) users() users.Service {
+	return users.Service{Repository: users.NewFlatFileRepository(c.config.UsersFile)}
+}
+
+// readPassword reads the password from the first line of stdin, so it doesn't end up in the shell history.
+func (c *cli) readPassword() (string, error) {
+	if file, ok := c.stdin.(*os.File); ok {
+		if info, err := file.Stat(); err == nil && info.Mode()&os.ModeCharDevice != 0 {
+			_, _ = fmt.Fprint(c.stderr, "Password: ")
+		}
+	}
+	line, err := bufio.NewReader(c.stdin).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", fmt.Errorf("unable to read password: %w", err)
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// optionalString returns the value of a string flag, or nil when it's not set.
+func optionalString(f *pflag.FlagSet, name string) *string {
+	if !f.Changed(name) {
+		return nil
+	}
+	value, _ := f.GetString(name)
+	return &value
+}
+
+func stringValue(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
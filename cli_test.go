@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testCLIConfig writes a config file which stores the data of the CLI in a temporary directory, returning its path.
+func testCLIConfig(t *testing.T, extra string) string {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	config := fmt.Sprintf("dbfile: %s\ncustomersfile: %s\nusersfile: %s\n%s",
+		filepath.Join(dir, "registry-admin.db"), filepath.Join(dir, "customers.json"), filepath.Join(dir, "users.json"), extra)
+	require.NoError(t, os.WriteFile(path, []byte(config), 0600))
+	return path
+}
+
+func TestRunCLI(t *testing.T) {
+	tests := []struct {
+		name           string
+		args           []string
+		config         string
+		expectedCode   int
+		expectedStdout string
+		expectedStderr string
+	}{
+		{name: "no command", args: []string{"customer"}, expectedCode: 2, expectedStderr: "Usage: <command> <subcommand>"},
+		{name: "unknown command", args: []string{"customer", "remove"}, expectedCode: 2, expectedStderr: "Unknown command: customer remove"},
+		{name: "help", args: []string{"customer", "update", "--help"}, expectedCode: 0, expectedStderr: "Usage: customer update [flags] <id>"},
+		{name: "invalid flag", args: []string{"customer", "list", "--unknown"}, expectedCode: 2, expectedStderr: "unknown flag: --unknown"},
+		{name: "missing argument", args: []string{"customer", "update"}, expectedCode: 2, expectedStderr: "Usage: customer update"},
+		{name: "missing variadic argument", args: []string{"vc", "revoke"}, expectedCode: 2, expectedStderr: "Usage: vc revoke"},
+		{name: "invalid output format", args: []string{"sp", "show", "--output", "xml"}, expectedCode: 2, expectedStderr: "Invalid output format: xml"},
+		{name: "invalid config", args: []string{"sp", "show"}, config: "loglevel: loud\n", expectedCode: 1, expectedStderr: "Invalid config:\ninvalid loglevel"},
+		{name: "command fails", args: []string{"sp", "show"}, expectedCode: 1, expectedStderr: "Error: service provider not configured"},
+		{name: "invalid argument", args: []string{"customer", "activate", "one"}, expectedCode: 1, expectedStderr: "Error: invalid customer ID"},
+		{name: "invalid trust", args: []string{"trust", "set", "NutsOrganizationCredential", "did:nuts:issuer", "maybe"}, expectedCode: 1, expectedStderr: "Error: trust must be trusted or untrusted: maybe"},
+		{name: "table output", args: []string{"customer", "list"}, expectedStdout: "ID  NAME  CITY  DOMAIN  DID  ACTIVE\n"},
+		{name: "JSON output", args: []string{"customer", "list", "-o", "json"}, expectedStdout: "[]\n"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			args := test.args
+			if len(args) >= 2 {
+				args = append(args, "--"+configFileFlag, testCLIConfig(t, test.config))
+			}
+			stdout, stderr := new(bytes.Buffer), new(bytes.Buffer)
+
+			code := runCLI(args, strings.NewReader(""), stdout, stderr)
+
+			assert.Equal(t, test.expectedCode, code, stderr.String())
+			assert.Equal(t, test.expectedStdout, stdout.String())
+			assert.True(t, strings.HasPrefix(stderr.String(), test.expectedStderr), stderr.String())
+		})
+	}
+}
+
+func TestRunCLI_Users(t *testing.T) {
+	config := testCLIConfig(t, "")
+	run := func(password string, args ...string) (int, string) {
+		stderr := new(bytes.Buffer)
+		code := runCLI(append(args, "--"+configFileFlag, config), strings.NewReader(password+"\n"), new(bytes.Buffer), stderr)
+		return code, stderr.String()
+	}
+
+	code, stderr := run("correct horse", "user", "add", "alice")
+	require.Equal(t, 0, code, stderr)
+	code, stderr = run("battery staple", "user", "add", "alice")
+	assert.Equal(t, 1, code)
+	assert.True(t, strings.HasPrefix(stderr, "Error: "), stderr)
+
+	code, stderr = run("battery staple", "user", "passwd", "alice")
+	require.Equal(t, 0, code, stderr)
+
+	cfg, errs := readConfig(mustFlagSet(t, "--"+configFileFlag, config))
+	require.Empty(t, errs)
+	c := cli{config: cfg}
+	assert.True(t, c.users().CheckCredentials("alice", "battery staple"))
+	assert.False(t, c.users().CheckCredentials("alice", "correct horse"))
+}
+
+func mustFlagSet(t *testing.T, args ...string) *pflag.FlagSet {
+	flagset, err := loadFlagSet(args)
+	require.NoError(t, err)
+	return flagset
+}
@@ -12,14 +12,22 @@ import (
 	"golang.org/x/crypto/ssh"
 	"io"
 	"log"
+	"net/http"
+	"net/url"
 	"os"
+	"reflect"
+	"regexp"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/knadh/koanf"
 	"github.com/knadh/koanf/parsers/yaml"
 	"github.com/knadh/koanf/providers/env"
-	"github.com/knadh/koanf/providers/file"
 	"github.com/knadh/koanf/providers/posflag"
+	"github.com/knadh/koanf/providers/rawbytes"
+	"github.com/nuts-foundation/go-did/did"
+	"github.com/sirupsen/logrus"
 	"github.com/spf13/pflag"
 )
 
@@ -30,14 +38,46 @@ const defaultConfigFile = "server.config.yaml"
 const defaultDBFile = "registry-admin.db"
 const defaultHTTPPort = 1303
 const defaultNutsNodeAddress = "http://localhost:1323"
+const defaultNutsNodeTimeout = 10 * time.Second
+const defaultLogLevel = "info"
 const defaultCustomerFile = "customers.json"
+const defaultUsersFile = "users.json"
+const defaultNutsOrgCredentialValidityDays = 365
+const defaultRenewalDays = 30
+const defaultIssuerCacheTTL = 10 * time.Minute
+const defaultIndexInterval = 5 * time.Minute
+const nodeCheckTimeout = 5 * time.Second
+const redacted = "********"
+
+// demoPassword is the password of the sample config, which is only accepted when insecure is set.
+const demoPassword = "demo"
+
+// fileSuffix is appended to the key of a secret to read it from a file instead, e.g. credentials.passwordfile.
+const fileSuffix = "file"
+
+// envReference matches a reference to an environment variable in the config file, e.g. ${NUTS_PASSWORD}.
+var envReference = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
 
 func defaultConfig() Config {
 	return Config{
 		HTTPPort:        defaultHTTPPort,
 		DBFile:          defaultDBFile,
 		NutsNodeAddress: defaultNutsNodeAddress,
+		NutsNodeTimeout: defaultNutsNodeTimeout,
+		LogLevel:        defaultLogLevel,
 		CustomersFile:   defaultCustomerFile,
+		UsersFile:       defaultUsersFile,
+		Renewal: Renewal{
+			ValidityDays: map[string]int{"NutsOrganizationCredential": defaultNutsOrgCredentialValidityDays},
+			Days:         defaultRenewalDays,
+		},
+		Trust: Trust{
+			CredentialTypes: []string{"NutsOrganizationCredential", "NutsAuthorizationCredential"},
+			IssuerCacheTTL:  defaultIssuerCacheTTL,
+		},
+		Directory: Directory{
+			IndexInterval: defaultIndexInterval,
+		},
 	}
 }
 
@@ -47,22 +87,53 @@ type Config struct {
 	HTTPPort    int         `koanf:"port"`
 	// NutsNodeAddress contains the address of the Nuts node. It's also used in the aud field when API security is enabled
 	NutsNodeAddress string `koanf:"nutsnodeaddr"`
+	// NutsNodeTimeout defines how long to wait for a response of the Nuts node
+	NutsNodeTimeout time.Duration `koanf:"nutsnodetimeout"`
 	// NutsNodeAPIKeyFile points to the private key used to sign JWTs. If empty Nuts node API security is not enabled
 	NutsNodeAPIKeyFile string `koanf:"nutsnodeapikeyfile"`
 	// NutsNodeAPIUser contains the API key user that will go into the iss field. It must match the user with the public key from the authorized_keys file in the Nuts node
 	NutsNodeAPIUser string `koanf:"nutsnodeapiuser"`
 	// NutsNodeAPIAudience dictates the aud field of the created JWT
-	NutsNodeAPIAudience string   `kaonf:"nutsnodeapiaudience"`
-	CustomersFile       string   `koanf:"customersfile"`
-	Branding            Branding `koanf:"branding"`
-	sessionKey          *ecdsa.PrivateKey
-	apiKey              crypto.Signer
-	VendorDID           string `koanf:"vendordid"`
+	NutsNodeAPIAudience string `koanf:"nutsnodeapiaudience"`
+	CustomersFile       string `koanf:"customersfile"`
+	// UsersFile points to the JSON file with the user accounts managed using the user CLI commands, besides the account in credentials
+	UsersFile string `koanf:"usersfile"`
+	// LogLevel defines the level of the log messages of the domain services: trace, debug, info, warn or error
+	LogLevel   string   `koanf:"loglevel"`
+	Branding   Branding `koanf:"branding"`
+	sessionKey *ecdsa.PrivateKey
+	apiKey     crypto.Signer
+	VendorDID  string `koanf:"vendordid"`
+	// ServiceCatalogFile points to a YAML file with templates of well-known compound services. If empty, the built-in catalog is used
+	ServiceCatalogFile string    `koanf:"servicecatalogfile"`
+	Reconcile          Reconcile `koanf:"reconcile"`
+	Renewal            Renewal   `koanf:"renewal"`
+	Trust              Trust     `koanf:"trust"`
+	Directory          Directory `koanf:"directory"`
+	// Insecure allows insecure settings for development, like the demo password
+	Insecure bool `koanf:"insecure"`
 }
 
 type Credentials struct {
 	Username string `koanf:"username"`
-	Password string `koanf:"password" json:"-"` // json omit tag to avoid having it printed in server log
+	// Password is redacted when the config is printed
+	Password string `koanf:"password"`
+	// PasswordFile points to a file containing the password, e.g. a Docker or Kubernetes secret
+	PasswordFile string `koanf:"passwordfile"`
+}
+
+// secret is a config value which is redacted when printed or logged, and which can be read from a file.
+type secret struct {
+	value *string
+	file  *string
+}
+
+// secrets returns the secrets in the config by their key. The password is the only one,
+// because the other secret, the private key of nutsnodeapikeyfile, is already read from a file.
+func (c *Config) secrets() map[string]secret {
+	return map[string]secret{
+		"credentials.password": {value: &c.Credentials.Password, file: &c.Credentials.PasswordFile},
+	}
 }
 
 type Branding struct {
@@ -70,6 +141,36 @@ type Branding struct {
 	Logo string `koanf:"logo"`
 }
 
+type Reconcile struct {
+	// Interval defines how often the customers are compared with the Nuts network. If 0, they're only compared on request.
+	Interval time.Duration `koanf:"interval"`
+	// AutoRepair defines whether drift found by the periodic comparison is repaired automatically.
+	AutoRepair bool `koanf:"autorepair"`
+}
+
+type Renewal struct {
+	// ValidityDays defines per credential type how many days issued credentials are valid, when issued without an expiration date.
+	ValidityDays map[string]int `koanf:"validitydays"`
+	// Days defines how many days before their expiration date credentials are renewed.
+	Days int `koanf:"days"`
+	// Interval defines how often expiring credentials are renewed. If 0, they're only renewed on request.
+	Interval time.Duration `koanf:"interval"`
+}
+
+type Trust struct {
+	// CredentialTypes defines the credential types of which the trusted issuers are managed, besides the types of the VC templates.
+	CredentialTypes []string `koanf:"credentialtypes"`
+	// PolicyFile points to a YAML file with the trust policy, which is applied on startup. If empty, trust is managed through the API only.
+	PolicyFile string `koanf:"policyfile"`
+	// IssuerCacheTTL defines how long the contact information of issuers is cached. If 0, it isn't cached.
+	IssuerCacheTTL time.Duration `koanf:"issuercachettl"`
+}
+
+type Directory struct {
+	// IndexInterval defines how often the local index of organizations is refreshed. If 0, organizations are searched on the Nuts node.
+	IndexInterval time.Duration `koanf:"indexinterval"`
+}
+
 func (c Credentials) Empty() bool {
 	return len(c.Username) == 0 && len(c.Password) == 0
 }
@@ -83,13 +184,19 @@ func generateSessionKey() (*ecdsa.PrivateKey, error) {
 	return key, nil
 }
 
+// Print writes the effective config as JSON, with secrets redacted.
 func (c Config) Print(writer io.Writer) error {
 	if _, err := fmt.Fprintln(writer, "========== CONFIG: =========="); err != nil {
 		return err
 	}
 	var pr Config = c
+	for _, curr := range pr.secrets() {
+		if len(*curr.value) > 0 {
+			*curr.value = redacted
+		}
+	}
 	data, _ := json.MarshalIndent(pr, "", "  ")
-	if _, err := fmt.Println(writer, string(data)); err != nil {
+	if _, err := fmt.Fprintln(writer, string(data)); err != nil {
 		return err
 	}
 	if _, err := fmt.Fprintln(writer, "========= END CONFIG ========="); err != nil {
@@ -98,8 +205,24 @@ func (c Config) Print(writer io.Writer) error {
 	return nil
 }
 
+// loadConfig loads the config of the server, exiting with all problems found when it's invalid.
 func loadConfig() Config {
-	flagset := loadFlagSet(os.Args[1:])
+	flagset, err := loadFlagSet(os.Args[1:])
+	config, errs := readConfig(flagset)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	errs = append(errs, validateConfig(config)...)
+	if len(errs) > 0 {
+		log.Fatalf("invalid config:\n%v", errors.Join(errs...))
+	}
+	return config
+}
+
+// readConfig loads the config from the defaults, the config file (given by the flags) and environment variables.
+// Instead of stopping at the first problem, it returns every problem found, so they can be fixed at once.
+func readConfig(flagset *pflag.FlagSet) (Config, []error) {
+	var errs []error
 
 	var k = koanf.New(".")
 
@@ -108,9 +231,17 @@ func loadConfig() Config {
 	// Check if the file exists
 	if _, err := os.Stat(configFilePath); err == nil {
 		log.Printf("Loading config from file: %s", configFilePath)
-		if err := k.Load(file.Provider(configFilePath), yaml.Parser()); err != nil {
-			log.Fatalf("error while loading config from file: %v", err)
+		fileConfig, err := readConfigFile(configFilePath)
+		if err != nil {
+			errs = append(errs, err)
+		} else {
+			for _, key := range unknownKeys(fileConfig.Keys()) {
+				errs = append(errs, fmt.Errorf("unknown key in config file %s: %s", configFilePath, key))
+			}
+			_ = k.Merge(fileConfig)
 		}
+	} else if configFilePath != defaultConfigFile {
+		errs = append(errs, fmt.Errorf("config file not found: %s", configFilePath))
 	} else {
 		log.Printf("Using default config because no file was found at: %s", configFilePath)
 	}
@@ -119,47 +250,244 @@ func loadConfig() Config {
 	_ = k.Load(envProvider(), nil)
 
 	config := defaultConfig()
-	var err error
 	sessionKey, err := generateSessionKey()
 	if err != nil {
-		log.Fatalf("unable to generate session key: %v", err)
+		errs = append(errs, fmt.Errorf("unable to generate session key: %w", err))
 	}
 	config.sessionKey = sessionKey
 
 	// Unmarshal values of the config file into the config struct, potentially replacing default values
 	if err := k.Unmarshal("", &config); err != nil {
-		log.Fatalf("error while unmarshalling config: %v", err)
+		errs = append(errs, fmt.Errorf("invalid config values: %w", err))
 	}
 
+	errs = append(errs, readSecretFiles(&config)...)
+
 	// Load the API key
 	if len(config.NutsNodeAPIKeyFile) > 0 {
 		bytes, err := os.ReadFile(config.NutsNodeAPIKeyFile)
 		if err != nil {
-			log.Fatalf("error while reading private key file: %v", err)
-		}
-		config.apiKey, err = pemToPrivateKey(bytes)
-		if err != nil {
-			log.Fatalf("error while decoding private key file: %v", err)
+			errs = append(errs, fmt.Errorf("error while reading private key file: %w", err))
+		} else if config.apiKey, err = pemToPrivateKey(bytes); err != nil {
+			errs = append(errs, fmt.Errorf("error while decoding private key file: %w", err))
 		}
 		if len(config.NutsNodeAPIUser) == 0 {
-			log.Fatal("nutsnodeapiuser config is required with nutsnodeapikeyfile")
+			errs = append(errs, errors.New("nutsnodeapiuser config is required with nutsnodeapikeyfile"))
 		}
 		if len(config.NutsNodeAPIAudience) == 0 {
-			log.Fatal("nutsnodeapiaudience config is required with nutsnodeapikeyfile")
+			errs = append(errs, errors.New("nutsnodeapiaudience config is required with nutsnodeapikeyfile"))
 		}
 	}
 
-	return config
+	return config, errs
+}
+
+// validateConfig checks the values of the config which can't be checked while loading it, returning every problem found.
+func validateConfig(config Config) []error {
+	var errs []error
+	if len(config.VendorDID) > 0 {
+		if _, err := did.ParseDID(config.VendorDID); err != nil {
+			errs = append(errs, fmt.Errorf("invalid vendordid: %w", err))
+		}
+	}
+	if _, err := url.ParseRequestURI(config.NutsNodeAddress); err != nil {
+		errs = append(errs, fmt.Errorf("invalid nutsnodeaddr: %w", err))
+	}
+	if config.NutsNodeTimeout <= 0 {
+		errs = append(errs, errors.New("invalid nutsnodetimeout: must be positive"))
+	}
+	if _, err := logrus.ParseLevel(config.LogLevel); err != nil {
+		errs = append(errs, fmt.Errorf("invalid loglevel: %w", err))
+	}
+	files := map[string]string{
+		"branding.logo":      config.Branding.Logo,
+		"servicecatalogfile": config.ServiceCatalogFile,
+		"trust.policyfile":   config.Trust.PolicyFile,
+	}
+	for _, key := range sortedKeys(files) {
+		if len(files[key]) == 0 {
+			continue
+		}
+		if _, err := os.Stat(files[key]); err != nil {
+			errs = append(errs, fmt.Errorf("invalid %s: %w", key, err))
+		}
+	}
+	durations := map[string]time.Duration{
+		"reconcile.interval":      config.Reconcile.Interval,
+		"renewal.interval":        config.Renewal.Interval,
+		"trust.issuercachettl":    config.Trust.IssuerCacheTTL,
+		"directory.indexinterval": config.Directory.IndexInterval,
+	}
+	for _, key := range sortedKeys(durations) {
+		if durations[key] < 0 {
+			errs = append(errs, fmt.Errorf("invalid %s: must not be negative", key))
+		}
+	}
+	if config.Renewal.Days < 0 {
+		errs = append(errs, errors.New("invalid renewal.days: must not be negative"))
+	}
+	if config.Credentials.Password == demoPassword && !config.Insecure {
+		errs = append(errs, errors.New("invalid credentials.password: the demo password is only allowed when insecure is set"))
+	}
+	return errs
+}
+
+// readConfigFile parses the config file, replacing references to environment variables (${NAME}) in its values with their values.
+// They're replaced after parsing, so the value of an environment variable can't change the structure of the config file.
+// Undefined environment variables are reported instead of replaced with an empty value.
+func readConfigFile(path string) (*koanf.Koanf, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read config file %s: %w", path, err)
+	}
+	k := koanf.New(defaultDelimiter)
+	if err := k.Load(rawbytes.Provider(data), yaml.Parser()); err != nil {
+		return nil, fmt.Errorf("unable to parse config file %s: %w", path, err)
+	}
+	undefined := map[string]bool{}
+	values := k.All()
+	for _, key := range sortedKeys(values) {
+		_ = k.Set(key, expandEnv(values[key], undefined))
+	}
+	if len(undefined) > 0 {
+		return nil, fmt.Errorf("undefined environment variable(s) in config file %s: %s", path, strings.Join(sortedKeys(undefined), ", "))
+	}
+	return k, nil
 }
 
-func loadFlagSet(args []string) *pflag.FlagSet {
-	f := pflag.NewFlagSet("config", pflag.ContinueOnError)
+// expandEnv replaces the references to environment variables in a config value, which can be a string or a list of values.
+// The names of undefined environment variables are added to undefined.
+func expandEnv(value interface{}, undefined map[string]bool) interface{} {
+	switch v := value.(type) {
+	case string:
+		return envReference.ReplaceAllStringFunc(v, func(reference string) string {
+			name := envReference.FindStringSubmatch(reference)[1]
+			envValue, ok := os.LookupEnv(name)
+			if !ok {
+				undefined[name] = true
+			}
+			return envValue
+		})
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, curr := range v {
+			result[i] = expandEnv(curr, undefined)
+		}
+		return result
+	default:
+		return value
+	}
+}
+
+// readSecretFiles sets the secrets which are configured as a file to the contents of the file, without trailing newlines.
+func readSecretFiles(config *Config) []error {
+	var errs []error
+	secrets := config.secrets()
+	for _, key := range sortedKeys(secrets) {
+		curr := secrets[key]
+		if len(*curr.file) == 0 {
+			continue
+		}
+		if len(*curr.value) > 0 {
+			errs = append(errs, fmt.Errorf("invalid %s: can't be combined with %s%s", key, key, fileSuffix))
+			continue
+		}
+		data, err := os.ReadFile(*curr.file)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid %s%s: %w", key, fileSuffix, err))
+			continue
+		}
+		*curr.value = strings.TrimRight(string(data), "\r\n")
+	}
+	return errs
+}
+
+// checkNutsNode returns an error when the Nuts node's status endpoint doesn't respond with 200 OK.
+func checkNutsNode(address string) error {
+	client := http.Client{Timeout: nodeCheckTimeout}
+	response, err := client.Get(strings.TrimSuffix(address, "/") + "/status")
+	if err != nil {
+		return fmt.Errorf("nuts node is unreachable: %w", err)
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("nuts node is unhealthy: status endpoint returned %s", response.Status)
+	}
+	return nil
+}
+
+// unknownKeys returns the keys which don't correspond to a config value, sorted.
+func unknownKeys(keys []string) []string {
+	known := map[string]bool{configFileFlag: true}
+	var mapKeys []string
+	collectKeys(reflect.TypeOf(Config{}), "", known, &mapKeys)
+	var result []string
+	for _, key := range keys {
+		lowerKey := strings.ToLower(key)
+		if known[lowerKey] || hasAnyPrefix(lowerKey, mapKeys) {
+			continue
+		}
+		result = append(result, key)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// collectKeys collects the keys of the config values in the given struct type, using their koanf tags.
+// Maps can contain any key, so the keys of map values are collected separately as prefixes.
+func collectKeys(t reflect.Type, prefix string, keys map[string]bool, mapKeys *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("koanf")
+		if len(tag) == 0 {
+			continue
+		}
+		key := prefix + tag
+		switch field.Type.Kind() {
+		case reflect.Struct:
+			collectKeys(field.Type, key+defaultDelimiter, keys, mapKeys)
+		case reflect.Map:
+			*mapKeys = append(*mapKeys, key+defaultDelimiter)
+		default:
+			keys[key] = true
+		}
+	}
+}
+
+func hasAnyPrefix(value string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(value, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedKeys[T any](values map[string]T) []string {
+	result := make([]string, 0, len(values))
+	for key := range values {
+		result = append(result, key)
+	}
+	sort.Strings(result)
+	return result
+}
+
+func loadFlagSet(args []string) (*pflag.FlagSet, error) {
+	f := newFlagSet("config")
+	if err := f.Parse(args); err != nil {
+		return f, fmt.Errorf("invalid command line flags: %w", err)
+	}
+	return f, nil
+}
+
+// newFlagSet returns a flag set with the flags needed to load the config.
+func newFlagSet(name string) *pflag.FlagSet {
+	f := pflag.NewFlagSet(name, pflag.ContinueOnError)
 	f.String(configFileFlag, defaultConfigFile, "Nuts config file")
 	f.Usage = func() {
 		fmt.Println(f.FlagUsages())
 		os.Exit(0)
 	}
-	f.Parse(args)
 	return f
 }
 
@@ -181,10 +509,17 @@ func resolveConfigFile(flagset *pflag.FlagSet) string {
 	return configFile
 }
 
+// envProvider loads the config from environment variables, e.g. NUTS_CREDENTIALS_USERNAME sets credentials.username.
+// Like Docker secrets, the _FILE suffix reads a secret from a file: NUTS_CREDENTIALS_PASSWORD_FILE sets credentials.passwordfile.
 func envProvider() *env.Env {
 	return env.Provider(defaultPrefix, defaultDelimiter, func(s string) string {
-		return strings.Replace(strings.ToLower(
-			strings.TrimPrefix(s, defaultPrefix)), "_", defaultDelimiter, -1)
+		key := strings.ToLower(strings.TrimPrefix(s, defaultPrefix))
+		suffix := ""
+		if strings.HasSuffix(key, "_"+fileSuffix) {
+			key = strings.TrimSuffix(key, "_"+fileSuffix)
+			suffix = fileSuffix
+		}
+		return strings.Replace(key, "_", defaultDelimiter, -1) + suffix
 	})
 }
 
@@ -207,3 +542,27 @@ func pemToPrivateKey(bytes []byte) (signer crypto.Signer, err error) {
 
 	return
 }
+
+// validateConfigCommand loads the config like the server does and checks the Nuts node is reachable.
+// It prints the effective config with secrets redacted and reports every problem found. Returns the exit code.
+func validateConfigCommand(args []string, writer io.Writer) int {
+	flagset, err := loadFlagSet(args)
+	config, errs := readConfig(flagset)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	errs = append(errs, validateConfig(config)...)
+	if err := checkNutsNode(config.NutsNodeAddress); err != nil {
+		errs = append(errs, err)
+	}
+	_ = config.Print(writer)
+	if len(errs) == 0 {
+		_, _ = fmt.Fprintln(writer, "Config is valid")
+		return 0
+	}
+	_, _ = fmt.Fprintf(writer, "Found %d problem(s):\n", len(errs))
+	for _, err := range errs {
+		_, _ = fmt.Fprintf(writer, "  - %v\n", err)
+	}
+	return 1
+}
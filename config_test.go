@@ -0,0 +1,168 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/knadh/koanf"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnknownKeys(t *testing.T) {
+	tests := []struct {
+		name     string
+		keys     []string
+		expected []string
+	}{
+		{name: "no keys"},
+		{name: "known keys", keys: []string{"port", "credentials.username", "credentials.passwordfile", "trust.issuercachettl"}},
+		{name: "case insensitive", keys: []string{"NutsNodeAddr", "Renewal.Days"}},
+		{name: "map values", keys: []string{"renewal.validitydays.NutsOrganizationCredential", "renewal.validitydays.CustomCredential"}},
+		{name: "config file flag", keys: []string{configFileFlag}},
+		{name: "unknown keys are sorted", keys: []string{"prot", "credentials.user", "port"}, expected: []string{"credentials.user", "prot"}},
+		{name: "struct isn't a value", keys: []string{"credentials"}, expected: []string{"credentials"}},
+		{name: "map itself isn't a value", keys: []string{"renewal.validitydays"}, expected: []string{"renewal.validitydays"}},
+		{name: "unexported fields", keys: []string{"sessionkey", "apikey"}, expected: []string{"apikey", "sessionkey"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, unknownKeys(test.keys))
+		})
+	}
+}
+
+func TestReadConfigFile(t *testing.T) {
+	t.Setenv("TEST_USERNAME", "admin")
+	t.Setenv("TEST_PASSWORD", "p: #{secret}\n- [x]")
+	t.Setenv("TEST_PORT", "8080")
+	tests := []struct {
+		name        string
+		contents    string
+		expected    map[string]interface{}
+		expectedErr string
+	}{
+		{
+			name:     "without references",
+			contents: "credentials:\n  username: demo\nport: 1303\n",
+			expected: map[string]interface{}{"credentials.username": "demo", "port": 1303},
+		},
+		{
+			name:     "references",
+			contents: "credentials:\n  username: ${TEST_USERNAME}@example.com\nport: ${TEST_PORT}\n",
+			expected: map[string]interface{}{"credentials.username": "admin@example.com", "port": "8080"},
+		},
+		{
+			name:     "value isn't parsed as YAML",
+			contents: "credentials:\n  password: ${TEST_PASSWORD}\n",
+			expected: map[string]interface{}{"credentials.password": "p: #{secret}\n- [x]"},
+		},
+		{
+			name:     "list values",
+			contents: "trust:\n  credentialtypes: [\"${TEST_USERNAME}Credential\", 42]\n",
+			expected: map[string]interface{}{"trust.credentialtypes": []interface{}{"adminCredential", 42}},
+		},
+		{
+			name:     "reference in a comment",
+			contents: "# port: ${TEST_UNDEFINED}\nport: 1303\n",
+			expected: map[string]interface{}{"port": 1303},
+		},
+		{
+			name:        "undefined environment variables",
+			contents:    "credentials:\n  username: ${TEST_UNDEFINED_B}\n  password: ${TEST_UNDEFINED_A}${TEST_UNDEFINED_B}\n",
+			expectedErr: "undefined environment variable(s) in config file %s: TEST_UNDEFINED_A, TEST_UNDEFINED_B",
+		},
+		{
+			name:        "invalid YAML",
+			contents:    "credentials: [\n",
+			expectedErr: "unable to parse config file %s: ",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.yaml")
+			require.NoError(t, os.WriteFile(path, []byte(test.contents), 0600))
+
+			k, err := readConfigFile(path)
+
+			if len(test.expectedErr) > 0 {
+				assert.ErrorContains(t, err, fmt.Sprintf(test.expectedErr, path))
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, k.All())
+		})
+	}
+	t.Run("file doesn't exist", func(t *testing.T) {
+		_, err := readConfigFile(filepath.Join(t.TempDir(), "config.yaml"))
+
+		assert.ErrorIs(t, err, os.ErrNotExist)
+	})
+}
+
+func TestReadSecretFiles(t *testing.T) {
+	dir := t.TempDir()
+	passwordFile := filepath.Join(dir, "password")
+	require.NoError(t, os.WriteFile(passwordFile, []byte("correct horse\r\n"), 0600))
+	tests := []struct {
+		name             string
+		credentials      Credentials
+		expectedPassword string
+		expectedErr      string
+	}{
+		{name: "no file", credentials: Credentials{Password: "secret"}, expectedPassword: "secret"},
+		{name: "file", credentials: Credentials{PasswordFile: passwordFile}, expectedPassword: "correct horse"},
+		{
+			name:             "file and value",
+			credentials:      Credentials{Password: "secret", PasswordFile: passwordFile},
+			expectedPassword: "secret",
+			expectedErr:      "invalid credentials.password: can't be combined with credentials.passwordfile",
+		},
+		{
+			name:        "file doesn't exist",
+			credentials: Credentials{PasswordFile: filepath.Join(dir, "unknown")},
+			expectedErr: "invalid credentials.passwordfile: open " + filepath.Join(dir, "unknown"),
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := Config{Credentials: test.credentials}
+
+			errs := readSecretFiles(&config)
+
+			assert.Equal(t, test.expectedPassword, config.Credentials.Password)
+			if len(test.expectedErr) == 0 {
+				assert.Empty(t, errs)
+				return
+			}
+			require.Len(t, errs, 1)
+			assert.ErrorContains(t, errs[0], test.expectedErr)
+		})
+	}
+}
+
+func TestEnvProvider(t *testing.T) {
+	tests := []struct {
+		name        string
+		env         string
+		expectedKey string
+	}{
+		{name: "value", env: "NUTS_PORT", expectedKey: "port"},
+		{name: "nested value", env: "NUTS_CREDENTIALS_USERNAME", expectedKey: "credentials.username"},
+		{name: "secret file", env: "NUTS_CREDENTIALS_PASSWORD_FILE", expectedKey: "credentials.passwordfile"},
+		{name: "key ending with file", env: "NUTS_NUTSNODEAPIKEYFILE", expectedKey: "nutsnodeapikeyfile"},
+		{name: "case insensitive", env: "NUTS_LogLevel", expectedKey: "loglevel"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Setenv(test.env, "value")
+			k := koanf.New(defaultDelimiter)
+
+			require.NoError(t, k.Load(envProvider(), nil))
+
+			assert.Equal(t, "value", k.Get(test.expectedKey))
+		})
+	}
+}
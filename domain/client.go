@@ -1,6 +1,41 @@
 package domain
 
-import "net"
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+)
+
+var ErrNutsNodeUnauthorized = errors.New("nuts node refused the API credentials")
+
+var ErrNutsNodeNotFound = errors.New("not found on the nuts node")
+
+var ErrNutsNodeInvalidRequest = errors.New("nuts node rejected the request")
+
+var ErrNutsNodeFailed = errors.New("nuts node failed to process the request")
+
+// NodeError is returned when the Nuts node responds with an error status. It wraps the error matching the status,
+// e.g. ErrNutsNodeNotFound, and contains the message from the node's problem details.
+type NodeError struct {
+	StatusCode int
+	Detail     string
+	cause      error
+}
+
+func (e NodeError) Error() string {
+	if len(e.Detail) == 0 {
+		return fmt.Sprintf("%s (status=%d)", e.cause, e.StatusCode)
+	}
+	return fmt.Sprintf("%s (status=%d): %s", e.cause, e.StatusCode, e.Detail)
+}
+
+func (e NodeError) Unwrap() error {
+	return e.cause
+}
 
 func UnwrapAPIError(err error) error {
 	if _, ok := err.(net.Error); ok {
@@ -8,3 +43,34 @@ func UnwrapAPIError(err error) error {
 	}
 	return err
 }
+
+// NodeResponseError returns a NodeError for the response of the Nuts node, which has an unexpected status.
+// The response body is read, to pass on the detail of the problem.
+func NodeResponseError(response *http.Response) error {
+	body, _ := io.ReadAll(response.Body)
+	result := NodeError{StatusCode: response.StatusCode}
+	switch response.StatusCode {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		result.cause = ErrNutsNodeUnauthorized
+	case http.StatusNotFound:
+		result.cause = ErrNutsNodeNotFound
+	case http.StatusBadRequest, http.StatusConflict, http.StatusUnprocessableEntity:
+		result.cause = ErrNutsNodeInvalidRequest
+	default:
+		result.cause = ErrNutsNodeFailed
+	}
+	// The Nuts node responds with RFC 7807 problem details
+	problem := struct {
+		Title  string `json:"title"`
+		Detail string `json:"detail"`
+	}{}
+	if err := json.Unmarshal(body, &problem); err == nil && (len(problem.Detail) > 0 || len(problem.Title) > 0) {
+		result.Detail = problem.Detail
+		if len(result.Detail) == 0 {
+			result.Detail = problem.Title
+		}
+	} else {
+		result.Detail = strings.TrimSpace(string(body))
+	}
+	return result
+}
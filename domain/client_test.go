@@ -0,0 +1,96 @@
+package domain
+
+import (
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNodeResponseError(t *testing.T) {
+	tests := []struct {
+		name          string
+		status        int
+		body          string
+		expectedCause error
+		expectedMsg   string
+	}{
+		{
+			name:          "problem details",
+			status:        http.StatusNotFound,
+			body:          `{"title":"Resolving DID document failed","detail":"unable to find the DID document","status":404}`,
+			expectedCause: ErrNutsNodeNotFound,
+			expectedMsg:   "not found on the nuts node (status=404): unable to find the DID document",
+		},
+		{
+			name:          "problem details without detail",
+			status:        http.StatusBadRequest,
+			body:          `{"title":"Invalid request"}`,
+			expectedCause: ErrNutsNodeInvalidRequest,
+			expectedMsg:   "nuts node rejected the request (status=400): Invalid request",
+		},
+		{
+			name:          "plain text",
+			status:        http.StatusForbidden,
+			body:          "access denied\n",
+			expectedCause: ErrNutsNodeUnauthorized,
+			expectedMsg:   "nuts node refused the API credentials (status=403): access denied",
+		},
+		{
+			name:          "unauthorized without body",
+			status:        http.StatusUnauthorized,
+			expectedCause: ErrNutsNodeUnauthorized,
+			expectedMsg:   "nuts node refused the API credentials (status=401)",
+		},
+		{
+			name:          "conflict",
+			status:        http.StatusConflict,
+			expectedCause: ErrNutsNodeInvalidRequest,
+			expectedMsg:   "nuts node rejected the request (status=409)",
+		},
+		{
+			name:          "unprocessable entity",
+			status:        http.StatusUnprocessableEntity,
+			expectedCause: ErrNutsNodeInvalidRequest,
+			expectedMsg:   "nuts node rejected the request (status=422)",
+		},
+		{
+			name:          "server error",
+			status:        http.StatusInternalServerError,
+			body:          `{"detail":"database is locked"}`,
+			expectedCause: ErrNutsNodeFailed,
+			expectedMsg:   "nuts node failed to process the request (status=500): database is locked",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			response := &http.Response{StatusCode: test.status, Body: io.NopCloser(strings.NewReader(test.body))}
+
+			err := NodeResponseError(response)
+
+			assert.ErrorIs(t, err, test.expectedCause)
+			assert.EqualError(t, err, test.expectedMsg)
+			var nodeErr NodeError
+			if assert.True(t, errors.As(err, &nodeErr)) {
+				assert.Equal(t, test.status, nodeErr.StatusCode)
+			}
+		})
+	}
+}
+
+func TestUnwrapAPIError(t *testing.T) {
+	t.Run("network error", func(t *testing.T) {
+		err := UnwrapAPIError(&net.OpError{Op: "dial", Err: errors.New("connection refused")})
+
+		assert.Equal(t, ErrNutsNodeUnreachable, err)
+	})
+	t.Run("other error", func(t *testing.T) {
+		err := errors.New("other")
+
+		assert.Equal(t, err, UnwrapAPIError(err))
+	})
+}
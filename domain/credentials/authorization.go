@@ -0,0 +1,221 @@
+package credentials
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	ssi "github.com/nuts-foundation/go-did"
+	"github.com/nuts-foundation/go-did/did"
+	"github.com/nuts-foundation/go-did/vc"
+	"github.com/nuts-foundation/nuts-node/vcr/credential"
+	"github.com/nuts-foundation/nuts-registry-admin-demo/domain"
+)
+
+var ErrInvalidAuthorization = errors.New("invalid authorization")
+
+var ErrUnknownOrganization = errors.New("organization doesn't have a NutsOrganizationCredential")
+
+var ErrCustomerWithoutDID = errors.New("customer isn't connected to a DID")
+
+var validOperations = map[domain.AuthorizationResourceOperations]bool{
+	domain.AuthorizationResourceOperationsRead:         true,
+	domain.AuthorizationResourceOperationsVread:        true,
+	domain.AuthorizationResourceOperationsUpdate:       true,
+	domain.AuthorizationResourceOperationsPatch:        true,
+	domain.AuthorizationResourceOperationsDelete:       true,
+	domain.AuthorizationResourceOperationsHistory:      true,
+	domain.AuthorizationResourceOperationsCreate:       true,
+	domain.AuthorizationResourceOperationsSearchType:   true,
+	domain.AuthorizationResourceOperationsSearchSystem: true,
+}
+
+var validAssuranceLevels = map[domain.AuthorizationResourceAssuranceLevel]bool{
+	domain.AuthorizationResourceAssuranceLevelLow:         true,
+	domain.AuthorizationResourceAssuranceLevelSubstantial: true,
+	domain.AuthorizationResourceAssuranceLevelHigh:        true,
+}
+
+// authorizationSubject models the credentialSubject of a NutsAuthorizationCredential.
+type authorizationSubject struct {
+	ID           string                         `json:"id"`
+	PurposeOfUse string                         `json:"purposeOfUse"`
+	Resources    []domain.AuthorizationResource `json:"resources"`
+	Subject      *string                        `json:"subject,omitempty"`
+}
+
+// IssueAuthorization issues a NutsAuthorizationCredential from the customer to the organization in the request.
+// The organization must have a NutsOrganizationCredential of a trusted issuer.
+func (s Service) IssueAuthorization(customer domain.Customer, request domain.AuthorizationRequest) (*domain.Authorization, error) {
+	if customer.Did == nil {
+		return nil, ErrCustomerWithoutDID
+	}
+	if err := validateAuthorization(customer, request); err != nil {
+		return nil, err
+	}
+	organizations, err := s.search(SearchVCRequest{
+		Query: SearchVCQuery{
+			Type:    []ssi.URI{ssi.MustParseURI(credential.NutsOrganizationCredentialType), ssi.MustParseURI(vc.VerifiableCredentialType)},
+			Context: []ssi.URI{ssi.MustParseURI(vc.VCContextV1), ssi.MustParseURI(credential.NutsV1Context)},
+			CredentialSubject: domain.NutsOrganizationCredentialSubject{
+				ID: request.Organization,
+				Organization: domain.Organization{
+					Name: "*",
+					City: "*",
+				},
+			},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(organizations) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownOrganization, request.Organization)
+	}
+
+	issueRequest, err := authorizationIssueRequest(customer, request)
+	if err != nil {
+		return nil, err
+	}
+	issued, err := s.Issue(*issueRequest)
+	if err != nil {
+		return nil, fmt.Errorf("unable to issue NutsAuthorizationCredential: %w", domain.UnwrapAPIError(err))
+	}
+	return toAuthorization(*issued)
+}
+
+// authorizationIssueRequest returns the request to issue the NutsAuthorizationCredential privately from the customer to the organization,
+// valid from validFrom (if given) until validUntil.
+func authorizationIssueRequest(customer domain.Customer, request domain.AuthorizationRequest) (*domain.IssueVCRequest, error) {
+	subject := authorizationSubject{
+		ID:           request.Organization,
+		PurposeOfUse: request.PurposeOfUse,
+		Resources:    request.Resources,
+		Subject:      request.Patient,
+	}
+	subjectData, _ := json.Marshal(subject)
+	credentialSubject := domain.CredentialSubject{}
+	if err := json.Unmarshal(subjectData, &credentialSubject); err != nil {
+		return nil, err
+	}
+	ldContext := credential.NutsV1Context
+	visibility := domain.IssueVCRequestVisibilityPrivate
+	publishToNetwork := true
+	var issuanceDate *string
+	if request.ValidFrom != nil {
+		validFrom := request.ValidFrom.UTC().Format(time.RFC3339)
+		issuanceDate = &validFrom
+	}
+	expirationDate := request.ValidUntil.UTC().Format(time.RFC3339)
+	return &domain.IssueVCRequest{
+		Type:              credential.NutsAuthorizationCredentialType,
+		Context:           &ldContext,
+		Issuer:            *customer.Did,
+		CredentialSubject: credentialSubject,
+		IssuanceDate:      issuanceDate,
+		ExpirationDate:    &expirationDate,
+		PublishToNetwork:  &publishToNetwork,
+		Visibility:        &visibility,
+	}, nil
+}
+
+// CustomerAuthorizations returns the NutsAuthorizationCredentials issued by the customer which haven't been revoked or expired,
+// the most recently issued first.
+func (s Service) CustomerAuthorizations(customer domain.Customer) ([]domain.Authorization, error) {
+	result := []domain.Authorization{}
+	if customer.Did == nil {
+		return result, nil
+	}
+	issued, err := s.searchIssued(credential.NutsAuthorizationCredentialType, *customer.Did, nil)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	for _, curr := range issued {
+		verifiableCredential := curr.VerifiableCredential
+		if curr.Revocation != nil || verifiableCredential.ID == nil {
+			continue
+		}
+		if verifiableCredential.ExpirationDate != nil && verifiableCredential.ExpirationDate.Before(now) {
+			continue
+		}
+		authorization, err := toAuthorization(verifiableCredential)
+		if err != nil {
+			return nil, fmt.Errorf("invalid NutsAuthorizationCredential (id=%s): %w", verifiableCredential.ID, err)
+		}
+		result = append(result, *authorization)
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].IssuanceDate.After(result[j].IssuanceDate)
+	})
+	return result, nil
+}
+
+func validateAuthorization(customer domain.Customer, request domain.AuthorizationRequest) error {
+	if _, err := did.ParseDID(request.Organization); err != nil {
+		return fmt.Errorf("%w: invalid organization: %s", ErrInvalidAuthorization, err)
+	}
+	if request.Organization == *customer.Did {
+		return fmt.Errorf("%w: customer can't authorize itself", ErrInvalidAuthorization)
+	}
+	if len(strings.TrimSpace(request.PurposeOfUse)) == 0 {
+		return fmt.Errorf("%w: purposeOfUse must be given", ErrInvalidAuthorization)
+	}
+	if len(request.Resources) == 0 {
+		return fmt.Errorf("%w: at least 1 resource must be given", ErrInvalidAuthorization)
+	}
+	for _, resource := range request.Resources {
+		if !strings.HasPrefix(resource.Path, "/") {
+			return fmt.Errorf("%w: resource path must start with /: %s", ErrInvalidAuthorization, resource.Path)
+		}
+		if len(resource.Operations) == 0 {
+			return fmt.Errorf("%w: resource %s must have at least 1 operation", ErrInvalidAuthorization, resource.Path)
+		}
+		for _, operation := range resource.Operations {
+			if !validOperations[operation] {
+				return fmt.Errorf("%w: resource %s has invalid operation: %s", ErrInvalidAuthorization, resource.Path, operation)
+			}
+		}
+		if resource.AssuranceLevel != nil && !validAssuranceLevels[*resource.AssuranceLevel] {
+			return fmt.Errorf("%w: resource %s has invalid assuranceLevel: %s", ErrInvalidAuthorization, resource.Path, *resource.AssuranceLevel)
+		}
+	}
+	if request.Patient != nil && len(*request.Patient) > 0 {
+		if _, err := ssi.ParseURI(*request.Patient); err != nil {
+			return fmt.Errorf("%w: patient must be a URI: %s", ErrInvalidAuthorization, err)
+		}
+	}
+	if !request.ValidUntil.After(time.Now()) {
+		return fmt.Errorf("%w: validUntil must be in the future", ErrInvalidAuthorization)
+	}
+	if request.ValidFrom != nil && !request.ValidFrom.Before(request.ValidUntil) {
+		return fmt.Errorf("%w: validFrom must be before validUntil", ErrInvalidAuthorization)
+	}
+	return nil
+}
+
+func toAuthorization(verifiableCredential vc.VerifiableCredential) (*domain.Authorization, error) {
+	var subjects []authorizationSubject
+	if err := verifiableCredential.UnmarshalCredentialSubject(&subjects); err != nil {
+		return nil, err
+	}
+	if len(subjects) != 1 {
+		return nil, fmt.Errorf("expected 1 credential subject, found %d", len(subjects))
+	}
+	result := domain.Authorization{
+		Id:             verifiableCredential.ID.String(),
+		Organization:   subjects[0].ID,
+		PurposeOfUse:   subjects[0].PurposeOfUse,
+		Resources:      subjects[0].Resources,
+		Patient:        subjects[0].Subject,
+		IssuanceDate:   verifiableCredential.IssuanceDate,
+		ExpirationDate: verifiableCredential.ExpirationDate,
+	}
+	if result.Resources == nil {
+		result.Resources = []domain.AuthorizationResource{}
+	}
+	return &result, nil
+}
@@ -0,0 +1,180 @@
+package credentials
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nuts-foundation/nuts-node/vcr/credential"
+	"github.com/nuts-foundation/nuts-registry-admin-demo/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateAuthorization(t *testing.T) {
+	customerDID := "did:nuts:customer"
+	customer := domain.Customer{Id: 1, Name: "Care", Did: &customerDID}
+	validRequest := func() domain.AuthorizationRequest {
+		return domain.AuthorizationRequest{
+			Organization: "did:nuts:other",
+			PurposeOfUse: "eOverdracht",
+			Resources: []domain.AuthorizationResource{
+				{Path: "/Task/1", Operations: []domain.AuthorizationResourceOperations{domain.AuthorizationResourceOperationsRead}},
+			},
+			ValidUntil: time.Now().Add(time.Hour),
+		}
+	}
+	tests := []struct {
+		name        string
+		change      func(request *domain.AuthorizationRequest)
+		expectedErr string
+	}{
+		{name: "valid", change: func(*domain.AuthorizationRequest) {}},
+		{
+			name: "valid with optional fields",
+			change: func(request *domain.AuthorizationRequest) {
+				patient := "urn:oid:2.16.840.1.113883.2.4.6.3:123456780"
+				level := domain.AuthorizationResourceAssuranceLevelHigh
+				request.Patient = &patient
+				request.Resources[0].AssuranceLevel = &level
+			},
+		},
+		{
+			name:        "invalid organization",
+			change:      func(request *domain.AuthorizationRequest) { request.Organization = "other" },
+			expectedErr: "invalid organization",
+		},
+		{
+			name:        "customer authorizes itself",
+			change:      func(request *domain.AuthorizationRequest) { request.Organization = customerDID },
+			expectedErr: "customer can't authorize itself",
+		},
+		{
+			name:        "no purposeOfUse",
+			change:      func(request *domain.AuthorizationRequest) { request.PurposeOfUse = " " },
+			expectedErr: "purposeOfUse must be given",
+		},
+		{
+			name:        "no resources",
+			change:      func(request *domain.AuthorizationRequest) { request.Resources = nil },
+			expectedErr: "at least 1 resource must be given",
+		},
+		{
+			name:        "relative path",
+			change:      func(request *domain.AuthorizationRequest) { request.Resources[0].Path = "Task/1" },
+			expectedErr: "resource path must start with /: Task/1",
+		},
+		{
+			name:        "no operations",
+			change:      func(request *domain.AuthorizationRequest) { request.Resources[0].Operations = nil },
+			expectedErr: "resource /Task/1 must have at least 1 operation",
+		},
+		{
+			name: "invalid operation",
+			change: func(request *domain.AuthorizationRequest) {
+				request.Resources[0].Operations = []domain.AuthorizationResourceOperations{"write"}
+			},
+			expectedErr: "resource /Task/1 has invalid operation: write",
+		},
+		{
+			name: "invalid assurance level",
+			change: func(request *domain.AuthorizationRequest) {
+				level := domain.AuthorizationResourceAssuranceLevel("medium")
+				request.Resources[0].AssuranceLevel = &level
+			},
+			expectedErr: "resource /Task/1 has invalid assuranceLevel: medium",
+		},
+		{
+			name: "invalid patient",
+			change: func(request *domain.AuthorizationRequest) {
+				patient := "::"
+				request.Patient = &patient
+			},
+			expectedErr: "patient must be a URI",
+		},
+		{
+			name:        "validUntil in the past",
+			change:      func(request *domain.AuthorizationRequest) { request.ValidUntil = time.Now().Add(-time.Hour) },
+			expectedErr: "validUntil must be in the future",
+		},
+		{
+			name: "valid from a later moment",
+			change: func(request *domain.AuthorizationRequest) {
+				validFrom := request.ValidUntil.Add(-time.Minute)
+				request.ValidFrom = &validFrom
+			},
+		},
+		{
+			name: "validFrom equals validUntil",
+			change: func(request *domain.AuthorizationRequest) {
+				validFrom := request.ValidUntil
+				request.ValidFrom = &validFrom
+			},
+			expectedErr: "validFrom must be before validUntil",
+		},
+		{
+			name: "validFrom after validUntil",
+			change: func(request *domain.AuthorizationRequest) {
+				validFrom := request.ValidUntil.Add(time.Hour)
+				request.ValidFrom = &validFrom
+			},
+			expectedErr: "validFrom must be before validUntil",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			request := validRequest()
+			test.change(&request)
+
+			err := validateAuthorization(customer, request)
+
+			if test.expectedErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, ErrInvalidAuthorization)
+			assert.ErrorContains(t, err, test.expectedErr)
+		})
+	}
+}
+
+func TestAuthorizationIssueRequest(t *testing.T) {
+	customerDID := "did:nuts:customer"
+	customer := domain.Customer{Id: 1, Name: "Care", Did: &customerDID}
+	patient := "urn:oid:2.16.840.1.113883.2.4.6.3:123456780"
+	validFrom := time.Date(2030, 1, 1, 12, 0, 0, 0, time.FixedZone("CET", 3600))
+	request := domain.AuthorizationRequest{
+		Organization: "did:nuts:other",
+		PurposeOfUse: "eOverdracht",
+		Resources: []domain.AuthorizationResource{
+			{Path: "/Task/1", Operations: []domain.AuthorizationResourceOperations{domain.AuthorizationResourceOperationsRead}},
+		},
+		Patient:    &patient,
+		ValidUntil: validFrom.AddDate(1, 0, 0),
+	}
+
+	t.Run("valid from the moment it's issued", func(t *testing.T) {
+		result, err := authorizationIssueRequest(customer, request)
+
+		require.NoError(t, err)
+		assert.Equal(t, credential.NutsAuthorizationCredentialType, result.Type)
+		assert.Equal(t, customerDID, result.Issuer)
+		assert.Nil(t, result.IssuanceDate)
+		assert.Equal(t, "2031-01-01T11:00:00Z", *result.ExpirationDate)
+		assert.Equal(t, domain.IssueVCRequestVisibilityPrivate, *result.Visibility)
+		assert.Equal(t, "did:nuts:other", result.CredentialSubject["id"])
+		assert.Equal(t, "eOverdracht", result.CredentialSubject["purposeOfUse"])
+		assert.Equal(t, patient, result.CredentialSubject["subject"])
+		assert.Len(t, result.CredentialSubject["resources"], 1)
+	})
+	t.Run("valid from a given moment", func(t *testing.T) {
+		withValidFrom := request
+		withValidFrom.ValidFrom = &validFrom
+
+		result, err := authorizationIssueRequest(customer, withValidFrom)
+
+		require.NoError(t, err)
+		if assert.NotNil(t, result.IssuanceDate) {
+			assert.Equal(t, "2030-01-01T11:00:00Z", *result.IssuanceDate)
+		}
+	})
+}
@@ -0,0 +1,134 @@
+package credentials
+
+import (
+	"errors"
+	"sort"
+
+	ssi "github.com/nuts-foundation/go-did"
+	"github.com/nuts-foundation/go-did/vc"
+	vcrApi "github.com/nuts-foundation/nuts-node/vcr/api/vcr/v2"
+	"github.com/nuts-foundation/nuts-node/vcr/credential"
+	"github.com/nuts-foundation/nuts-registry-admin-demo/domain"
+)
+
+var ErrCredentialNotFound = errors.New("credential not found")
+
+var ErrNotRevocable = errors.New("only credentials issued by the service provider or the customer can be revoked")
+
+var ErrAlreadyRevoked = errors.New("credential has already been revoked")
+
+// CustomerCredentials returns all credentials issued to or by the customer's DID, including private and revoked credentials.
+// The most recently issued credential comes first.
+func (s Service) CustomerCredentials(customer domain.Customer) ([]domain.Credential, error) {
+	serviceProvider, err := s.SPService.Get()
+	if err != nil {
+		return nil, err
+	}
+	found, err := s.findCustomerCredentials(customer, serviceProvider)
+	if err != nil {
+		return nil, err
+	}
+	return sortedCredentials(found), nil
+}
+
+// RevokeCustomerCredential revokes a credential of the customer, which must have been issued by the service provider or the customer.
+func (s Service) RevokeCustomerCredential(customer domain.Customer, credentialID string) error {
+	serviceProvider, err := s.SPService.Get()
+	if err != nil {
+		return err
+	}
+	found, err := s.findCustomerCredentials(customer, serviceProvider)
+	if err != nil {
+		return err
+	}
+	if err := checkRevocable(found, credentialID, customer, serviceProvider); err != nil {
+		return err
+	}
+	return s.revoke(credentialID)
+}
+
+// sortedCredentials converts the found credentials, the most recently issued first.
+func sortedCredentials(found map[string]vcrApi.SearchVCResult) []domain.Credential {
+	result := make([]domain.Credential, 0, len(found))
+	for _, curr := range found {
+		result = append(result, toCredential(curr))
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].IssuanceDate.Equal(result[j].IssuanceDate) {
+			return result[i].Id < result[j].Id
+		}
+		return result[i].IssuanceDate.After(result[j].IssuanceDate)
+	})
+	return result
+}
+
+// checkRevocable checks whether the credential is one of the customer's credentials that hasn't been revoked yet,
+// and whether it was issued by the customer or the service provider.
+func checkRevocable(found map[string]vcrApi.SearchVCResult, credentialID string, customer domain.Customer, serviceProvider *domain.ServiceProvider) error {
+	target, ok := found[credentialID]
+	if !ok {
+		return ErrCredentialNotFound
+	}
+	if target.Revocation != nil {
+		return ErrAlreadyRevoked
+	}
+	issuer := target.VerifiableCredential.Issuer.String()
+	if customer.Did != nil && issuer == *customer.Did {
+		return nil
+	}
+	if serviceProvider == nil || issuer != serviceProvider.Id {
+		return ErrNotRevocable
+	}
+	return nil
+}
+
+// findCustomerCredentials collects the credentials of the customer, keyed by their ID:
+// - credentials issued to the customer found in the VCR, of the Nuts credential types,
+// - credentials of the known types issued to the customer by the service provider,
+// - credentials of the known types issued by the customer.
+// The latter two also include private credentials, which are only known to the issuer.
+func (s Service) findCustomerCredentials(customer domain.Customer, serviceProvider *domain.ServiceProvider) (map[string]vcrApi.SearchVCResult, error) {
+	result := make(map[string]vcrApi.SearchVCResult)
+	if customer.Did == nil {
+		return result, nil
+	}
+	add := func(results []vcrApi.SearchVCResult) {
+		for _, curr := range results {
+			if curr.VerifiableCredential.ID != nil {
+				result[curr.VerifiableCredential.ID.String()] = curr
+			}
+		}
+	}
+
+	allowUntrusted := true
+	for _, credentialType := range []string{credential.NutsOrganizationCredentialType, credential.NutsAuthorizationCredentialType} {
+		held, err := s.searchVCs(SearchVCRequest{
+			Query: SearchVCQuery{
+				Type:              []ssi.URI{ssi.MustParseURI(credentialType), ssi.MustParseURI(vc.VerifiableCredentialType)},
+				Context:           []ssi.URI{ssi.MustParseURI(vc.VCContextV1), ssi.MustParseURI(credential.NutsV1Context)},
+				CredentialSubject: map[string]interface{}{"id": *customer.Did},
+			},
+			SearchOptions: &vcrApi.SearchOptions{AllowUntrustedIssuer: &allowUntrusted},
+		})
+		if err != nil {
+			return nil, err
+		}
+		add(held)
+	}
+
+	for _, credentialType := range s.knownTypes() {
+		if serviceProvider != nil {
+			issuedTo, err := s.searchIssued(credentialType, serviceProvider.Id, customer.Did)
+			if err != nil {
+				return nil, err
+			}
+			add(issuedTo)
+		}
+		issuedBy, err := s.searchIssued(credentialType, *customer.Did, nil)
+		if err != nil {
+			return nil, err
+		}
+		add(issuedBy)
+	}
+	return result, nil
+}
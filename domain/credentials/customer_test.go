@@ -0,0 +1,83 @@
+package credentials
+
+import (
+	"testing"
+	"time"
+
+	ssi "github.com/nuts-foundation/go-did"
+	"github.com/nuts-foundation/go-did/vc"
+	vcrApi "github.com/nuts-foundation/nuts-node/vcr/api/vcr/v2"
+	"github.com/nuts-foundation/nuts-node/vcr/credential"
+	"github.com/nuts-foundation/nuts-registry-admin-demo/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSortedCredentials(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	year := 365 * 24 * time.Hour
+	oldest := testCredential("did:nuts:sp#1", credential.NutsOrganizationCredentialType, "did:nuts:1", now.AddDate(-2, 0, 0), year)
+	newest := testCredential("did:nuts:sp#2", credential.NutsOrganizationCredentialType, "did:nuts:1", now, year)
+	sameAsNewest := testCredential("did:nuts:sp#3", credential.NutsAuthorizationCredentialType, "did:nuts:1", now, year)
+	revocation := &vcrApi.Revocation{Date: now, Reason: "no longer valid"}
+
+	result := sortedCredentials(map[string]vcrApi.SearchVCResult{
+		"did:nuts:sp#1": {VerifiableCredential: oldest, Revocation: revocation},
+		"did:nuts:sp#2": {VerifiableCredential: newest},
+		"did:nuts:sp#3": {VerifiableCredential: sameAsNewest},
+	})
+
+	if !assert.Len(t, result, 3) {
+		return
+	}
+	assert.Equal(t, "did:nuts:sp#2", result[0].Id)
+	assert.Equal(t, "did:nuts:sp#3", result[1].Id)
+	assert.Equal(t, "did:nuts:sp#1", result[2].Id)
+	assert.Equal(t, "did:nuts:1", result[0].Subject)
+	assert.Nil(t, result[0].Revocation)
+	if assert.NotNil(t, result[2].Revocation) {
+		assert.Equal(t, "no longer valid", *result[2].Revocation.Reason)
+	}
+}
+
+func TestCheckRevocable(t *testing.T) {
+	now := time.Now()
+	customerDID := "did:nuts:customer"
+	customer := domain.Customer{Id: 1, Did: &customerDID}
+	serviceProvider := &domain.ServiceProvider{Id: "did:nuts:sp"}
+	issuedBy := func(issuer string) vc.VerifiableCredential {
+		result := testCredential(issuer+"#1", credential.NutsAuthorizationCredentialType, customerDID, now, time.Hour)
+		result.Issuer = ssi.MustParseURI(issuer)
+		return result
+	}
+	found := map[string]vcrApi.SearchVCResult{
+		"did:nuts:sp#1":       {VerifiableCredential: issuedBy("did:nuts:sp")},
+		"did:nuts:customer#1": {VerifiableCredential: issuedBy("did:nuts:customer")},
+		"did:nuts:other#1":    {VerifiableCredential: issuedBy("did:nuts:other")},
+		"did:nuts:revoked#1":  {VerifiableCredential: issuedBy("did:nuts:sp"), Revocation: &vcrApi.Revocation{Date: now}},
+	}
+
+	tests := []struct {
+		name            string
+		credentialID    string
+		serviceProvider *domain.ServiceProvider
+		expected        error
+	}{
+		{name: "issued by the service provider", credentialID: "did:nuts:sp#1", serviceProvider: serviceProvider},
+		{name: "issued by the customer", credentialID: "did:nuts:customer#1", serviceProvider: serviceProvider},
+		{name: "issued by the customer, no service provider", credentialID: "did:nuts:customer#1"},
+		{name: "issued by someone else", credentialID: "did:nuts:other#1", serviceProvider: serviceProvider, expected: ErrNotRevocable},
+		{name: "issued by the service provider, no service provider", credentialID: "did:nuts:sp#1", expected: ErrNotRevocable},
+		{name: "already revoked", credentialID: "did:nuts:revoked#1", serviceProvider: serviceProvider, expected: ErrAlreadyRevoked},
+		{name: "not found", credentialID: "did:nuts:sp#2", serviceProvider: serviceProvider, expected: ErrCredentialNotFound},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := checkRevocable(found, test.credentialID, customer, test.serviceProvider)
+			if test.expected == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, test.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,190 @@
+package credentials
+
+import (
+	"sync"
+	"time"
+
+	ssi "github.com/nuts-foundation/go-did"
+	"github.com/nuts-foundation/go-did/vc"
+	vcrApi "github.com/nuts-foundation/nuts-node/vcr/api/vcr/v2"
+	"github.com/nuts-foundation/nuts-node/vcr/credential"
+	"github.com/nuts-foundation/nuts-registry-admin-demo/domain"
+	"github.com/sirupsen/logrus"
+)
+
+// issuerLookupWorkers is the maximum number of issuers which are looked up concurrently.
+const issuerLookupWorkers = 8
+
+// issuerInfo contains what's known about an issuer, apart from whether it's trusted.
+type issuerInfo struct {
+	serviceProvider  domain.ServiceProvider
+	organizationName *string
+}
+
+func (i issuerInfo) credentialIssuer(trusted bool) domain.CredentialIssuer {
+	return domain.CredentialIssuer{Trusted: trusted, ServiceProvider: i.serviceProvider, OrganizationName: i.organizationName}
+}
+
+// IssuerCache caches the contact information and organization names of issuers, keyed by DID.
+// Expired entries are reused when the issuer's DID document hasn't been updated since they were cached.
+// Entries which have been expired for longer than the TTL are evicted when reading from the cache.
+type IssuerCache struct {
+	TTL     time.Duration
+	mutex   sync.Mutex
+	entries map[string]issuerCacheEntry
+	// lastEviction is when the expired entries were last evicted, which is done at most once per TTL.
+	lastEviction time.Time
+}
+
+type issuerCacheEntry struct {
+	info            issuerInfo
+	expires         time.Time
+	documentUpdated *time.Time
+}
+
+func NewIssuerCache(ttl time.Duration) *IssuerCache {
+	return &IssuerCache{TTL: ttl, entries: map[string]issuerCacheEntry{}}
+}
+
+// Invalidate removes the issuer from the cache, e.g. because its DID document has been updated.
+func (c *IssuerCache) Invalidate(id string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	delete(c.entries, id)
+}
+
+func (c *IssuerCache) get(id string) (issuerCacheEntry, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.evict(time.Now())
+	entry, ok := c.entries[id]
+	return entry, ok
+}
+
+// evict removes the entries which have been expired for longer than the TTL, so issuers which aren't looked up anymore
+// don't stay in the cache forever. The caller must hold the mutex.
+func (c *IssuerCache) evict(now time.Time) {
+	if now.Sub(c.lastEviction) < c.TTL {
+		return
+	}
+	c.lastEviction = now
+	for id, entry := range c.entries {
+		if now.After(entry.expires.Add(c.TTL)) {
+			delete(c.entries, id)
+		}
+	}
+}
+
+func (c *IssuerCache) put(id string, info issuerInfo, documentUpdated *time.Time) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.entries[id] = issuerCacheEntry{info: info, expires: time.Now().Add(c.TTL), documentUpdated: documentUpdated}
+}
+
+// lookupIssuers looks up the given issuers concurrently, keyed by their ID. Duplicate IDs are looked up once.
+func (s Service) lookupIssuers(ids []ssi.URI) map[string]issuerInfo {
+	unique := make(map[string]ssi.URI, len(ids))
+	for _, id := range ids {
+		unique[id.String()] = id
+	}
+	result := make(map[string]issuerInfo, len(unique))
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+	semaphore := make(chan struct{}, issuerLookupWorkers)
+	for _, id := range unique {
+		wg.Add(1)
+		go func(id ssi.URI) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+			info := s.lookupIssuer(id)
+			mutex.Lock()
+			result[id.String()] = info
+			mutex.Unlock()
+		}(id)
+	}
+	wg.Wait()
+	return result
+}
+
+// lookupIssuer returns the contact information and organization name of the issuer, using the cache when possible.
+// Lookup failures are logged and ignored, so the issuer can still be shown by its ID.
+func (s Service) lookupIssuer(id ssi.URI) issuerInfo {
+	info := issuerInfo{serviceProvider: domain.ServiceProvider{Id: id.String()}}
+	if id.Scheme != "did" {
+		return info
+	}
+
+	var documentUpdated *time.Time
+	if s.IssuerCache != nil {
+		entry, cached := s.IssuerCache.get(info.serviceProvider.Id)
+		if cached && time.Now().Before(entry.expires) {
+			return entry.info
+		}
+		_, metadata, err := s.VDRClient.Get(info.serviceProvider.Id)
+		if err == nil && metadata != nil {
+			documentUpdated = metadata.Updated
+			if cached && sameTime(entry.documentUpdated, documentUpdated) {
+				s.IssuerCache.put(info.serviceProvider.Id, entry.info, documentUpdated)
+				return entry.info
+			}
+		}
+	}
+
+	contactInformation, err := s.DIDManClient.GetContactInformation(info.serviceProvider.Id)
+	if err != nil {
+		// ignore so we can still see the DID
+		logrus.Warnf("Unable to get contactinfo (did=%s)", id.String())
+	} else if contactInformation != nil {
+		info.serviceProvider.Email = contactInformation.Email
+		info.serviceProvider.Name = contactInformation.Name
+		info.serviceProvider.Phone = contactInformation.Phone
+		info.serviceProvider.Website = contactInformation.Website
+	}
+	info.organizationName = s.organizationName(info.serviceProvider.Id)
+
+	if s.IssuerCache != nil && err == nil {
+		s.IssuerCache.put(info.serviceProvider.Id, info, documentUpdated)
+	}
+	return info
+}
+
+// organizationName returns the organization name from a NutsOrganizationCredential of the DID, of any issuer.
+func (s Service) organizationName(id string) *string {
+	allowUntrusted := true
+	found, err := s.searchVCs(SearchVCRequest{
+		Query: SearchVCQuery{
+			Type:    []ssi.URI{ssi.MustParseURI(credential.NutsOrganizationCredentialType), ssi.MustParseURI(vc.VerifiableCredentialType)},
+			Context: []ssi.URI{ssi.MustParseURI(vc.VCContextV1), ssi.MustParseURI(credential.NutsV1Context)},
+			CredentialSubject: domain.NutsOrganizationCredentialSubject{
+				ID: id,
+				Organization: domain.Organization{
+					Name: "*",
+					City: "*",
+				},
+			},
+		},
+		SearchOptions: &vcrApi.SearchOptions{AllowUntrustedIssuer: &allowUntrusted},
+	})
+	if err != nil {
+		logrus.Warnf("Unable to search NutsOrganizationCredential (did=%s): %v", id, err)
+		return nil
+	}
+	for _, curr := range found {
+		var subjects []domain.NutsOrganizationCredentialSubject
+		if curr.Revocation != nil || curr.VerifiableCredential.UnmarshalCredentialSubject(&subjects) != nil {
+			continue
+		}
+		if len(subjects) > 0 && len(subjects[0].Organization.Name) > 0 {
+			return &subjects[0].Organization.Name
+		}
+	}
+	return nil
+}
+
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return a.Equal(*b)
+}
@@ -0,0 +1,67 @@
+package credentials
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nuts-foundation/nuts-registry-admin-demo/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIssuerCache(t *testing.T) {
+	info := issuerInfo{serviceProvider: domain.ServiceProvider{Id: "did:nuts:issuer", Name: "Issuer"}}
+
+	t.Run("cached", func(t *testing.T) {
+		cache := NewIssuerCache(time.Minute)
+		cache.put("did:nuts:issuer", info, nil)
+
+		entry, ok := cache.get("did:nuts:issuer")
+
+		assert.True(t, ok)
+		assert.Equal(t, info, entry.info)
+		assert.WithinDuration(t, time.Now().Add(time.Minute), entry.expires, time.Second)
+	})
+	t.Run("invalidated", func(t *testing.T) {
+		cache := NewIssuerCache(time.Minute)
+		cache.put("did:nuts:issuer", info, nil)
+
+		cache.Invalidate("did:nuts:issuer")
+
+		_, ok := cache.get("did:nuts:issuer")
+		assert.False(t, ok)
+	})
+	t.Run("expired entries", func(t *testing.T) {
+		now := time.Now()
+		tests := []struct {
+			name     string
+			expires  time.Time
+			expected bool
+		}{
+			{name: "not expired", expires: now.Add(time.Minute), expected: true},
+			{name: "recently expired, so it can be reused", expires: now.Add(-30 * time.Second), expected: true},
+			{name: "expired for longer than the TTL", expires: now.Add(-2 * time.Minute), expected: false},
+		}
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				cache := NewIssuerCache(time.Minute)
+				cache.entries["did:nuts:issuer"] = issuerCacheEntry{info: info, expires: test.expires}
+
+				_, ok := cache.get("did:nuts:issuer")
+
+				assert.Equal(t, test.expected, ok)
+			})
+		}
+	})
+	t.Run("evicted at most once per TTL", func(t *testing.T) {
+		cache := NewIssuerCache(time.Minute)
+		now := time.Now()
+		cache.evict(now)
+		cache.entries["did:nuts:issuer"] = issuerCacheEntry{info: info, expires: now.Add(-2 * time.Minute)}
+
+		cache.evict(now.Add(30 * time.Second))
+		assert.Len(t, cache.entries, 1)
+
+		cache.evict(now.Add(time.Minute))
+		assert.Empty(t, cache.entries)
+	})
+}
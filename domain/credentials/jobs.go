@@ -0,0 +1,46 @@
+package credentials
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/nuts-foundation/nuts-registry-admin-demo/domain"
+	"github.com/nuts-foundation/nuts-registry-admin-demo/domain/jobs"
+	"github.com/sirupsen/logrus"
+)
+
+// ActivateCustomersJobType is the type of the job which issues NutsOrganizationCredentials for many customers.
+const ActivateCustomersJobType = "activate-customers"
+
+// ActivateCustomersJobPayload is the payload of an ActivateCustomersJobType job.
+type ActivateCustomersJobPayload struct {
+	CustomerIDs []int `json:"customerIds"`
+}
+
+// HandleActivateCustomersJob issues a NutsOrganizationCredential for every customer in the payload which doesn't have one yet.
+func (s Service) HandleActivateCustomersJob(payload json.RawMessage, progress jobs.ProgressFunc) error {
+	var jobPayload ActivateCustomersJobPayload
+	if err := json.Unmarshal(payload, &jobPayload); err != nil {
+		return err
+	}
+
+	failures := 0
+	for i, id := range jobPayload.CustomerIDs {
+		_, err := s.CustomerRepository.Update(id, func(c domain.Customer) (*domain.Customer, error) {
+			if err := s.ManageNutsOrgCredential(c, true); err != nil {
+				return nil, err
+			}
+			c.Active = true
+			return &c, nil
+		})
+		if err != nil {
+			logrus.Warnf("Couldn't activate customer (id=%d): %v", id, err)
+			failures++
+		}
+		progress(i+1, len(jobPayload.CustomerIDs))
+	}
+	if failures > 0 {
+		return fmt.Errorf("couldn't activate %d of %d customers", failures, len(jobPayload.CustomerIDs))
+	}
+	return nil
+}
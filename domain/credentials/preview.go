@@ -0,0 +1,139 @@
+package credentials
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	ssi "github.com/nuts-foundation/go-did"
+	"github.com/nuts-foundation/go-did/did"
+	"github.com/nuts-foundation/go-did/vc"
+	"github.com/nuts-foundation/nuts-node/vcr/credential"
+	"github.com/nuts-foundation/nuts-registry-admin-demo/domain"
+)
+
+// builtinContexts are the JSON-LD contexts the Nuts node has built in, so it can process credentials without fetching them.
+var builtinContexts = []string{
+	vc.VCContextV1,
+	credential.NutsV1Context,
+	"https://w3c-ccg.github.io/lds-jws2020/contexts/lds-jws2020-v1.json",
+	"https://schema.org",
+}
+
+// Preview returns the unsigned credential as it would be issued for the request, with the problems found validating it locally.
+// The template is the template for the VC type, or nil if there's none. Its context is accepted besides the built-in contexts.
+func (s Service) Preview(request domain.IssueVCRequest, template *domain.VCTemplate) (*domain.IssueVCPreview, error) {
+	var problems []domain.FieldError
+	addProblem := func(field string, format string, args ...interface{}) {
+		problems = append(problems, domain.FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if len(request.Type) == 0 {
+		addProblem("/type", "type must be given")
+	} else if template == nil && !s.isKnownType(request.Type) {
+		addProblem("/type", "unknown VC type: no template exists for %s", request.Type)
+	}
+
+	ldContext := credential.NutsV1Context
+	if request.Context != nil && len(*request.Context) > 0 {
+		ldContext = *request.Context
+	}
+	if !isKnownContext(ldContext, template) {
+		addProblem("/@context/1", "JSON-LD context isn't built in and doesn't belong to the template: %s", ldContext)
+	}
+
+	if err := s.resolveDID(request.Issuer); err != nil {
+		addProblem("/issuer", "%s", err)
+	}
+	subjectDID, _ := request.CredentialSubject["id"].(string)
+	if len(subjectDID) == 0 {
+		addProblem("/credentialSubject/0/id", "subject DID must be given")
+	} else if err := s.resolveDID(subjectDID); err != nil {
+		addProblem("/credentialSubject/0/id", "%s", err)
+	}
+
+	if request.ExpirationDate == nil {
+		request.ExpirationDate = s.expirationDate(request.Type)
+	}
+	issuanceDate := time.Now().UTC().Truncate(time.Second)
+	var expirationDate *time.Time
+	if request.ExpirationDate != nil {
+		parsed, err := time.Parse(time.RFC3339, *request.ExpirationDate)
+		if err != nil {
+			addProblem("/expirationDate", "invalid expiration date: %s", err)
+		} else if !parsed.After(issuanceDate) {
+			addProblem("/expirationDate", "expiration date must be in the future")
+		} else {
+			expirationDate = &parsed
+		}
+	}
+
+	unsigned := vc.VerifiableCredential{
+		Context:           []ssi.URI{ssi.MustParseURI(vc.VCContextV1)},
+		Type:              []ssi.URI{ssi.MustParseURI(vc.VerifiableCredentialType)},
+		IssuanceDate:      issuanceDate,
+		ExpirationDate:    expirationDate,
+		CredentialSubject: []interface{}{request.CredentialSubject},
+	}
+	if parsed, err := ssi.ParseURI(ldContext); err == nil {
+		unsigned.Context = append(unsigned.Context, *parsed)
+	}
+	if parsed, err := ssi.ParseURI(request.Type); err == nil && len(request.Type) > 0 {
+		unsigned.Type = append(unsigned.Type, *parsed)
+	}
+	if parsed, err := ssi.ParseURI(request.Issuer); err == nil {
+		unsigned.Issuer = *parsed
+	}
+	credentialData, err := json.Marshal(unsigned)
+	if err != nil {
+		return nil, err
+	}
+	result := domain.IssueVCPreview{
+		PublishToNetwork: request.PublishToNetwork == nil || *request.PublishToNetwork,
+		Visibility:       domain.IssueVCPreviewVisibility(domain.IssueVCRequestVisibilityPrivate),
+		Problems:         []domain.FieldError{},
+	}
+	if err := json.Unmarshal(credentialData, &result.Credential); err != nil {
+		return nil, err
+	}
+	// The node adds the ID and proof when signing
+	delete(result.Credential, "proof")
+	if request.Visibility != nil {
+		result.Visibility = domain.IssueVCPreviewVisibility(*request.Visibility)
+	}
+	result.Problems = append(result.Problems, problems...)
+	result.Valid = len(result.Problems) == 0
+	return &result, nil
+}
+
+func (s Service) isKnownType(credentialType string) bool {
+	for _, curr := range s.knownTypes() {
+		if curr == credentialType {
+			return true
+		}
+	}
+	return false
+}
+
+func isKnownContext(ldContext string, template *domain.VCTemplate) bool {
+	if template != nil && template.Context == ldContext {
+		return true
+	}
+	for _, curr := range builtinContexts {
+		if curr == ldContext {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveDID returns an error when the DID is invalid or its DID document can't be resolved.
+func (s Service) resolveDID(id string) error {
+	if _, err := did.ParseDID(id); err != nil {
+		return fmt.Errorf("invalid DID: %w", err)
+	}
+	if _, _, err := s.VDRClient.Get(id); err != nil {
+		return fmt.Errorf("DID document can't be resolved: %w", domain.UnwrapAPIError(err))
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+package credentials
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nuts-foundation/go-did/did"
+	"github.com/nuts-foundation/nuts-node/vcr/credential"
+	vdrAPI "github.com/nuts-foundation/nuts-node/vdr/api/v1"
+	"github.com/nuts-foundation/nuts-registry-admin-demo/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestService_Preview(t *testing.T) {
+	stringPtr := func(value string) *string {
+		return &value
+	}
+	validRequest := func() domain.IssueVCRequest {
+		return domain.IssueVCRequest{
+			Type:              credential.NutsOrganizationCredentialType,
+			Issuer:            "did:nuts:sp",
+			CredentialSubject: domain.CredentialSubject{"id": "did:nuts:1", "organization": map[string]interface{}{"name": "Care", "city": "Utrecht"}},
+		}
+	}
+	template := &domain.VCTemplate{Type: "CustomCredential", Context: "https://example.com/v1"}
+	tests := []struct {
+		name     string
+		change   func(request *domain.IssueVCRequest)
+		template *domain.VCTemplate
+		// expectedProblems contains the expected problems, of which the actual messages must start with the given messages.
+		expectedProblems []domain.FieldError
+	}{
+		{name: "valid", change: func(*domain.IssueVCRequest) {}},
+		{
+			name: "template type and context",
+			change: func(request *domain.IssueVCRequest) {
+				request.Type = "CustomCredential"
+				request.Context = stringPtr("https://example.com/v1")
+			},
+			template: template,
+		},
+		{
+			name:             "no type",
+			change:           func(request *domain.IssueVCRequest) { request.Type = "" },
+			expectedProblems: []domain.FieldError{{Field: "/type", Message: "type must be given"}},
+		},
+		{
+			name:             "unknown type",
+			change:           func(request *domain.IssueVCRequest) { request.Type = "CustomCredential" },
+			expectedProblems: []domain.FieldError{{Field: "/type", Message: "unknown VC type: no template exists for CustomCredential"}},
+		},
+		{
+			name:             "unknown context",
+			change:           func(request *domain.IssueVCRequest) { request.Context = stringPtr("https://example.com/v1") },
+			expectedProblems: []domain.FieldError{{Field: "/@context/1", Message: "JSON-LD context isn't built in and doesn't belong to the template: https://example.com/v1"}},
+		},
+		{
+			name:             "issuer can't be resolved",
+			change:           func(request *domain.IssueVCRequest) { request.Issuer = "did:nuts:unknown" },
+			expectedProblems: []domain.FieldError{{Field: "/issuer", Message: "DID document can't be resolved: not found"}},
+		},
+		{
+			name:             "no subject DID",
+			change:           func(request *domain.IssueVCRequest) { delete(request.CredentialSubject, "id") },
+			expectedProblems: []domain.FieldError{{Field: "/credentialSubject/0/id", Message: "subject DID must be given"}},
+		},
+		{
+			name:             "invalid subject DID",
+			change:           func(request *domain.IssueVCRequest) { request.CredentialSubject["id"] = "subject" },
+			expectedProblems: []domain.FieldError{{Field: "/credentialSubject/0/id", Message: "invalid DID: "}},
+		},
+		{
+			name:             "expiration date in the past",
+			change:           func(request *domain.IssueVCRequest) { request.ExpirationDate = stringPtr("2020-01-01T00:00:00Z") },
+			expectedProblems: []domain.FieldError{{Field: "/expirationDate", Message: "expiration date must be in the future"}},
+		},
+		{
+			name:             "invalid expiration date",
+			change:           func(request *domain.IssueVCRequest) { request.ExpirationDate = stringPtr("tomorrow") },
+			expectedProblems: []domain.FieldError{{Field: "/expirationDate", Message: `invalid expiration date: parsing time "tomorrow" as "2006-01-02T15:04:05Z07:00": cannot parse "tomorrow" as "2006"`}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			vdrClient := domain.NewMockVDRClient(ctrl)
+			vdrClient.EXPECT().Get(gomock.Any()).DoAndReturn(func(id string) (*did.Document, *vdrAPI.DocumentMetadata, error) {
+				if id == "did:nuts:unknown" {
+					return nil, nil, errors.New("not found")
+				}
+				return &did.Document{ID: did.MustParseDID(id)}, &vdrAPI.DocumentMetadata{}, nil
+			}).AnyTimes()
+			service := Service{VDRClient: vdrClient, ValidityDays: map[string]int{credential.NutsOrganizationCredentialType: 365}}
+			request := validRequest()
+			test.change(&request)
+
+			preview, err := service.Preview(request, test.template)
+
+			require.NoError(t, err)
+			assert.Equal(t, len(test.expectedProblems) == 0, preview.Valid)
+			require.Len(t, preview.Problems, len(test.expectedProblems))
+			for i, expected := range test.expectedProblems {
+				assert.Equal(t, expected.Field, preview.Problems[i].Field)
+				assert.True(t, strings.HasPrefix(preview.Problems[i].Message, expected.Message), preview.Problems[i].Message)
+			}
+			assert.Equal(t, request.Issuer, preview.Credential["issuer"])
+			assert.NotContains(t, preview.Credential, "proof")
+		})
+	}
+}
+
+func TestService_Preview_Defaults(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	vdrClient := domain.NewMockVDRClient(ctrl)
+	vdrClient.EXPECT().Get(gomock.Any()).Return(&did.Document{}, &vdrAPI.DocumentMetadata{}, nil).AnyTimes()
+	service := Service{VDRClient: vdrClient, ValidityDays: map[string]int{credential.NutsOrganizationCredentialType: 365}}
+
+	preview, err := service.Preview(domain.IssueVCRequest{
+		Type:              credential.NutsOrganizationCredentialType,
+		Issuer:            "did:nuts:sp",
+		CredentialSubject: domain.CredentialSubject{"id": "did:nuts:1"},
+	}, nil)
+
+	require.NoError(t, err)
+	assert.True(t, preview.PublishToNetwork)
+	assert.Equal(t, domain.IssueVCPreviewVisibility(domain.IssueVCRequestVisibilityPrivate), preview.Visibility)
+	assert.Equal(t, []interface{}{"https://www.w3.org/2018/credentials/v1", credential.NutsV1Context}, preview.Credential["@context"])
+	assert.ElementsMatch(t, []interface{}{"VerifiableCredential", credential.NutsOrganizationCredentialType}, preview.Credential["type"])
+	expirationDate, err := time.Parse(time.RFC3339, preview.Credential["expirationDate"].(string))
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now().AddDate(0, 0, 365), expirationDate, time.Minute)
+}
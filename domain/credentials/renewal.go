@@ -0,0 +1,260 @@
+package credentials
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/nuts-foundation/go-did/vc"
+	vcrApi "github.com/nuts-foundation/nuts-node/vcr/api/vcr/v2"
+	"github.com/nuts-foundation/nuts-node/vcr/credential"
+	"github.com/nuts-foundation/nuts-registry-admin-demo/domain"
+	"github.com/nuts-foundation/nuts-registry-admin-demo/domain/jobs"
+	"github.com/sirupsen/logrus"
+)
+
+// RenewCredentialsJobType is the type of the job which renews the credentials that expire within the renewal period.
+const RenewCredentialsJobType = "renew-credentials"
+
+var ErrInvalidPeriod = errors.New("number of days must not be negative")
+
+// expirationDate returns the expiration date for a new credential of the given type, or nil if no validity is configured for the type.
+func (s Service) expirationDate(credentialType string) *string {
+	days := s.ValidityDays[credentialType]
+	if days <= 0 {
+		return nil
+	}
+	expirationDate := time.Now().AddDate(0, 0, days).UTC().Format(time.RFC3339)
+	return &expirationDate
+}
+
+// ScheduleRenewal periodically submits a job which renews the credentials that expire within the renewal period.
+func (s Service) ScheduleRenewal(queue *jobs.Queue, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if _, err := queue.Submit(RenewCredentialsJobType, struct{}{}); err != nil {
+				logrus.Errorf("Unable to submit credential renewal job: %v", err)
+			}
+		}
+	}()
+}
+
+// ExpiringCredentials returns the credentials issued by the service provider which expire within the given number of days,
+// the first expiring first.
+func (s Service) ExpiringCredentials(days int) ([]domain.ExpiringCredential, error) {
+	if days < 0 {
+		return nil, ErrInvalidPeriod
+	}
+	expiring, err := s.findExpiring(days)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]domain.ExpiringCredential, len(expiring))
+	for i, curr := range expiring {
+		result[i] = domain.ExpiringCredential{
+			Id:             curr.ID.String(),
+			Type:           credentialType(curr),
+			IssuanceDate:   curr.IssuanceDate,
+			ExpirationDate: *curr.ExpirationDate,
+		}
+		if subjectDID, err := curr.SubjectDID(); err == nil {
+			result[i].Subject = subjectDID.String()
+		}
+	}
+	return result, nil
+}
+
+// HandleRenewCredentialsJob re-issues every credential which expires within the renewal period and revokes the credential it replaces.
+func (s Service) HandleRenewCredentialsJob(_ json.RawMessage, progress jobs.ProgressFunc) error {
+	expiring, err := s.findExpiring(s.RenewalDays)
+	if err != nil {
+		return err
+	}
+
+	failures := 0
+	for i, curr := range expiring {
+		if err := s.renew(curr); err != nil {
+			logrus.Warnf("Couldn't renew credential (id=%s): %v", curr.ID, err)
+			failures++
+		}
+		progress(i+1, len(expiring))
+	}
+	if failures > 0 {
+		return fmt.Errorf("couldn't renew %d of %d credentials", failures, len(expiring))
+	}
+	return nil
+}
+
+// renew issues a new credential with the same subject, after which the given credential is revoked.
+// The new credential is valid for the configured validity of its type, or as long as the given credential was.
+// When the credential already has a successor, e.g. because revoking failed in an earlier attempt, it's only revoked,
+// so a retry never issues a second credential.
+func (s Service) renew(original vc.VerifiableCredential) error {
+	if len(original.CredentialSubject) != 1 {
+		return fmt.Errorf("expected 1 credential subject, found %d", len(original.CredentialSubject))
+	}
+	subjectDID, err := original.SubjectDID()
+	if err != nil {
+		return fmt.Errorf("unable to determine credential subject: %w", err)
+	}
+	subjectID := subjectDID.String()
+	issued, err := s.searchIssued(credentialType(original), original.Issuer.String(), &subjectID)
+	if err != nil {
+		return fmt.Errorf("unable to search for renewed credential: %w", err)
+	}
+	if successor := findSuccessor(original, issued); successor != nil {
+		logrus.Infof("Credential already renewed, revoking it (id=%s, renewed=%s)", original.ID, successor.ID)
+		return s.revokeRenewed(original)
+	}
+
+	subjectData, _ := json.Marshal(original.CredentialSubject[0])
+	subject := domain.CredentialSubject{}
+	if err := json.Unmarshal(subjectData, &subject); err != nil {
+		return err
+	}
+
+	request := domain.IssueVCRequest{
+		Type:              credentialType(original),
+		Issuer:            original.Issuer.String(),
+		CredentialSubject: subject,
+		ExpirationDate:    s.expirationDate(credentialType(original)),
+	}
+	for _, curr := range original.Context {
+		if curr.String() != vc.VCContextV1 {
+			ldContext := curr.String()
+			request.Context = &ldContext
+			break
+		}
+	}
+	visibility := domain.IssueVCRequestVisibilityPrivate
+	if request.Type == credential.NutsOrganizationCredentialType {
+		visibility = domain.IssueVCRequestVisibilityPublic
+	}
+	request.Visibility = &visibility
+	if request.ExpirationDate == nil {
+		expirationDate := time.Now().Add(original.ExpirationDate.Sub(original.IssuanceDate)).UTC().Format(time.RFC3339)
+		request.ExpirationDate = &expirationDate
+	}
+
+	renewed, err := s.Issue(request)
+	if err != nil {
+		return fmt.Errorf("unable to issue renewed credential: %w", err)
+	}
+	logrus.Infof("Renewed credential (id=%s, renewed=%s)", original.ID, renewed.ID)
+	return s.revokeRenewed(original)
+}
+
+func (s Service) revokeRenewed(original vc.VerifiableCredential) error {
+	if err := s.revoke(original.ID.String()); err != nil {
+		return fmt.Errorf("unable to revoke renewed credential: %w", domain.UnwrapAPIError(err))
+	}
+	return nil
+}
+
+// findSuccessor returns the credential which replaces the original credential: a credential of the same type and subject
+// which hasn't been revoked, issued after the original and valid for longer. Returns nil when there's no such credential.
+func findSuccessor(original vc.VerifiableCredential, issued []vcrApi.SearchVCResult) *vc.VerifiableCredential {
+	originalSubject, err := original.SubjectDID()
+	if err != nil {
+		return nil
+	}
+	for _, curr := range issued {
+		candidate := curr.VerifiableCredential
+		if curr.Revocation != nil || candidate.ID == nil || (original.ID != nil && candidate.ID.String() == original.ID.String()) {
+			continue
+		}
+		if credentialType(candidate) != credentialType(original) || !candidate.IssuanceDate.After(original.IssuanceDate) {
+			continue
+		}
+		if candidate.ExpirationDate != nil && original.ExpirationDate != nil && !candidate.ExpirationDate.After(*original.ExpirationDate) {
+			continue
+		}
+		if subject, err := candidate.SubjectDID(); err != nil || subject.String() != originalSubject.String() {
+			continue
+		}
+		return &candidate
+	}
+	return nil
+}
+
+// findExpiring returns the credentials of the known types issued by the service provider, which haven't been revoked and expire within the given number of days.
+func (s Service) findExpiring(days int) ([]vc.VerifiableCredential, error) {
+	serviceProvider, err := s.SPService.Get()
+	if err != nil {
+		return nil, err
+	}
+	if serviceProvider == nil {
+		return nil, nil
+	}
+
+	deadline := time.Now().AddDate(0, 0, days)
+	var result []vc.VerifiableCredential
+	for _, credentialType := range s.knownTypes() {
+		issued, err := s.searchIssued(credentialType, serviceProvider.Id, nil)
+		if err != nil {
+			return nil, err
+		}
+		for _, curr := range issued {
+			expirationDate := curr.VerifiableCredential.ExpirationDate
+			if curr.Revocation != nil || expirationDate == nil || curr.VerifiableCredential.ID == nil {
+				continue
+			}
+			if expirationDate.Before(deadline) {
+				result = append(result, curr.VerifiableCredential)
+			}
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].ExpirationDate.Before(*result[j].ExpirationDate)
+	})
+	return result, nil
+}
+
+// knownTypes returns the types of the credentials which are administered by the service provider:
+// the Nuts credential types and the types for which a validity is configured.
+func (s Service) knownTypes() []string {
+	result := []string{credential.NutsOrganizationCredentialType, credential.NutsAuthorizationCredentialType}
+	nutsTypes := len(result)
+	for credentialType := range s.ValidityDays {
+		if credentialType != credential.NutsOrganizationCredentialType && credentialType != credential.NutsAuthorizationCredentialType {
+			result = append(result, credentialType)
+		}
+	}
+	sort.Strings(result[nutsTypes:])
+	return result
+}
+
+// searchIssued returns the credentials of the given type issued by the given issuer, optionally only those issued to the given subject.
+func (s Service) searchIssued(credentialType string, issuer string, subject *string) ([]vcrApi.SearchVCResult, error) {
+	ctx, cancel := s.requestContext()
+	defer cancel()
+	response, err := s.client().SearchIssuedVCs(ctx, &vcrApi.SearchIssuedVCsParams{CredentialType: credentialType, Issuer: issuer, Subject: subject})
+	if err != nil {
+		return nil, domain.UnwrapAPIError(err)
+	}
+	if response.StatusCode != http.StatusOK {
+		return nil, domain.NodeResponseError(response)
+	}
+	body, _ := io.ReadAll(response.Body)
+	var results vcrApi.SearchVCResults
+	if err := json.Unmarshal(body, &results); err != nil {
+		return nil, fmt.Errorf("unable to unmarshal issued credentials: %w", err)
+	}
+	return results.VerifiableCredentials, nil
+}
+
+// credentialType returns the type of the credential, other than VerifiableCredential.
+func credentialType(verifiableCredential vc.VerifiableCredential) string {
+	for _, curr := range verifiableCredential.Type {
+		if curr.String() != vc.VerifiableCredentialType {
+			return curr.String()
+		}
+	}
+	return vc.VerifiableCredentialType
+}
@@ -0,0 +1,87 @@
+package credentials
+
+import (
+	"testing"
+	"time"
+
+	ssi "github.com/nuts-foundation/go-did"
+	"github.com/nuts-foundation/go-did/vc"
+	vcrApi "github.com/nuts-foundation/nuts-node/vcr/api/vcr/v2"
+	"github.com/nuts-foundation/nuts-node/vcr/credential"
+	"github.com/stretchr/testify/assert"
+)
+
+func testCredential(id string, credentialType string, subject string, issued time.Time, validity time.Duration) vc.VerifiableCredential {
+	credentialID := ssi.MustParseURI(id)
+	expirationDate := issued.Add(validity)
+	return vc.VerifiableCredential{
+		ID:                &credentialID,
+		Issuer:            ssi.MustParseURI("did:nuts:sp"),
+		Type:              []ssi.URI{ssi.MustParseURI(vc.VerifiableCredentialType), ssi.MustParseURI(credentialType)},
+		CredentialSubject: []interface{}{map[string]interface{}{"id": subject}},
+		IssuanceDate:      issued,
+		ExpirationDate:    &expirationDate,
+	}
+}
+
+func TestFindSuccessor(t *testing.T) {
+	issued := time.Now().AddDate(-1, 0, 0)
+	year := 365 * 24 * time.Hour
+	// Expires in 10 days, so it's being renewed
+	original := testCredential("did:nuts:sp#1", credential.NutsOrganizationCredentialType, "did:nuts:1", issued, year+10*24*time.Hour)
+	renewed := testCredential("did:nuts:sp#2", credential.NutsOrganizationCredentialType, "did:nuts:1", time.Now(), year)
+
+	tests := []struct {
+		name       string
+		issued     []vcrApi.SearchVCResult
+		expectedID string
+	}{
+		{name: "no other credentials", issued: []vcrApi.SearchVCResult{{VerifiableCredential: original}}},
+		{name: "renewed", issued: []vcrApi.SearchVCResult{{VerifiableCredential: original}, {VerifiableCredential: renewed}}, expectedID: "did:nuts:sp#2"},
+		{name: "renewed credential revoked", issued: []vcrApi.SearchVCResult{{VerifiableCredential: renewed, Revocation: &credential.Revocation{}}}},
+		{name: "other subject", issued: []vcrApi.SearchVCResult{{VerifiableCredential: testCredential("did:nuts:sp#2", credential.NutsOrganizationCredentialType, "did:nuts:2", time.Now(), year)}}},
+		{name: "other type", issued: []vcrApi.SearchVCResult{{VerifiableCredential: testCredential("did:nuts:sp#2", credential.NutsAuthorizationCredentialType, "did:nuts:1", time.Now(), year)}}},
+		{name: "issued before the original", issued: []vcrApi.SearchVCResult{{VerifiableCredential: testCredential("did:nuts:sp#2", credential.NutsOrganizationCredentialType, "did:nuts:1", issued.Add(-time.Hour), year)}}},
+		{name: "expires before the original", issued: []vcrApi.SearchVCResult{{VerifiableCredential: testCredential("did:nuts:sp#2", credential.NutsOrganizationCredentialType, "did:nuts:1", time.Now(), time.Hour)}}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			successor := findSuccessor(original, test.issued)
+
+			if test.expectedID == "" {
+				assert.Nil(t, successor)
+				return
+			}
+			if assert.NotNil(t, successor) {
+				assert.Equal(t, test.expectedID, successor.ID.String())
+			}
+		})
+	}
+}
+
+func TestService_expirationDate(t *testing.T) {
+	service := Service{ValidityDays: map[string]int{credential.NutsOrganizationCredentialType: 365, "Disabled": 0}}
+
+	tests := []struct {
+		name           string
+		credentialType string
+		expectedDays   int
+	}{
+		{name: "configured", credentialType: credential.NutsOrganizationCredentialType, expectedDays: 365},
+		{name: "zero days", credentialType: "Disabled"},
+		{name: "not configured", credentialType: credential.NutsAuthorizationCredentialType},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			expirationDate := service.expirationDate(test.credentialType)
+
+			if test.expectedDays == 0 {
+				assert.Nil(t, expirationDate)
+				return
+			}
+			parsed, err := time.Parse(time.RFC3339, *expirationDate)
+			assert.NoError(t, err)
+			assert.WithinDuration(t, time.Now().AddDate(0, 0, test.expectedDays), parsed, time.Minute)
+		})
+	}
+}
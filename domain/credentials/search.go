@@ -0,0 +1,133 @@
+package credentials
+
+import (
+	"errors"
+	"fmt"
+
+	ssi "github.com/nuts-foundation/go-did"
+	"github.com/nuts-foundation/go-did/did"
+	"github.com/nuts-foundation/go-did/vc"
+	vcrApi "github.com/nuts-foundation/nuts-node/vcr/api/vcr/v2"
+	"github.com/nuts-foundation/nuts-node/vcr/credential"
+	"github.com/nuts-foundation/nuts-registry-admin-demo/domain"
+	"github.com/sirupsen/logrus"
+)
+
+var ErrInvalidSearch = errors.New("invalid search")
+
+// SearchVCs searches the credentials known to the Nuts node. Unless specified otherwise, credentials of untrusted issuers are included.
+func (s Service) SearchVCs(request domain.SearchVCsRequest) ([]domain.Credential, error) {
+	query, err := toSearchVCQuery(request)
+	if err != nil {
+		return nil, err
+	}
+	allowUntrusted := true
+	if request.AllowUntrustedIssuer != nil {
+		allowUntrusted = *request.AllowUntrustedIssuer
+	}
+	found, err := s.searchVCs(SearchVCRequest{
+		Query:         *query,
+		SearchOptions: &vcrApi.SearchOptions{AllowUntrustedIssuer: &allowUntrusted},
+	})
+	if err != nil {
+		return nil, err
+	}
+	result := make([]domain.Credential, 0, len(found))
+	for _, curr := range found {
+		if curr.VerifiableCredential.ID != nil {
+			result = append(result, toCredential(curr))
+		}
+	}
+	return result, nil
+}
+
+// RevokeVCs revokes the given credentials one by one, returning the result for every credential.
+func (s Service) RevokeVCs(ids []string) []domain.RevocationResult {
+	result := make([]domain.RevocationResult, len(ids))
+	for i, id := range ids {
+		result[i] = domain.RevocationResult{Id: id, Revoked: true}
+		if err := s.revoke(id); err != nil {
+			logrus.Warnf("Unable to revoke credential (id=%s): %v", id, err)
+			msg := domain.UnwrapAPIError(err).Error()
+			result[i].Revoked = false
+			result[i].Error = &msg
+		}
+	}
+	return result
+}
+
+func toSearchVCQuery(request domain.SearchVCsRequest) (*SearchVCQuery, error) {
+	if len(request.Type) == 0 {
+		return nil, fmt.Errorf("%w: type must be given", ErrInvalidSearch)
+	}
+	credentialType, err := ssi.ParseURI(request.Type)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid type: %s", ErrInvalidSearch, err)
+	}
+	ldContext := ssi.MustParseURI(credential.NutsV1Context)
+	if request.Context != nil && len(*request.Context) > 0 {
+		parsedContext, err := ssi.ParseURI(*request.Context)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid @context: %s", ErrInvalidSearch, err)
+		}
+		ldContext = *parsedContext
+	}
+	query := SearchVCQuery{
+		Context: []ssi.URI{ssi.MustParseURI(vc.VCContextV1), ldContext},
+		Type:    []ssi.URI{*credentialType, ssi.MustParseURI(vc.VerifiableCredentialType)},
+	}
+	if request.Issuer != nil && len(*request.Issuer) > 0 {
+		if _, err := did.ParseDID(*request.Issuer); err != nil {
+			return nil, fmt.Errorf("%w: invalid issuer: %s", ErrInvalidSearch, err)
+		}
+		query.Issuer = *request.Issuer
+	}
+
+	credentialSubject := make(map[string]interface{})
+	if request.CredentialSubject != nil {
+		for key, value := range *request.CredentialSubject {
+			credentialSubject[key] = value
+		}
+	}
+	if request.Subject != nil && len(*request.Subject) > 0 {
+		if _, err := did.ParseDID(*request.Subject); err != nil {
+			return nil, fmt.Errorf("%w: invalid subject: %s", ErrInvalidSearch, err)
+		}
+		if id, ok := credentialSubject["id"]; ok && id != *request.Subject {
+			return nil, fmt.Errorf("%w: subject and credentialSubject.id differ", ErrInvalidSearch)
+		}
+		credentialSubject["id"] = *request.Subject
+	}
+	if len(credentialSubject) > 0 {
+		query.CredentialSubject = credentialSubject
+	}
+	return &query, nil
+}
+
+func toCredential(searchResult vcrApi.SearchVCResult) domain.Credential {
+	verifiableCredential := searchResult.VerifiableCredential
+	result := domain.Credential{
+		Id:                verifiableCredential.ID.String(),
+		Type:              credentialType(verifiableCredential),
+		Issuer:            verifiableCredential.Issuer.String(),
+		IssuanceDate:      verifiableCredential.IssuanceDate,
+		ExpirationDate:    verifiableCredential.ExpirationDate,
+		CredentialSubject: map[string]interface{}{},
+	}
+	if len(verifiableCredential.CredentialSubject) > 0 {
+		if subject, ok := verifiableCredential.CredentialSubject[0].(map[string]interface{}); ok {
+			result.CredentialSubject = subject
+		}
+	}
+	if subjectDID, err := verifiableCredential.SubjectDID(); err == nil {
+		result.Subject = subjectDID.String()
+	}
+	if searchResult.Revocation != nil {
+		result.Revocation = &domain.CredentialRevocation{Date: searchResult.Revocation.Date}
+		if len(searchResult.Revocation.Reason) > 0 {
+			reason := searchResult.Revocation.Reason
+			result.Revocation.Reason = &reason
+		}
+	}
+	return result
+}
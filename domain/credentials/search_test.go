@@ -0,0 +1,97 @@
+package credentials
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/nuts-foundation/nuts-registry-admin-demo/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestToSearchVCQuery(t *testing.T) {
+	stringPtr := func(value string) *string {
+		return &value
+	}
+	subject := func(values map[string]interface{}) *map[string]interface{} {
+		return &values
+	}
+	tests := []struct {
+		name          string
+		request       domain.SearchVCsRequest
+		expectedQuery string
+		expectedErr   string
+	}{
+		{
+			name:          "type only",
+			request:       domain.SearchVCsRequest{Type: "NutsOrganizationCredential"},
+			expectedQuery: `{"@context":["https://www.w3.org/2018/credentials/v1","https://nuts.nl/credentials/v1"],"type":["NutsOrganizationCredential","VerifiableCredential"]}`,
+		},
+		{
+			name: "all fields",
+			request: domain.SearchVCsRequest{
+				Type:              "CustomCredential",
+				Context:           stringPtr("https://example.com/v1"),
+				Issuer:            stringPtr("did:nuts:issuer"),
+				Subject:           stringPtr("did:nuts:subject"),
+				CredentialSubject: subject(map[string]interface{}{"organization": map[string]interface{}{"city": "Amster*"}}),
+			},
+			expectedQuery: `{"@context":["https://www.w3.org/2018/credentials/v1","https://example.com/v1"],"type":["CustomCredential","VerifiableCredential"],"issuer":"did:nuts:issuer","credentialSubject":{"id":"did:nuts:subject","organization":{"city":"Amster*"}}}`,
+		},
+		{
+			name:          "empty optional fields are ignored",
+			request:       domain.SearchVCsRequest{Type: "NutsOrganizationCredential", Context: stringPtr(""), Issuer: stringPtr(""), Subject: stringPtr(""), CredentialSubject: subject(map[string]interface{}{})},
+			expectedQuery: `{"@context":["https://www.w3.org/2018/credentials/v1","https://nuts.nl/credentials/v1"],"type":["NutsOrganizationCredential","VerifiableCredential"]}`,
+		},
+		{
+			name:          "same subject in credentialSubject",
+			request:       domain.SearchVCsRequest{Type: "NutsOrganizationCredential", Subject: stringPtr("did:nuts:subject"), CredentialSubject: subject(map[string]interface{}{"id": "did:nuts:subject"})},
+			expectedQuery: `{"@context":["https://www.w3.org/2018/credentials/v1","https://nuts.nl/credentials/v1"],"type":["NutsOrganizationCredential","VerifiableCredential"],"credentialSubject":{"id":"did:nuts:subject"}}`,
+		},
+		{
+			name:        "no type",
+			request:     domain.SearchVCsRequest{},
+			expectedErr: "invalid search: type must be given",
+		},
+		{
+			name:        "invalid type",
+			request:     domain.SearchVCsRequest{Type: "::"},
+			expectedErr: "invalid search: invalid type",
+		},
+		{
+			name:        "invalid context",
+			request:     domain.SearchVCsRequest{Type: "NutsOrganizationCredential", Context: stringPtr("::")},
+			expectedErr: "invalid search: invalid @context",
+		},
+		{
+			name:        "invalid issuer",
+			request:     domain.SearchVCsRequest{Type: "NutsOrganizationCredential", Issuer: stringPtr("issuer")},
+			expectedErr: "invalid search: invalid issuer",
+		},
+		{
+			name:        "invalid subject",
+			request:     domain.SearchVCsRequest{Type: "NutsOrganizationCredential", Subject: stringPtr("subject")},
+			expectedErr: "invalid search: invalid subject",
+		},
+		{
+			name:        "other subject in credentialSubject",
+			request:     domain.SearchVCsRequest{Type: "NutsOrganizationCredential", Subject: stringPtr("did:nuts:subject"), CredentialSubject: subject(map[string]interface{}{"id": "did:nuts:other"})},
+			expectedErr: "invalid search: subject and credentialSubject.id differ",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			query, err := toSearchVCQuery(test.request)
+
+			if test.expectedErr != "" {
+				assert.ErrorIs(t, err, ErrInvalidSearch)
+				assert.ErrorContains(t, err, test.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			data, err := json.Marshal(query)
+			require.NoError(t, err)
+			assert.JSONEq(t, test.expectedQuery, string(data))
+		})
+	}
+}
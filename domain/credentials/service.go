@@ -10,13 +10,13 @@ import (
 	"io"
 	"net/http"
 	"net/url"
-	"time"
+	"sort"
 
 	"github.com/nuts-foundation/go-did/vc"
 	"github.com/nuts-foundation/nuts-node/vcr/credential"
 	"github.com/sirupsen/logrus"
 
-	didmanAPI "github.com/nuts-foundation/nuts-node/didman/api/v1"
+	"github.com/nuts-foundation/nuts-registry-admin-demo/domain/customers"
 	"github.com/nuts-foundation/nuts-registry-admin-demo/domain/sp"
 
 	ssi "github.com/nuts-foundation/go-did"
@@ -24,23 +24,40 @@ import (
 	"github.com/nuts-foundation/nuts-registry-admin-demo/domain"
 )
 
+var errNoServiceProvider = errors.New("no service-provider registered")
+
 type Service struct {
-	NutsNodeAddr string
 	SPService    sp.Service
-	DIDManClient didmanAPI.HTTPClient
-	VCRClient    vcrApi.HTTPClient
+	DIDManClient domain.DIDManClient
+	VDRClient    domain.VDRClient
+	// NodeClients is used to create the client of the VCR API, using the timeout at the time of the call.
+	NodeClients        *domain.NodeClients
+	CustomerRepository customers.Repository
+	// ValidityDays contains the number of days credentials of a certain type are valid, when issued without an expiration date.
+	ValidityDays map[string]int
+	// RenewalDays is the number of days before their expiration date credentials are renewed.
+	RenewalDays int
+	// TrustCredentialTypes contains the credential types of which the trusted issuers are managed.
+	TrustCredentialTypes []string
+	// IssuerCache caches the information of issuers. If nil, issuers are looked up every time.
+	IssuerCache *IssuerCache
 }
 
 func (s Service) client() vcrApi.ClientInterface {
-	url := s.NutsNodeAddr
+	config := s.NodeClients.ClientConfig()
 
-	response, err := vcrApi.NewClientWithResponses(url, vcrApi.WithHTTPClient(core.MustCreateHTTPClient(s.VCRClient.ClientConfig, s.VCRClient.TokenGenerator)))
+	response, err := vcrApi.NewClientWithResponses(config.Address, vcrApi.WithHTTPClient(core.MustCreateHTTPClient(config, s.NodeClients.TokenGenerator())))
 	if err != nil {
 		panic(err)
 	}
 	return response
 }
 
+// requestContext returns the context for a call to the VCR API, which is cancelled when the call exceeds the Nuts node timeout.
+func (s Service) requestContext() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), s.NodeClients.Timeout())
+}
+
 func (s Service) ManageNutsOrgCredential(customer domain.Customer, shouldHaveCredential bool) error {
 	credentials, err := s.GetOrganizationCredentials(customer)
 	if err != nil {
@@ -118,25 +135,52 @@ func (s Service) SearchOrganizations(name, city string) ([]domain.OrganizationCo
 	})
 }
 
-func (s Service) search(request SearchVCRequest) ([]domain.OrganizationConceptCredential, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-
-	requestData, _ := json.Marshal(request)
-	response, err := s.client().SearchVCsWithBody(ctx, "application/json", bytes.NewReader(requestData))
-
+// AllOrganizations returns the NutsOrganizationCredentials of all organizations which haven't been revoked, of trusted and untrusted issuers.
+func (s Service) AllOrganizations() ([]domain.OrganizationConceptCredential, error) {
+	allowUntrusted := true
+	searchResults, err := s.searchVCs(SearchVCRequest{
+		Query: SearchVCQuery{
+			Type:    []ssi.URI{ssi.MustParseURI(credential.NutsOrganizationCredentialType), ssi.MustParseURI(vc.VerifiableCredentialType)},
+			Context: []ssi.URI{ssi.MustParseURI(vc.VCContextV1), ssi.MustParseURI(credential.NutsV1Context)},
+			CredentialSubject: domain.NutsOrganizationCredentialSubject{
+				Organization: domain.Organization{
+					Name: "*",
+					City: "*",
+				},
+			},
+		},
+		SearchOptions: &vcrApi.SearchOptions{AllowUntrustedIssuer: &allowUntrusted},
+	})
 	if err != nil {
-		return nil, domain.UnwrapAPIError(err)
+		return nil, err
 	}
-	if response.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("expected status 200: %s", response.Status)
+	results := []domain.OrganizationConceptCredential{}
+	for _, curr := range searchResults {
+		var subjects []domain.NutsOrganizationCredentialSubject
+		if curr.Revocation != nil || curr.VerifiableCredential.ID == nil {
+			continue
+		}
+		if err := curr.VerifiableCredential.UnmarshalCredentialSubject(&subjects); err != nil || len(subjects) == 0 {
+			logrus.Warnf("Ignoring invalid NutsOrganizationCredential (id=%s)", curr.VerifiableCredential.ID)
+			continue
+		}
+		results = append(results, domain.OrganizationConceptCredential{
+			ID:           curr.VerifiableCredential.ID.String(),
+			Issuer:       curr.VerifiableCredential.Issuer.String(),
+			Organization: subjects[0].Organization,
+			Subject:      subjects[0].ID,
+		})
 	}
-	searchResponse, err := vcrApi.ParseSearchVCsResponse(response)
+	return results, nil
+}
+
+func (s Service) search(request SearchVCRequest) ([]domain.OrganizationConceptCredential, error) {
+	searchResults, err := s.searchVCs(request)
 	if err != nil {
 		return nil, err
 	}
 	results := []domain.OrganizationConceptCredential{}
-	for _, curr := range searchResponse.JSON200.VerifiableCredentials {
+	for _, curr := range searchResults {
 		var subjects []domain.NutsOrganizationCredentialSubject
 		err = curr.VerifiableCredential.UnmarshalCredentialSubject(&subjects)
 		if err != nil {
@@ -152,75 +196,104 @@ func (s Service) search(request SearchVCRequest) ([]domain.OrganizationConceptCr
 	return results, nil
 }
 
+// searchVCs searches the Nuts node's VCR, returning the credentials including their revocation status.
+func (s Service) searchVCs(request SearchVCRequest) ([]vcrApi.SearchVCResult, error) {
+	ctx, cancel := s.requestContext()
+	defer cancel()
+
+	requestData, _ := json.Marshal(request)
+	response, err := s.client().SearchVCsWithBody(ctx, "application/json", bytes.NewReader(requestData))
+
+	if err != nil {
+		return nil, domain.UnwrapAPIError(err)
+	}
+	if response.StatusCode != http.StatusOK {
+		return nil, domain.NodeResponseError(response)
+	}
+	searchResponse, err := vcrApi.ParseSearchVCsResponse(response)
+	if err != nil {
+		return nil, err
+	}
+	return searchResponse.JSON200.VerifiableCredentials, nil
+}
+
+// IssuerCredentialTypes returns the credential types of which the issuers are listed: only the requested type if given,
+// otherwise the credential types of which the trusted issuers are managed, including the types of the templates.
+// The result is sorted and doesn't contain duplicates.
+func (s Service) IssuerCredentialTypes(requestedType string, templates func() ([]domain.VCTemplate, error)) ([]string, error) {
+	if len(requestedType) > 0 {
+		return []string{requestedType}, nil
+	}
+	all, err := templates()
+	if err != nil {
+		return nil, err
+	}
+	unique := map[string]bool{}
+	for _, credentialType := range s.TrustCredentialTypes {
+		unique[credentialType] = true
+	}
+	for _, template := range all {
+		unique[template.Type] = true
+	}
+	delete(unique, "")
+	result := make([]string, 0, len(unique))
+	for credentialType := range unique {
+		result = append(result, credentialType)
+	}
+	sort.Strings(result)
+	return result, nil
+}
+
 func (s Service) GetCredentialIssuers(credentials []string) (domain.CredentialIssuers, error) {
 	result := domain.CredentialIssuers{}
+	trustedDIDs := make(map[string][]ssi.URI, len(credentials))
+	untrustedDIDs := make(map[string][]ssi.URI, len(credentials))
+	var allDIDs []ssi.URI
 	for _, credential := range credentials {
-
-		trustedDIDs, err := s.fetchCredentialIssuers(credential, s.client().ListTrusted)
+		trusted, err := s.fetchCredentialIssuers(credential, s.client().ListTrusted)
 		if err != nil {
 			return result, err
 		}
-		untrustedDIDs, err := s.fetchCredentialIssuers(credential, s.client().ListUntrusted)
+		untrusted, err := s.fetchCredentialIssuers(credential, s.client().ListUntrusted)
 		if err != nil {
 			return result, err
 		}
-		issuers := make([]domain.CredentialIssuer, len(trustedDIDs)+len(untrustedDIDs))
-		for i, id := range trustedDIDs {
-			issuer, err := s.getIssuer(id)
-			if err != nil {
-				return result, err
-			}
-			issuers[i] = domain.CredentialIssuer{Trusted: true, ServiceProvider: *issuer}
+		trustedDIDs[credential] = trusted
+		untrustedDIDs[credential] = untrusted
+		allDIDs = append(append(allDIDs, trusted...), untrusted...)
+	}
+
+	// Issuers are often listed for multiple credential types, so look them up once
+	issuerInfos := s.lookupIssuers(allDIDs)
+	for _, credential := range credentials {
+		issuers := make([]domain.CredentialIssuer, 0, len(trustedDIDs[credential])+len(untrustedDIDs[credential]))
+		for _, id := range trustedDIDs[credential] {
+			issuers = append(issuers, issuerInfos[id.String()].credentialIssuer(true))
 		}
-		for i, id := range untrustedDIDs {
-			issuer, err := s.getIssuer(id)
-			if err != nil {
-				return result, err
-			}
-			issuers[len(trustedDIDs)+i] = domain.CredentialIssuer{Trusted: false, ServiceProvider: *issuer}
+		for _, id := range untrustedDIDs[credential] {
+			issuers = append(issuers, issuerInfos[id.String()].credentialIssuer(false))
 		}
 		result.Set(credential, issuers)
 	}
 	return result, nil
 }
 
-func (s Service) getIssuer(id ssi.URI) (*domain.ServiceProvider, error) {
-	sp := &domain.ServiceProvider{Id: id.String()}
-	if id.Scheme != "did" {
-		return sp, nil
-	}
-	contactInformation, err := s.DIDManClient.GetContactInformation(sp.Id)
-	if err != nil {
-		// ignore so we can still see the DID
-		logrus.Warnf("Unable to get contactinfo (did=%s)", id.String())
-		return sp, nil
-		//return nil, domain.UnwrapAPIError(err)
-	}
-	if contactInformation != nil {
-		sp.Email = contactInformation.Email
-		sp.Name = contactInformation.Name
-		sp.Phone = contactInformation.Phone
-		sp.Website = contactInformation.Website
-	}
-	return sp, nil
-}
-
 func (s Service) fetchCredentialIssuers(credential string, clientFn func(ctx context.Context, credentialType string, reqEditors ...vcrApi.RequestEditorFn) (*http.Response, error)) ([]ssi.URI, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	response, err := clientFn(ctx, credential)
+	ctx, cancel := s.requestContext()
 	defer cancel()
+	response, err := clientFn(ctx, credential)
 	if err != nil {
-		return nil, err
+		return nil, domain.UnwrapAPIError(err)
 	}
-
-	body, _ := io.ReadAll(response.Body)
 	if response.StatusCode != http.StatusOK {
-		return nil, err
+		return nil, domain.NodeResponseError(response)
 	}
+	body, _ := io.ReadAll(response.Body)
 	var issuerDIDs []ssi.URI
-	err = json.Unmarshal(body, &issuerDIDs)
-	return issuerDIDs, err
-
+	if err := json.Unmarshal(body, &issuerDIDs); err != nil {
+		return nil, fmt.Errorf("unable to unmarshal issuers (type=%s): %w", credential, err)
+	}
+	return issuerDIDs, nil
 }
 
 func (s Service) issueNutsOrgCredential(customer domain.Customer) error {
@@ -229,7 +302,7 @@ func (s Service) issueNutsOrgCredential(customer domain.Customer) error {
 		return err
 	}
 	if vendorDID == nil {
-		return err
+		return errNoServiceProvider
 	}
 
 	logrus.Infof("Issuing NutsOrganizationCredential (did=%s,name=%s,city=%s)", *customer.Did, customer.Name, *customer.City)
@@ -245,19 +318,18 @@ func (s Service) issueNutsOrgCredential(customer domain.Customer) error {
 		Type:              "NutsOrganizationCredential",
 		Issuer:            vendorDID.Id,
 		CredentialSubject: credentialSubject,
+		ExpirationDate:    s.expirationDate(credential.NutsOrganizationCredentialType),
 		Visibility:        &visiblity,
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := s.requestContext()
 	defer cancel()
 	response, err := s.client().IssueVC(ctx, requestBody)
 	if err != nil {
-		return err
+		return domain.UnwrapAPIError(err)
 	}
-
-	responseBody, _ := io.ReadAll(response.Body)
 	if response.StatusCode != http.StatusOK {
-		return errors.New(string(responseBody))
+		return domain.NodeResponseError(response)
 	}
 	return nil
 }
@@ -268,78 +340,94 @@ func (s Service) RevokeCredentials(credentials []domain.OrganizationConceptCrede
 		return err
 	}
 	if vendorDID == nil {
-		return errors.New("no vendor DID")
+		return errNoServiceProvider
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-
 	for _, credential := range credentials {
-		response, err := s.client().RevokeVC(ctx, url.PathEscape(credential.ID))
-		if err != nil {
+		if err := s.revoke(credential.ID); err != nil {
 			return err
 		}
-
-		responseBody, _ := io.ReadAll(response.Body)
-		if response.StatusCode != http.StatusOK {
-			return errors.New(string(responseBody))
-		}
 	}
 
 	return nil
 }
 
+func (s Service) revoke(credentialID string) error {
+	ctx, cancel := s.requestContext()
+	defer cancel()
+
+	response, err := s.client().RevokeVC(ctx, url.PathEscape(credentialID))
+	if err != nil {
+		return domain.UnwrapAPIError(err)
+	}
+	if response.StatusCode != http.StatusOK {
+		return domain.NodeResponseError(response)
+	}
+	return nil
+}
+
 func (s Service) ManageIssuerTrust(credentialType string, issuerID ssi.URI, trusted bool) (*domain.CredentialIssuer, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	if err := s.SetIssuerTrust(credentialType, issuerID.String(), trusted); err != nil {
+		return nil, err
+	}
+	issuer := s.lookupIssuer(issuerID).credentialIssuer(trusted)
+	return &issuer, nil
+}
+
+// TrustedIssuers returns the issuers the Nuts node trusts for the credential type.
+func (s Service) TrustedIssuers(credentialType string) ([]string, error) {
+	trusted, err := s.fetchCredentialIssuers(credentialType, s.client().ListTrusted)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]string, len(trusted))
+	for i, issuer := range trusted {
+		result[i] = issuer.String()
+	}
+	return result, nil
+}
+
+// SetIssuerTrust trusts or untrusts the issuer for the credential type on the Nuts node.
+func (s Service) SetIssuerTrust(credentialType string, issuer string, trusted bool) error {
+	ctx, cancel := s.requestContext()
 	defer cancel()
 
 	var (
 		response *http.Response
 		err      error
 	)
-
 	if trusted {
-		requestBody := vcrApi.TrustIssuerJSONRequestBody{
-			CredentialType: credentialType,
-			Issuer:         issuerID.String(),
-		}
-		response, err = s.client().TrustIssuer(ctx, requestBody)
+		response, err = s.client().TrustIssuer(ctx, vcrApi.TrustIssuerJSONRequestBody{CredentialType: credentialType, Issuer: issuer})
 	} else {
-		requestBody := vcrApi.UntrustIssuerJSONRequestBody{
-			CredentialType: credentialType,
-			Issuer:         issuerID.String(),
-		}
-		response, err = s.client().UntrustIssuer(ctx, requestBody)
-	}
-	if response.StatusCode != http.StatusNoContent {
-		return nil, fmt.Errorf("expected status 204: %s", response.Status)
+		response, err = s.client().UntrustIssuer(ctx, vcrApi.UntrustIssuerJSONRequestBody{CredentialType: credentialType, Issuer: issuer})
 	}
-
 	if err != nil {
-		return nil, domain.UnwrapAPIError(err)
+		return domain.UnwrapAPIError(err)
 	}
-
-	sp, err := s.getIssuer(issuerID)
-	if err != nil {
-		return nil, domain.UnwrapAPIError(err)
+	if response.StatusCode != http.StatusNoContent {
+		return domain.NodeResponseError(response)
 	}
-	return &domain.CredentialIssuer{
-		ServiceProvider: *sp,
-		Trusted:         trusted,
-	}, nil
+	return nil
 }
 
 func (s Service) Issue(request domain.IssueVCRequest) (*vc.VerifiableCredential, error) {
+	if request.ExpirationDate == nil {
+		request.ExpirationDate = s.expirationDate(request.Type)
+	}
+	// The issuing customer has been resolved to the issuer DID, the Nuts node doesn't know about customers
+	request.IssuerCustomerId = nil
 	data, _ := json.Marshal(request)
 	requestBody := bytes.NewReader(data)
-	response, err := s.client().IssueVCWithBody(context.Background(), "application/json", requestBody)
+	ctx, cancel := s.requestContext()
+	defer cancel()
+	response, err := s.client().IssueVCWithBody(ctx, "application/json", requestBody)
 	if err != nil {
-		return nil, err
+		return nil, domain.UnwrapAPIError(err)
 	}
-	responseBody, _ := io.ReadAll(response.Body)
 	if response.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("http status response != %d: %w", http.StatusOK, err)
+		return nil, domain.NodeResponseError(response)
 	}
+	responseBody, _ := io.ReadAll(response.Body)
 	var result vc.VerifiableCredential
 	err = json.Unmarshal(responseBody, &result)
 	if err != nil {
@@ -0,0 +1,40 @@
+package credentials
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/nuts-foundation/nuts-node/vcr/credential"
+	"github.com/nuts-foundation/nuts-registry-admin-demo/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestService_IssuerCredentialTypes(t *testing.T) {
+	templates := func() ([]domain.VCTemplate, error) {
+		return []domain.VCTemplate{{Type: "ZorgCredential"}, {Type: credential.NutsOrganizationCredentialType}, {Type: ""}}, nil
+	}
+	service := Service{TrustCredentialTypes: []string{credential.NutsOrganizationCredentialType, "AgbCredential"}}
+
+	t.Run("requested type", func(t *testing.T) {
+		result, err := service.IssuerCredentialTypes("OtherCredential", func() ([]domain.VCTemplate, error) {
+			t.Fatal("templates shouldn't be loaded when a type is requested")
+			return nil, nil
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"OtherCredential"}, result)
+	})
+	t.Run("managed and template types", func(t *testing.T) {
+		result, err := service.IssuerCredentialTypes("", templates)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"AgbCredential", credential.NutsOrganizationCredentialType, "ZorgCredential"}, result)
+	})
+	t.Run("templates can't be loaded", func(t *testing.T) {
+		_, err := service.IssuerCredentialTypes("", func() ([]domain.VCTemplate, error) {
+			return nil, errors.New("database closed")
+		})
+
+		assert.EqualError(t, err, "database closed")
+	})
+}
@@ -18,6 +18,8 @@ type SearchVCQuery struct {
 	Context []ssi.URI `json:"@context"`
 	// Type holds multiple types for a credential. A credential must always have the 'VerifiableCredential' type.
 	Type []ssi.URI `json:"type,omitempty"`
+	// Issuer refers to the party that issued the credential
+	Issuer string `json:"issuer,omitempty"`
 	// CredentialSubject holds the actual data for the credential. It must be extracted using the UnmarshalCredentialSubject method and a custom type.
 	CredentialSubject interface{} `json:"credentialSubject,omitempty"`
 }
@@ -0,0 +1,45 @@
+package customers
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/nuts-foundation/nuts-registry-admin-demo/domain/jobs"
+	"github.com/sirupsen/logrus"
+)
+
+// SyncNutsCommJobType is the type of the job which registers the NutsComm service on all customers' DID documents.
+const SyncNutsCommJobType = "nutscomm-sync"
+
+// SyncNutsCommJobPayload is the payload of a SyncNutsCommJobType job.
+type SyncNutsCommJobPayload struct {
+	ServiceProviderID string `json:"serviceProviderId"`
+}
+
+// HandleSyncNutsCommJob makes sure all customers' DID documents refer to the NutsComm service of the service provider.
+// Customers for which it fails are retried when the job is retried, since registering is a no-op for customers which already have it.
+func (s Service) HandleSyncNutsCommJob(payload json.RawMessage, progress jobs.ProgressFunc) error {
+	var jobPayload SyncNutsCommJobPayload
+	if err := json.Unmarshal(payload, &jobPayload); err != nil {
+		return err
+	}
+	allCustomers, err := s.Repository.All()
+	if err != nil {
+		return err
+	}
+
+	failures := 0
+	for i, customer := range allCustomers {
+		if customer.Did != nil {
+			if err := s.RegisterNutsCommService(customer.Id, jobPayload.ServiceProviderID); err != nil {
+				logrus.Warnf("Couldn't register NutsComm endpoint on customer DID (id=%d): %v", customer.Id, err)
+				failures++
+			}
+		}
+		progress(i+1, len(allCustomers))
+	}
+	if failures > 0 {
+		return fmt.Errorf("couldn't register NutsComm endpoint for %d of %d customers", failures, len(allCustomers))
+	}
+	return nil
+}
@@ -23,7 +23,10 @@ type Repository interface {
 
 type flatFileRepo struct {
 	filepath string
-	mutex    sync.Mutex
+	// mutex guards the records and the file, since the repository is used by the API and the background jobs concurrently.
+	mutex sync.Mutex
+	// customerLocks serializes the updates per customer, so a slow update (e.g. calling the Nuts node) doesn't block the other customers.
+	customerLocks map[int]*sync.Mutex
 	// records is a cache
 	records map[string]domain.Customer
 }
@@ -36,9 +39,10 @@ func NewFlatFileRepository(filepath string) *flatFileRepo {
 	}
 
 	return &flatFileRepo{
-		filepath: filepath,
-		mutex:    sync.Mutex{},
-		records:  make(map[string]domain.Customer, 0),
+		filepath:      filepath,
+		mutex:         sync.Mutex{},
+		customerLocks: make(map[int]*sync.Mutex),
+		records:       make(map[string]domain.Customer, 0),
 	}
 }
 
@@ -47,7 +51,7 @@ func (db *flatFileRepo) NewCustomer(customer domain.Customer) (*domain.Customer,
 	db.mutex.Lock()
 	defer db.mutex.Unlock()
 
-	if err := db.ReadAll(); err != nil {
+	if err := db.readAll(); err != nil {
 		return nil, err
 	}
 	if _, ok := db.records[strconv.Itoa(customer.Id)]; ok {
@@ -56,57 +60,89 @@ func (db *flatFileRepo) NewCustomer(customer domain.Customer) (*domain.Customer,
 
 	db.records[strconv.Itoa(customer.Id)] = customer
 
-	return &customer, db.WriteAll()
+	return &customer, db.writeAll()
 }
 
 func (db *flatFileRepo) FindByID(id int) (*domain.Customer, error) {
-	if len(db.records) == 0 {
-		if err := db.ReadAll(); err != nil {
-			return nil, err
-		}
+	_, customer, err := db.find(id)
+	if err != nil {
+		return nil, err
+	}
+	if customer == nil {
+		return nil, fmt.Errorf("could not FindCustomerByID with id: %d, reason: %w", id, ErrNotFound)
 	}
+	return customer, nil
+}
 
-	for _, r := range db.records {
-		if r.Id == id {
-			// Hazardous to return a pointer, but this is a demo.
-			return &r, nil
-		}
+// Update changes the customer using updateFn. The repository isn't locked while updateFn runs,
+// only other updates of the same customer wait for it.
+func (db *flatFileRepo) Update(id int, updateFn func(c domain.Customer) (*domain.Customer, error)) (*domain.Customer, error) {
+	customerLock := db.customerLock(id)
+	customerLock.Lock()
+	defer customerLock.Unlock()
+
+	key, current, err := db.find(id)
+	if err != nil {
+		return nil, err
+	}
+	if current == nil {
+		return nil, fmt.Errorf("could update customer with id: %d, reason: %w", id, ErrNotFound)
 	}
 
-	return nil, fmt.Errorf("could not FindCustomerByID with id: %d, reason: %w", id, ErrNotFound)
+	updatedCustomer, err := updateFn(*current)
+	if err != nil {
+		return nil, err
+	}
+
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+	db.records[key] = *updatedCustomer
+	if err := db.writeAll(); err != nil {
+		return nil, err
+	}
+	return updatedCustomer, nil
 }
 
-func (db *flatFileRepo) Update(id int, updateFn func(c domain.Customer) (*domain.Customer, error)) (*domain.Customer, error) {
+// find returns the key and a copy of the customer with the given ID, or nil when it doesn't exist.
+func (db *flatFileRepo) find(id int) (string, *domain.Customer, error) {
 	db.mutex.Lock()
 	defer db.mutex.Unlock()
 
 	if len(db.records) == 0 {
-		if err := db.ReadAll(); err != nil {
-			return nil, err
+		if err := db.readAll(); err != nil {
+			return "", nil, err
 		}
 	}
-
-	for i, r := range db.records {
+	for key, r := range db.records {
 		if r.Id == id {
-			updatedCustomer, err := updateFn(db.records[i])
-			if err != nil {
-				return nil, err
-			}
-
-			db.records[i] = *updatedCustomer
-			err = db.WriteAll()
-			if err != nil {
-				return nil, err
-			}
-			return updatedCustomer, nil
+			return key, &r, nil
 		}
 	}
+	return "", nil, nil
+}
+
+// customerLock returns the lock guarding the updates of the customer with the given ID.
+func (db *flatFileRepo) customerLock(id int) *sync.Mutex {
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
 
-	return nil, fmt.Errorf("could update customer with id: %d, reason: %w", id, ErrNotFound)
+	lock, ok := db.customerLocks[id]
+	if !ok {
+		lock = &sync.Mutex{}
+		db.customerLocks[id] = lock
+	}
+	return lock
 }
 
 // WriteAll writes all records to the file, truncating the file if it exists
 func (db *flatFileRepo) WriteAll() error {
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+	return db.writeAll()
+}
+
+// writeAll writes all records to the file. The caller must hold the mutex.
+func (db *flatFileRepo) writeAll() error {
 
 	bytes, err := json.Marshal(db.records)
 	if err != nil {
@@ -119,7 +155,15 @@ func (db *flatFileRepo) WriteAll() error {
 	return nil
 }
 
+// ReadAll reads all records from the file into the cache.
 func (db *flatFileRepo) ReadAll() error {
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+	return db.readAll()
+}
+
+// readAll reads all records from the file into the cache. The caller must hold the mutex.
+func (db *flatFileRepo) readAll() error {
 	//log.Debug("Reading full customer list from file")
 	bytes, err := os.ReadFile(db.filepath)
 	if err != nil {
@@ -140,7 +184,7 @@ func (db *flatFileRepo) All() ([]domain.Customer, error) {
 	db.mutex.Lock()
 	defer db.mutex.Unlock()
 
-	if err := db.ReadAll(); err != nil {
+	if err := db.readAll(); err != nil {
 		return nil, err
 	}
 
@@ -0,0 +1,119 @@
+package customers
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/nuts-foundation/nuts-registry-admin-demo/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFlatFileRepo_Update(t *testing.T) {
+	customers := []domain.Customer{{Id: 1, Name: "First"}, {Id: 2, Name: "Second"}}
+
+	t.Run("updated", func(t *testing.T) {
+		repository := testRepository(t, customers...)
+
+		updated, err := repository.Update(1, func(c domain.Customer) (*domain.Customer, error) {
+			c.Name = "Changed"
+			return &c, nil
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, "Changed", updated.Name)
+		stored, err := NewFlatFileRepository(repository.(*flatFileRepo).filepath).FindByID(1)
+		require.NoError(t, err)
+		assert.Equal(t, "Changed", stored.Name)
+	})
+	t.Run("not found", func(t *testing.T) {
+		repository := testRepository(t, customers...)
+
+		_, err := repository.Update(3, func(c domain.Customer) (*domain.Customer, error) {
+			return &c, nil
+		})
+
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+	t.Run("update fails", func(t *testing.T) {
+		repository := testRepository(t, customers...)
+
+		_, err := repository.Update(1, func(c domain.Customer) (*domain.Customer, error) {
+			return nil, errors.New("failed")
+		})
+
+		assert.EqualError(t, err, "failed")
+		stored, err := repository.FindByID(1)
+		require.NoError(t, err)
+		assert.Equal(t, "First", stored.Name)
+	})
+	t.Run("reads and other customers aren't blocked while updating", func(t *testing.T) {
+		repository := testRepository(t, customers...)
+		updating := make(chan struct{})
+		release := make(chan struct{})
+		done := make(chan error)
+		go func() {
+			_, err := repository.Update(1, func(c domain.Customer) (*domain.Customer, error) {
+				close(updating)
+				<-release
+				c.Name = "Changed"
+				return &c, nil
+			})
+			done <- err
+		}()
+		<-updating
+
+		customer, err := repository.FindByID(1)
+		require.NoError(t, err)
+		assert.Equal(t, "First", customer.Name)
+		_, err = repository.Update(2, func(c domain.Customer) (*domain.Customer, error) {
+			c.Name = "Other"
+			return &c, nil
+		})
+		require.NoError(t, err)
+
+		close(release)
+		require.NoError(t, <-done)
+		all, err := repository.All()
+		require.NoError(t, err)
+		assert.Equal(t, "Changed", all[0].Name)
+		assert.Equal(t, "Other", all[1].Name)
+	})
+	t.Run("updates of the same customer wait for each other", func(t *testing.T) {
+		repository := testRepository(t, customers...)
+		updating := make(chan struct{})
+		release := make(chan struct{})
+		done := make(chan error)
+		go func() {
+			_, err := repository.Update(1, func(c domain.Customer) (*domain.Customer, error) {
+				close(updating)
+				<-release
+				c.Name = c.Name + " first"
+				return &c, nil
+			})
+			done <- err
+		}()
+		<-updating
+		second := make(chan error)
+		go func() {
+			_, err := repository.Update(1, func(c domain.Customer) (*domain.Customer, error) {
+				c.Name = c.Name + " second"
+				return &c, nil
+			})
+			second <- err
+		}()
+
+		select {
+		case <-second:
+			t.Fatal("second update didn't wait for the first")
+		case <-time.After(50 * time.Millisecond):
+		}
+		close(release)
+		require.NoError(t, <-done)
+		require.NoError(t, <-second)
+		customer, err := repository.FindByID(1)
+		require.NoError(t, err)
+		assert.Equal(t, "First first second", customer.Name)
+	})
+}
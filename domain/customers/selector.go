@@ -0,0 +1,60 @@
+package customers
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/nuts-foundation/nuts-registry-admin-demo/domain"
+)
+
+var ErrInvalidSelector = errors.New("invalid customer selector")
+
+// SelectCustomers returns the customers connected to a DID which match the selector.
+// It returns ErrInvalidSelector when the selector is incomplete, refers to unknown customers or doesn't match any customer.
+func (s Service) SelectCustomers(selector domain.CustomerSelector) ([]domain.Customer, error) {
+	selectAll := selector.All != nil && *selector.All
+	selectedIDs := make(map[int]bool)
+	if selector.CustomerIds != nil {
+		for _, id := range *selector.CustomerIds {
+			selectedIDs[id] = true
+		}
+	}
+	if !selectAll && len(selectedIDs) == 0 {
+		return nil, fmt.Errorf("%w: either all customers or a list of customer IDs must be selected", ErrInvalidSelector)
+	}
+
+	allCustomers, err := s.Repository.All()
+	if err != nil {
+		return nil, err
+	}
+	var result []domain.Customer
+	for _, customer := range allCustomers {
+		if !selectAll && !selectedIDs[customer.Id] {
+			continue
+		}
+		delete(selectedIDs, customer.Id)
+		if selector.City != nil && len(*selector.City) > 0 &&
+			(customer.City == nil || !strings.EqualFold(*customer.City, *selector.City)) {
+			continue
+		}
+		if customer.Did == nil {
+			// Not connected to a DID, so there's nothing to administer on the network
+			continue
+		}
+		result = append(result, customer)
+	}
+	if !selectAll && len(selectedIDs) > 0 {
+		unknownIDs := make([]int, 0, len(selectedIDs))
+		for id := range selectedIDs {
+			unknownIDs = append(unknownIDs, id)
+		}
+		sort.Ints(unknownIDs)
+		return nil, fmt.Errorf("%w: unknown customers: %v", ErrInvalidSelector, unknownIDs)
+	}
+	if len(result) == 0 {
+		return nil, fmt.Errorf("%w: no customers selected", ErrInvalidSelector)
+	}
+	return result, nil
+}
@@ -0,0 +1,55 @@
+package customers
+
+import (
+	"testing"
+
+	"github.com/nuts-foundation/nuts-registry-admin-demo/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestService_SelectCustomers(t *testing.T) {
+	all := true
+	none := false
+	customers := []domain.Customer{
+		{Id: 1, Name: "Amsterdam", City: stringPtr("Amsterdam"), Did: stringPtr("did:nuts:1")},
+		{Id: 2, Name: "Utrecht", City: stringPtr("Utrecht"), Did: stringPtr("did:nuts:2")},
+		{Id: 3, Name: "Not connected", City: stringPtr("Amsterdam")},
+		{Id: 4, Name: "Without city", Did: stringPtr("did:nuts:4")},
+	}
+	ids := func(ids ...int) *[]int {
+		return &ids
+	}
+	tests := []struct {
+		name        string
+		selector    domain.CustomerSelector
+		expectedIDs []int
+		expectedErr string
+	}{
+		{name: "all", selector: domain.CustomerSelector{All: &all}, expectedIDs: []int{1, 2, 4}},
+		{name: "by ID", selector: domain.CustomerSelector{CustomerIds: ids(2, 4)}, expectedIDs: []int{2, 4}},
+		{name: "all in city", selector: domain.CustomerSelector{All: &all, City: stringPtr("amsterdam")}, expectedIDs: []int{1}},
+		{name: "by ID in city", selector: domain.CustomerSelector{CustomerIds: ids(1, 2), City: stringPtr("Utrecht")}, expectedIDs: []int{2}},
+		{name: "empty city is ignored", selector: domain.CustomerSelector{All: &all, City: stringPtr("")}, expectedIDs: []int{1, 2, 4}},
+		{name: "nothing selected", selector: domain.CustomerSelector{}, expectedErr: "invalid customer selector: either all customers or a list of customer IDs must be selected"},
+		{name: "all is false", selector: domain.CustomerSelector{All: &none}, expectedErr: "invalid customer selector: either all customers or a list of customer IDs must be selected"},
+		{name: "empty list of IDs", selector: domain.CustomerSelector{CustomerIds: ids()}, expectedErr: "invalid customer selector: either all customers or a list of customer IDs must be selected"},
+		{name: "unknown IDs", selector: domain.CustomerSelector{CustomerIds: ids(1, 9, 5)}, expectedErr: "invalid customer selector: unknown customers: [5 9]"},
+		{name: "only customers without DID", selector: domain.CustomerSelector{CustomerIds: ids(3)}, expectedErr: "invalid customer selector: no customers selected"},
+		{name: "no customers in city", selector: domain.CustomerSelector{All: &all, City: stringPtr("Rotterdam")}, expectedErr: "invalid customer selector: no customers selected"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			service := Service{Repository: testRepository(t, customers...)}
+
+			result, err := service.SelectCustomers(test.selector)
+
+			if test.expectedErr != "" {
+				assert.ErrorIs(t, err, ErrInvalidSelector)
+				assert.EqualError(t, err, test.expectedErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.expectedIDs, customerIDs(result))
+		})
+	}
+}
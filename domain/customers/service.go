@@ -1,18 +1,53 @@
 package customers
 
 import (
+	"errors"
 	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
 
 	"github.com/nuts-foundation/go-did/did"
-	didmanAPI "github.com/nuts-foundation/nuts-node/didman/api/v1"
 	nutsApi "github.com/nuts-foundation/nuts-node/vdr/api/v1"
 	"github.com/nuts-foundation/nuts-registry-admin-demo/domain"
+	"github.com/sirupsen/logrus"
 )
 
+var ErrInvalidEndpoint = errors.New("invalid endpoint")
+
+var ErrNoDID = errors.New("customer isn't connected to a DID")
+
+var ErrNotController = errors.New("service provider isn't a controller of the customer's DID")
+
+// documentLookupWorkers is the maximum number of customer DID documents which are resolved concurrently.
+const documentLookupWorkers = 8
+
+// ServiceReferencesError is returned when the service references of some customers couldn't be determined.
+type ServiceReferencesError struct {
+	// Customers contains the customers of which the DID document could be resolved and which refer to the service.
+	Customers []domain.Customer
+	// Failures contains the reason the DID document couldn't be resolved, per customer ID.
+	Failures map[int]error
+}
+
+func (e ServiceReferencesError) Error() string {
+	ids := make([]int, 0, len(e.Failures))
+	for id := range e.Failures {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	reasons := make([]string, len(ids))
+	for i, id := range ids {
+		reasons[i] = fmt.Sprintf("customer %d: %v", id, e.Failures[id])
+	}
+	return "unable to determine service references of customers: " + strings.Join(reasons, ", ")
+}
+
 type Service struct {
-	VDRClient    nutsApi.HTTPClient
+	VDRClient    domain.VDRClient
 	Repository   Repository
-	DIDManClient didmanAPI.HTTPClient
+	DIDManClient domain.DIDManClient
 }
 
 func (s Service) ConnectCustomer(reqCustomer domain.Customer, serviceProviderID did.DID) (*domain.Customer, error) {
@@ -43,6 +78,28 @@ func (s Service) ConnectCustomer(reqCustomer domain.Customer, serviceProviderID
 	return s.Repository.NewCustomer(customer)
 }
 
+// IssuerDID returns the DID of the customer, for issuing credentials on behalf of the customer.
+// The service provider must be a controller of the customer's DID document, otherwise the Nuts node can't sign the credentials.
+func (s Service) IssuerDID(customerID int, spDID string) (string, error) {
+	customer, err := s.Repository.FindByID(customerID)
+	if err != nil {
+		return "", err
+	}
+	if customer.Did == nil {
+		return "", ErrNoDID
+	}
+	document, _, err := s.VDRClient.Get(*customer.Did)
+	if err != nil {
+		return "", fmt.Errorf("unable to resolve customer DID document: %w", domain.UnwrapAPIError(err))
+	}
+	for _, controller := range document.Controller {
+		if controller.String() == spDID {
+			return *customer.Did, nil
+		}
+	}
+	return "", ErrNotController
+}
+
 const refTemplate = "%s/serviceEndpoint?type=%s"
 
 // RegisterNutsCommService registers the NutsComm service on the customer's DID document, referring to the vendor's NutsComm service.
@@ -75,19 +132,25 @@ func (s Service) RegisterNutsCommService(customerID int, spDID string) error {
 }
 
 // EnableService enables a service for a customer adding a reference by type to the compoundService
-// to the customers DID document.
+// to the customers DID document. A dedicated compoundService of the same type is replaced by the reference.
 func (s Service) EnableService(customerID int, spDID string, serviceType string) error {
 	customer, err := s.Repository.FindByID(customerID)
 	if err != nil {
 		return err
 	}
-	parsedDID, err := did.ParseDIDURL(spDID)
+	ref, err := ServiceReference(spDID, serviceType)
 	if err != nil {
 		return err
 	}
-	parsedDID.Fragment = ""
-
-	ref := fmt.Sprintf(refTemplate, parsedDID.String(), serviceType)
+	services, err := s.GetServices(customerID)
+	if err != nil {
+		return err
+	}
+	if hasServiceOfType(services, serviceType, true) {
+		return s.ReplaceService(customerID, serviceType, func() error {
+			return s.EnableService(customerID, spDID, serviceType)
+		})
+	}
 
 	_, err = s.DIDManClient.AddEndpoint(*customer.Did, serviceType, ref)
 	if err != nil {
@@ -96,16 +159,238 @@ func (s Service) EnableService(customerID int, spDID string, serviceType string)
 	return nil
 }
 
+// ServiceReference returns the reference to the compoundService of a certain type on the service provider's DID document.
+func ServiceReference(spDID string, serviceType string) (string, error) {
+	parsedDID, err := did.ParseDIDURL(spDID)
+	if err != nil {
+		return "", err
+	}
+	parsedDID.Fragment = ""
+	return fmt.Sprintf(refTemplate, parsedDID.String(), serviceType), nil
+}
+
+// EnableDedicatedService enables a service for a customer by adding a compoundService to the customer's own DID document.
+// Every endpoint URL is registered as endpoint on the customer's DID document and referred to by the compoundService,
+// other keys refer to the same endpoints as the service provider's (shared) compoundService does.
+// A reference to the service provider's compoundService of the same type is replaced by the customer's compoundService.
+func (s Service) EnableDedicatedService(customerID int, serviceType string, sharedReferences map[string]interface{}, endpointURLs map[string]interface{}) error {
+	customer, err := s.Repository.FindByID(customerID)
+	if err != nil {
+		return err
+	}
+
+	// Validate before changing the DID document
+	if err := ValidateEndpointURLs(endpointURLs); err != nil {
+		return err
+	}
+
+	document, _, err := s.VDRClient.Get(*customer.Did)
+	if err != nil {
+		return fmt.Errorf("unable to fetch customer DID Document: %w", domain.UnwrapAPIError(err))
+	}
+	if hasServiceOfType(document.Service, serviceType, false) {
+		return s.ReplaceService(customerID, serviceType, func() error {
+			return s.EnableDedicatedService(customerID, serviceType, sharedReferences, endpointURLs)
+		})
+	}
+
+	references := make(map[string]string, len(sharedReferences)+len(endpointURLs))
+	for key, value := range sharedReferences {
+		if reference, ok := value.(string); ok {
+			references[key] = reference
+		}
+	}
+	// Endpoints added here are removed again when a later step fails, so they aren't left behind.
+	// An endpoint which is already registered with the same URL (e.g. by an earlier attempt) is used as is.
+	var added []string
+	for _, key := range sortedKeys(endpointURLs) {
+		endpointType := dedicatedEndpointType(serviceType, key)
+		endpointURL := endpointURLs[key].(string)
+		references[key] = fmt.Sprintf(refTemplate, *customer.Did, endpointType)
+		if hasEndpoint(*document, endpointType, endpointURL) {
+			continue
+		}
+		if _, err := s.DIDManClient.AddEndpoint(*customer.Did, endpointType, endpointURL); err != nil {
+			s.removeEndpoints(*customer.Did, added)
+			return fmt.Errorf("unable to add endpoint to DID Document (type=%s): %w", endpointType, err)
+		}
+		added = append(added, endpointType)
+	}
+
+	if _, err = s.DIDManClient.AddCompoundService(*customer.Did, serviceType, references); err != nil {
+		s.removeEndpoints(*customer.Did, added)
+		return fmt.Errorf("unable to add new compound service to DID Document: %w", err)
+	}
+	return nil
+}
+
+// removeEndpoints removes the endpoints of the given types from the customer's DID document. Failures are logged.
+func (s Service) removeEndpoints(customerDID string, endpointTypes []string) {
+	for _, endpointType := range endpointTypes {
+		if err := s.DIDManClient.DeleteEndpointsByType(customerDID, endpointType); err != nil {
+			logrus.Errorf("Unable to remove endpoint from DID Document (did=%s, type=%s): %v", customerDID, endpointType, err)
+		}
+	}
+}
+
+// hasEndpoint returns whether the DID document contains an endpoint of the given type with the given URL.
+func hasEndpoint(document did.Document, endpointType string, endpointURL string) bool {
+	for _, service := range document.Service {
+		var currentURL string
+		if service.Type == endpointType && service.UnmarshalServiceEndpoint(&currentURL) == nil && currentURL == endpointURL {
+			return true
+		}
+	}
+	return false
+}
+
+// hasServiceOfType returns whether the services contain a compoundService (compound is true) or an endpoint of the given type.
+func hasServiceOfType(services []did.Service, serviceType string, compound bool) bool {
+	for _, svc := range services {
+		_, isCompound := svc.ServiceEndpoint.(map[string]interface{})
+		if svc.Type == serviceType && isCompound == compound {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedKeys(values map[string]interface{}) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// ValidateEndpointURLs checks whether the endpoint URLs for a dedicated compoundService are absolute URLs.
+func ValidateEndpointURLs(endpointURLs map[string]interface{}) error {
+	for key, value := range endpointURLs {
+		endpointURL, _ := value.(string)
+		if parsedURL, err := url.Parse(endpointURL); err != nil || !parsedURL.IsAbs() || parsedURL.Host == "" {
+			return fmt.Errorf("%w: %s must be an absolute URL", ErrInvalidEndpoint, key)
+		}
+	}
+	return nil
+}
+
+// dedicatedEndpointType returns the type of the endpoint on the customer's DID document for a key of a dedicated compoundService.
+func dedicatedEndpointType(serviceType string, key string) string {
+	return fmt.Sprintf("%s-%s", serviceType, key)
+}
+
 // DisableService disables a service for a customer by removing all references to a
 // compoundService of a certain type from the customers DID document.
+// When the customer has a dedicated compoundService of that type, it is removed together with the customer's own endpoints it refers to.
 func (s Service) DisableService(customerID int, serviceType string) error {
 	customer, err := s.Repository.FindByID(customerID)
 	if err != nil {
 		return err
 	}
+	services, err := s.GetServices(customerID)
+	if err != nil {
+		return err
+	}
+	for _, svc := range services {
+		references, isCompound := svc.ServiceEndpoint.(map[string]interface{})
+		if svc.Type != serviceType || !isCompound {
+			continue
+		}
+		// The compoundService must be removed first, since the endpoints can't be removed while being referred to
+		if err := s.DIDManClient.DeleteService(svc.ID); err != nil {
+			return fmt.Errorf("unable to remove compound service from DID Document: %w", err)
+		}
+		for key, reference := range references {
+			endpointType := dedicatedEndpointType(serviceType, key)
+			if reference != fmt.Sprintf(refTemplate, *customer.Did, endpointType) {
+				// Not one of the customer's own endpoints
+				continue
+			}
+			if err := s.DIDManClient.DeleteEndpointsByType(*customer.Did, endpointType); err != nil {
+				return fmt.Errorf("unable to remove endpoint from DID Document (type=%s): %w", endpointType, err)
+			}
+		}
+		return nil
+	}
 	return s.DIDManClient.DeleteEndpointsByType(*customer.Did, serviceType)
 }
 
+// ReplaceService replaces the service of a certain type of a customer: the service is disabled, after which enable is called
+// to enable it again. When enabling fails, the original service is restored, so it isn't lost when the update is rejected.
+func (s Service) ReplaceService(customerID int, serviceType string, enable func() error) error {
+	customer, err := s.Repository.FindByID(customerID)
+	if err != nil {
+		return err
+	}
+	services, err := s.GetServices(customerID)
+	if err != nil {
+		return err
+	}
+	endpoints, compoundServices := serviceOfType(*customer.Did, serviceType, services)
+	if err := s.DisableService(customerID, serviceType); err != nil {
+		return err
+	}
+	if err := enable(); err != nil {
+		s.restoreService(*customer.Did, endpoints, compoundServices)
+		return err
+	}
+	return nil
+}
+
+// serviceOfType returns the services which make up the service of a certain type on the customer's DID document:
+// the endpoint referring to the service provider's compoundService, or the customer's own compoundService
+// together with the customer's endpoints it refers to.
+func serviceOfType(customerDID string, serviceType string, services []did.Service) (endpoints []did.Service, compoundServices []did.Service) {
+	ownEndpoints := make(map[string]bool)
+	for _, svc := range services {
+		references, isCompound := svc.ServiceEndpoint.(map[string]interface{})
+		if svc.Type != serviceType || !isCompound {
+			continue
+		}
+		compoundServices = append(compoundServices, svc)
+		for key, reference := range references {
+			endpointType := dedicatedEndpointType(serviceType, key)
+			if reference == fmt.Sprintf(refTemplate, customerDID, endpointType) {
+				ownEndpoints[endpointType] = true
+			}
+		}
+	}
+	for _, svc := range services {
+		var endpointURL string
+		if svc.UnmarshalServiceEndpoint(&endpointURL) != nil {
+			continue
+		}
+		if svc.Type == serviceType || ownEndpoints[svc.Type] {
+			endpoints = append(endpoints, svc)
+		}
+	}
+	return endpoints, compoundServices
+}
+
+// restoreService adds the given services to the customer's DID document again. The endpoints are added first,
+// since the compoundServices refer to them. Failures are logged.
+func (s Service) restoreService(customerDID string, endpoints []did.Service, compoundServices []did.Service) {
+	for _, endpoint := range endpoints {
+		var endpointURL string
+		_ = endpoint.UnmarshalServiceEndpoint(&endpointURL)
+		if _, err := s.DIDManClient.AddEndpoint(customerDID, endpoint.Type, endpointURL); err != nil {
+			logrus.Errorf("Unable to restore endpoint after failed update (did=%s, type=%s): %v", customerDID, endpoint.Type, err)
+		}
+	}
+	for _, compoundService := range compoundServices {
+		references := make(map[string]string)
+		for key, value := range compoundService.ServiceEndpoint.(map[string]interface{}) {
+			if reference, ok := value.(string); ok {
+				references[key] = reference
+			}
+		}
+		if _, err := s.DIDManClient.AddCompoundService(customerDID, compoundService.Type, references); err != nil {
+			logrus.Errorf("Unable to restore compound service after failed update (did=%s, type=%s): %v", customerDID, compoundService.Type, err)
+		}
+	}
+}
+
 // GetServices returns all the enabled services for a customer.
 func (s Service) GetServices(customerID int) ([]did.Service, error) {
 	customer, err := s.Repository.FindByID(customerID)
@@ -120,3 +405,79 @@ func (s Service) GetServices(customerID int) ([]did.Service, error) {
 
 	return customerDIDDoc.Service, nil
 }
+
+// FindServiceReferences returns the customers which have a reference to the compoundService of a certain type
+// of the service provider on their DID document. The DID documents are resolved concurrently.
+// When the DID document of one or more customers can't be resolved, the other customers are still checked,
+// and a ServiceReferencesError is returned containing the customers found and the failures.
+func (s Service) FindServiceReferences(spDID string, serviceType string) ([]domain.Customer, error) {
+	ref, err := ServiceReference(spDID, serviceType)
+	if err != nil {
+		return nil, err
+	}
+	allCustomers, err := s.Repository.All()
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		wg        sync.WaitGroup
+		mutex     sync.Mutex
+		semaphore = make(chan struct{}, documentLookupWorkers)
+		found     = make(map[int]bool)
+		failures  = make(map[int]error)
+	)
+	for _, customer := range allCustomers {
+		if customer.Did == nil {
+			continue
+		}
+		wg.Add(1)
+		go func(customerID int) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+			refers, err := s.refersTo(customerID, serviceType, ref)
+			mutex.Lock()
+			defer mutex.Unlock()
+			if err != nil {
+				failures[customerID] = err
+			} else if refers {
+				found[customerID] = true
+			}
+		}(customer.Id)
+	}
+	wg.Wait()
+
+	var result []domain.Customer
+	for _, customer := range allCustomers {
+		if found[customer.Id] {
+			result = append(result, customer)
+		}
+	}
+	if len(failures) > 0 {
+		return result, ServiceReferencesError{Customers: result, Failures: failures}
+	}
+	return result, nil
+}
+
+// refersTo returns whether the DID document of the customer contains a service of the given type referring to ref.
+func (s Service) refersTo(customerID int, serviceType string, ref string) (bool, error) {
+	services, err := s.GetServices(customerID)
+	if err != nil {
+		return false, err
+	}
+	for _, svc := range services {
+		if svc.Type != serviceType {
+			continue
+		}
+		var endpoint string
+		if err := svc.UnmarshalServiceEndpoint(&endpoint); err != nil {
+			// Not a reference, e.g. a dedicated compound service of the customer
+			continue
+		}
+		if endpoint == ref {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,428 @@
+package customers
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	ssi "github.com/nuts-foundation/go-did"
+	"github.com/nuts-foundation/go-did/did"
+	didmanAPI "github.com/nuts-foundation/nuts-node/didman/api/v1"
+	vdrAPI "github.com/nuts-foundation/nuts-node/vdr/api/v1"
+	"github.com/nuts-foundation/nuts-registry-admin-demo/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+const testSPDID = "did:nuts:sp"
+
+func testRepository(t *testing.T, customers ...domain.Customer) Repository {
+	repository := NewFlatFileRepository(filepath.Join(t.TempDir(), "customers.json"))
+	for _, customer := range customers {
+		_, err := repository.NewCustomer(customer)
+		require.NoError(t, err)
+	}
+	return repository
+}
+
+func testDocument(id string, services ...did.Service) *did.Document {
+	return &did.Document{ID: did.MustParseDID(id), Service: services}
+}
+
+func testService(serviceType string, endpoint interface{}) did.Service {
+	return did.Service{ID: ssi.MustParseURI("#" + serviceType), Type: serviceType, ServiceEndpoint: endpoint}
+}
+
+var errNodeUnavailable = errors.New("node unavailable")
+
+func stringPtr(value string) *string {
+	return &value
+}
+
+func TestService_FindServiceReferences(t *testing.T) {
+	reference := testSPDID + "/serviceEndpoint?type=eOverdracht"
+	customers := []domain.Customer{
+		{Id: 1, Name: "Referring", Did: stringPtr("did:nuts:1")},
+		{Id: 2, Name: "Other service", Did: stringPtr("did:nuts:2")},
+		{Id: 3, Name: "Dedicated", Did: stringPtr("did:nuts:3")},
+		{Id: 4, Name: "Not connected"},
+	}
+	documents := map[string]*did.Document{
+		"did:nuts:1": testDocument("did:nuts:1", testService("eOverdracht", reference)),
+		"did:nuts:2": testDocument("did:nuts:2", testService("other", testSPDID+"/serviceEndpoint?type=other")),
+		"did:nuts:3": testDocument("did:nuts:3", testService("eOverdracht", map[string]interface{}{"fhir": "did:nuts:3/serviceEndpoint?type=eOverdracht-fhir"})),
+	}
+
+	tests := []struct {
+		name         string
+		failingDID   string
+		expectedIDs  []int
+		expectedFail []int
+	}{
+		{name: "all documents resolved", expectedIDs: []int{1}},
+		{name: "other customers are checked when a document can't be resolved", failingDID: "did:nuts:2", expectedIDs: []int{1}, expectedFail: []int{2}},
+		{name: "referring customer can't be resolved", failingDID: "did:nuts:1", expectedFail: []int{1}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			vdrClient := domain.NewMockVDRClient(ctrl)
+			vdrClient.EXPECT().Get(gomock.Any()).DoAndReturn(func(id string) (*did.Document, *vdrAPI.DocumentMetadata, error) {
+				if id == test.failingDID {
+					return nil, nil, errors.New("node unavailable")
+				}
+				return documents[id], nil, nil
+			}).Times(3)
+			service := Service{Repository: testRepository(t, customers...), VDRClient: vdrClient}
+
+			result, err := service.FindServiceReferences(testSPDID, "eOverdracht")
+
+			assert.Equal(t, test.expectedIDs, customerIDs(result))
+			if len(test.expectedFail) == 0 {
+				assert.NoError(t, err)
+				return
+			}
+			var referencesErr ServiceReferencesError
+			require.ErrorAs(t, err, &referencesErr)
+			var failed []int
+			for id := range referencesErr.Failures {
+				failed = append(failed, id)
+			}
+			assert.Equal(t, test.expectedFail, failed)
+			assert.Equal(t, test.expectedIDs, customerIDs(referencesErr.Customers))
+		})
+	}
+}
+
+func TestService_IssuerDID(t *testing.T) {
+	customers := []domain.Customer{
+		{Id: 1, Name: "Controlled", Did: stringPtr("did:nuts:1")},
+		{Id: 2, Name: "Controlled by someone else", Did: stringPtr("did:nuts:2")},
+		{Id: 3, Name: "Not resolvable", Did: stringPtr("did:nuts:3")},
+		{Id: 4, Name: "Not connected"},
+	}
+	documents := map[string]*did.Document{
+		"did:nuts:1": {ID: did.MustParseDID("did:nuts:1"), Controller: []did.DID{did.MustParseDID("did:nuts:other"), did.MustParseDID(testSPDID)}},
+		"did:nuts:2": {ID: did.MustParseDID("did:nuts:2"), Controller: []did.DID{did.MustParseDID("did:nuts:other")}},
+	}
+
+	tests := []struct {
+		name        string
+		customerID  int
+		expectedDID string
+		expectedErr error
+	}{
+		{name: "controlled by the service provider", customerID: 1, expectedDID: "did:nuts:1"},
+		{name: "not controlled by the service provider", customerID: 2, expectedErr: ErrNotController},
+		{name: "document can't be resolved", customerID: 3, expectedErr: errNodeUnavailable},
+		{name: "customer without DID", customerID: 4, expectedErr: ErrNoDID},
+		{name: "unknown customer", customerID: 5, expectedErr: ErrNotFound},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			vdrClient := domain.NewMockVDRClient(ctrl)
+			vdrClient.EXPECT().Get(gomock.Any()).DoAndReturn(func(id string) (*did.Document, *vdrAPI.DocumentMetadata, error) {
+				document, ok := documents[id]
+				if !ok {
+					return nil, nil, errNodeUnavailable
+				}
+				return document, nil, nil
+			}).AnyTimes()
+			service := Service{Repository: testRepository(t, customers...), VDRClient: vdrClient}
+
+			result, err := service.IssuerDID(test.customerID, testSPDID)
+
+			assert.Equal(t, test.expectedDID, result)
+			if test.expectedErr == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, test.expectedErr)
+			}
+		})
+	}
+}
+
+func customerIDs(customers []domain.Customer) []int {
+	var result []int
+	for _, customer := range customers {
+		result = append(result, customer.Id)
+	}
+	return result
+}
+
+// testNode simulates the DID document of a customer on the Nuts node, of which the services can be changed.
+type testNode struct {
+	document *did.Document
+	// failTypes contains the types of the endpoints and compound services which can't be added.
+	failTypes map[string]bool
+}
+
+func (n *testNode) service(t *testing.T, customer domain.Customer) Service {
+	ctrl := gomock.NewController(t)
+	vdrClient := domain.NewMockVDRClient(ctrl)
+	vdrClient.EXPECT().Get(*customer.Did).DoAndReturn(func(id string) (*did.Document, *vdrAPI.DocumentMetadata, error) {
+		document := *n.document
+		document.Service = append([]did.Service{}, n.document.Service...)
+		return &document, nil, nil
+	}).AnyTimes()
+	didmanClient := domain.NewMockDIDManClient(ctrl)
+	didmanClient.EXPECT().AddEndpoint(*customer.Did, gomock.Any(), gomock.Any()).DoAndReturn(func(id, endpointType, endpointURL string) (*didmanAPI.Endpoint, error) {
+		if n.failTypes[endpointType] {
+			return nil, errors.New("failed")
+		}
+		n.document.Service = append(n.document.Service, testService(endpointType, endpointURL))
+		return &didmanAPI.Endpoint{}, nil
+	}).AnyTimes()
+	didmanClient.EXPECT().AddCompoundService(*customer.Did, gomock.Any(), gomock.Any()).DoAndReturn(func(id, serviceType string, references map[string]string) (*didmanAPI.CompoundService, error) {
+		if n.failTypes[serviceType] {
+			return nil, errors.New("failed")
+		}
+		endpoint := make(map[string]interface{}, len(references))
+		for key, reference := range references {
+			endpoint[key] = reference
+		}
+		n.document.Service = append(n.document.Service, testService(serviceType, endpoint))
+		return &didmanAPI.CompoundService{}, nil
+	}).AnyTimes()
+	didmanClient.EXPECT().DeleteEndpointsByType(*customer.Did, gomock.Any()).DoAndReturn(func(id, endpointType string) error {
+		n.remove(endpointType)
+		return nil
+	}).AnyTimes()
+	didmanClient.EXPECT().DeleteService(gomock.Any()).DoAndReturn(func(id ssi.URI) error {
+		n.remove(id.Fragment)
+		return nil
+	}).AnyTimes()
+	return Service{Repository: testRepository(t, customer), VDRClient: vdrClient, DIDManClient: didmanClient}
+}
+
+func (n *testNode) remove(serviceType string) {
+	var services []did.Service
+	for _, service := range n.document.Service {
+		if service.Type != serviceType {
+			services = append(services, service)
+		}
+	}
+	n.document.Service = services
+}
+
+func TestValidateEndpointURLs(t *testing.T) {
+	tests := []struct {
+		name         string
+		endpointURLs map[string]interface{}
+		expectErr    bool
+	}{
+		{name: "absolute URLs", endpointURLs: map[string]interface{}{"fhir": "https://example.com/fhir", "oauth": "http://example.com:8080"}},
+		{name: "no URLs", endpointURLs: map[string]interface{}{}},
+		{name: "relative URL", endpointURLs: map[string]interface{}{"fhir": "/fhir"}, expectErr: true},
+		{name: "without host", endpointURLs: map[string]interface{}{"fhir": "https:///fhir"}, expectErr: true},
+		{name: "not a URL", endpointURLs: map[string]interface{}{"fhir": "::"}, expectErr: true},
+		{name: "not a string", endpointURLs: map[string]interface{}{"fhir": 42}, expectErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := ValidateEndpointURLs(test.endpointURLs)
+
+			if test.expectErr {
+				assert.ErrorIs(t, err, ErrInvalidEndpoint)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestService_EnableDedicatedService(t *testing.T) {
+	customer := domain.Customer{Id: 1, Name: "Customer", Did: stringPtr("did:nuts:1")}
+	sharedReferences := map[string]interface{}{"oauth": testSPDID + "/serviceEndpoint?type=oauth"}
+	endpointURLs := map[string]interface{}{"fhir": "https://example.com/fhir", "notification": "https://example.com/notification"}
+	tests := []struct {
+		name     string
+		services []did.Service
+		// failTypes contains the types which can't be added to the DID document.
+		failTypes     map[string]bool
+		expectedErr   string
+		expectedTypes []string
+	}{
+		{
+			name:          "endpoints and compound service added",
+			expectedTypes: []string{"eOverdracht-fhir", "eOverdracht-notification", "eOverdracht"},
+		},
+		{
+			name:          "identical endpoint is used",
+			services:      []did.Service{testService("eOverdracht-fhir", "https://example.com/fhir")},
+			expectedTypes: []string{"eOverdracht-fhir", "eOverdracht-notification", "eOverdracht"},
+		},
+		{
+			name:          "adding endpoint fails",
+			failTypes:     map[string]bool{"eOverdracht-notification": true},
+			expectedErr:   "unable to add endpoint to DID Document (type=eOverdracht-notification): failed",
+			expectedTypes: nil,
+		},
+		{
+			name:          "adding compound service fails",
+			failTypes:     map[string]bool{"eOverdracht": true},
+			expectedErr:   "unable to add new compound service to DID Document: failed",
+			expectedTypes: nil,
+		},
+		{
+			name:          "identical endpoint is kept when adding compound service fails",
+			services:      []did.Service{testService("eOverdracht-fhir", "https://example.com/fhir")},
+			failTypes:     map[string]bool{"eOverdracht": true},
+			expectedErr:   "unable to add new compound service to DID Document: failed",
+			expectedTypes: []string{"eOverdracht-fhir"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			node := &testNode{document: testDocument(*customer.Did, test.services...), failTypes: test.failTypes}
+
+			err := node.service(t, customer).EnableDedicatedService(customer.Id, "eOverdracht", sharedReferences, endpointURLs)
+
+			if test.expectedErr != "" {
+				assert.EqualError(t, err, test.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, test.expectedTypes, serviceTypes(node.document.Service))
+		})
+	}
+}
+
+func TestService_ReplaceService(t *testing.T) {
+	customer := domain.Customer{Id: 1, Name: "Customer", Did: stringPtr("did:nuts:1")}
+	sharedReference := testSPDID + "/serviceEndpoint?type=eOverdracht"
+	dedicated := []did.Service{
+		testService("eOverdracht-fhir", "https://example.com/fhir"),
+		testService("eOverdracht", map[string]interface{}{"fhir": "did:nuts:1/serviceEndpoint?type=eOverdracht-fhir"}),
+	}
+	tests := []struct {
+		name          string
+		services      []did.Service
+		enableErr     error
+		expectedTypes []string
+	}{
+		{
+			name:          "shared service replaced",
+			services:      []did.Service{testService("other", "https://example.com"), testService("eOverdracht", sharedReference)},
+			expectedTypes: []string{"other", "enabled"},
+		},
+		{
+			name:          "shared service restored",
+			services:      []did.Service{testService("other", "https://example.com"), testService("eOverdracht", sharedReference)},
+			enableErr:     errors.New("failed"),
+			expectedTypes: []string{"other", "eOverdracht"},
+		},
+		{
+			name:          "dedicated service replaced",
+			services:      dedicated,
+			expectedTypes: []string{"enabled"},
+		},
+		{
+			name:          "dedicated service restored",
+			services:      dedicated,
+			enableErr:     errors.New("failed"),
+			expectedTypes: []string{"eOverdracht-fhir", "eOverdracht"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			node := &testNode{document: testDocument(*customer.Did, test.services...)}
+
+			err := node.service(t, customer).ReplaceService(customer.Id, "eOverdracht", func() error {
+				if test.enableErr != nil {
+					return test.enableErr
+				}
+				node.document.Service = append(node.document.Service, testService("enabled", "https://example.com"))
+				return nil
+			})
+
+			assert.Equal(t, test.enableErr, err)
+			assert.Equal(t, test.expectedTypes, serviceTypes(node.document.Service))
+		})
+	}
+}
+
+func TestService_SwitchServiceMode(t *testing.T) {
+	customer := domain.Customer{Id: 1, Name: "Customer", Did: stringPtr("did:nuts:1")}
+	sharedReference := testSPDID + "/serviceEndpoint?type=eOverdracht"
+	shared := []did.Service{testService("other", "https://example.com"), testService("eOverdracht", sharedReference)}
+	dedicated := []did.Service{
+		testService("other", "https://example.com"),
+		testService("eOverdracht-fhir", "https://example.com/fhir"),
+		testService("eOverdracht", map[string]interface{}{"fhir": "did:nuts:1/serviceEndpoint?type=eOverdracht-fhir"}),
+	}
+	enableShared := func(service Service) error {
+		return service.EnableService(customer.Id, testSPDID, "eOverdracht")
+	}
+	enableDedicated := func(service Service) error {
+		return service.EnableDedicatedService(customer.Id, "eOverdracht", nil, map[string]interface{}{"fhir": "https://example.com/other"})
+	}
+	tests := []struct {
+		name      string
+		services  []did.Service
+		enable    func(service Service) error
+		failTypes map[string]bool
+		expectErr bool
+		// expected contains the types of the services, with compound services marked as such.
+		expected []string
+	}{
+		{
+			name:     "shared to dedicated",
+			services: shared,
+			enable:   enableDedicated,
+			expected: []string{"other", "eOverdracht-fhir", "eOverdracht (compound)"},
+		},
+		{
+			name:      "shared service is kept when switching to dedicated fails",
+			services:  shared,
+			enable:    enableDedicated,
+			failTypes: map[string]bool{"eOverdracht-fhir": true},
+			expectErr: true,
+			expected:  []string{"other", "eOverdracht"},
+		},
+		{
+			name:     "dedicated to shared",
+			services: dedicated,
+			enable:   enableShared,
+			expected: []string{"other", "eOverdracht"},
+		},
+		{
+			name:     "shared service added",
+			services: shared[:1],
+			enable:   enableShared,
+			expected: []string{"other", "eOverdracht"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			node := &testNode{document: testDocument(*customer.Did, test.services...), failTypes: test.failTypes}
+
+			err := test.enable(node.service(t, customer))
+
+			if test.expectErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			var actual []string
+			for _, service := range node.document.Service {
+				if _, isCompound := service.ServiceEndpoint.(map[string]interface{}); isCompound {
+					actual = append(actual, service.Type+" (compound)")
+				} else {
+					actual = append(actual, service.Type)
+				}
+			}
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
+
+func serviceTypes(services []did.Service) []string {
+	var result []string
+	for _, service := range services {
+		result = append(result, service.Type)
+	}
+	return result
+}
@@ -0,0 +1,183 @@
+package directory
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/nuts-foundation/nuts-node/vcr/credential"
+	"github.com/nuts-foundation/nuts-registry-admin-demo/domain"
+	"github.com/nuts-foundation/nuts-registry-admin-demo/domain/jobs"
+	"github.com/sirupsen/logrus"
+)
+
+// RefreshIndexJobType is the type of the job which refreshes the local index of organizations.
+const RefreshIndexJobType = "refresh-organization-index"
+
+// servicesMaxAge defines how long the services of an indexed organization are used before its DID document is resolved again.
+const servicesMaxAge = time.Hour
+
+// ScheduleIndexRefresh submits a job which refreshes the local index of organizations right away and then periodically.
+func (s Service) ScheduleIndexRefresh(queue *jobs.Queue, interval time.Duration) {
+	submit := func() {
+		if _, err := queue.Submit(RefreshIndexJobType, struct{}{}); err != nil {
+			logrus.Errorf("Unable to submit organization index refresh job: %v", err)
+		}
+	}
+	go func() {
+		submit()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			submit()
+		}
+	}()
+}
+
+func (s Service) HandleRefreshIndexJob(_ json.RawMessage, progress jobs.ProgressFunc) error {
+	return s.RefreshIndex(progress)
+}
+
+// RefreshIndex updates the local index with the NutsOrganizationCredentials the Nuts node knows, of trusted and untrusted issuers.
+// The refresh is incremental: only organizations of which the credentials changed are stored, and DID documents are only resolved
+// for new organizations and organizations of which the services are older than servicesMaxAge.
+// When a DID document can't be resolved, the organization keeps its previous services and is retried on the next refresh.
+func (s Service) RefreshIndex(progress jobs.ProgressFunc) error {
+	organizations, err := s.CredentialService.AllOrganizations()
+	if err != nil {
+		return err
+	}
+	trustedIssuers, err := s.CredentialService.TrustedIssuers(credential.NutsOrganizationCredentialType)
+	if err != nil {
+		return err
+	}
+	indexed, err := s.Index.All()
+	if err != nil {
+		return err
+	}
+	existing := make(map[string]IndexedOrganization, len(indexed))
+	for _, curr := range indexed {
+		existing[curr.ID] = curr
+	}
+
+	current := map[string]*IndexedOrganization{}
+	for _, curr := range organizations {
+		organization, ok := current[curr.Subject]
+		if !ok {
+			organization = &IndexedOrganization{ID: curr.Subject, Services: []string{}}
+			if previous, known := existing[curr.Subject]; known {
+				organization.Services = previous.Services
+				organization.ServicesResolved = previous.ServicesResolved
+			}
+			current[curr.Subject] = organization
+		}
+		organization.Credentials = append(organization.Credentials, IndexedCredential{
+			ID:     curr.ID,
+			Issuer: curr.Issuer,
+			Name:   curr.Organization.Name,
+			City:   curr.Organization.City,
+		})
+	}
+
+	now := time.Now()
+	var changed []IndexedOrganization
+	var stale []*IndexedOrganization
+	for id, organization := range current {
+		sort.Slice(organization.Credentials, func(i, j int) bool {
+			return organization.Credentials[i].ID < organization.Credentials[j].ID
+		})
+		previous, known := existing[id]
+		if !known || now.Sub(organization.ServicesResolved) > servicesMaxAge {
+			stale = append(stale, organization)
+		} else if !equalCredentials(previous.Credentials, organization.Credentials) {
+			changed = append(changed, *organization)
+		}
+	}
+
+	var mutex sync.Mutex
+	processed := 0
+	forEachConcurrently(len(stale), func(i int) {
+		services, err := s.compoundServices(stale[i].ID)
+		if err != nil {
+			logrus.Warnf("Unable to resolve services of organization (did=%s): %v", stale[i].ID, err)
+		} else {
+			stale[i].Services = services
+			stale[i].ServicesResolved = now
+		}
+		mutex.Lock()
+		defer mutex.Unlock()
+		processed++
+		progress(processed, len(stale))
+	})
+	for _, organization := range stale {
+		changed = append(changed, *organization)
+	}
+
+	var removed []string
+	for id := range existing {
+		if _, ok := current[id]; !ok {
+			removed = append(removed, id)
+		}
+	}
+	if err := s.Index.Update(changed, removed, trustedIssuers, now); err != nil {
+		return err
+	}
+	logrus.Infof("Refreshed organization index (organizations=%d, updated=%d, removed=%d)", len(current), len(changed), len(removed))
+	return nil
+}
+
+// indexCandidates returns the organizations in the local index and the issuers the Nuts node trusts.
+// Returns nil candidates when the index was never updated, so the Nuts node must be searched instead.
+func (s Service) indexCandidates() ([]candidate, map[string]bool, *time.Time, error) {
+	updated, err := s.Index.Updated()
+	if err != nil || updated == nil {
+		return nil, nil, nil, err
+	}
+	indexed, err := s.Index.All()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	trustedIssuers, err := s.trustedIssuers()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	candidates := []candidate{}
+	for _, organization := range indexed {
+		for _, curr := range organization.Credentials {
+			candidates = append(candidates, candidate{
+				subject:  organization.ID,
+				issuer:   curr.Issuer,
+				name:     curr.Name,
+				city:     curr.City,
+				id:       curr.ID,
+				services: organization.Services,
+			})
+		}
+	}
+	return candidates, toSet(trustedIssuers), updated, nil
+}
+
+// trustedIssuers returns the issuers of NutsOrganizationCredentials the Nuts node trusts, since trust may have changed after the index was updated.
+// Only when the node can't be reached, the trusted issuers at the time of the last update are returned.
+func (s Service) trustedIssuers() ([]string, error) {
+	trustedIssuers, err := s.CredentialService.TrustedIssuers(credential.NutsOrganizationCredentialType)
+	if errors.Is(err, domain.ErrNutsNodeUnreachable) {
+		logrus.Warnf("Unable to list trusted issuers, using those of the last organization index refresh: %v", err)
+		return s.Index.TrustedIssuers()
+	}
+	return trustedIssuers, err
+}
+
+func equalCredentials(a, b []IndexedCredential) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,109 @@
+package directory
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nuts-foundation/nuts-registry-admin-demo/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func indexedByID(t *testing.T, index IndexRepository) map[string]IndexedOrganization {
+	all, err := index.All()
+	require.NoError(t, err)
+	result := map[string]IndexedOrganization{}
+	for _, organization := range all {
+		result[organization.ID] = organization
+	}
+	return result
+}
+
+func TestService_RefreshIndex(t *testing.T) {
+	node := newTestNode()
+	index := testIndex(t)
+	service := node.service(t, index)
+	refresh := func(t *testing.T) (processed int) {
+		require.NoError(t, service.RefreshIndex(func(_ int, _ int) {
+			processed++
+		}))
+		return processed
+	}
+
+	t.Run("first refresh resolves all organizations", func(t *testing.T) {
+		processed := refresh(t)
+
+		assert.Equal(t, 3, processed)
+		assert.Equal(t, map[string]int{"did:nuts:1": 1, "did:nuts:2": 1, "did:nuts:3": 1}, node.resolved)
+		indexed := indexedByID(t, index)
+		assert.Len(t, indexed, 3)
+		assert.Equal(t, []IndexedCredential{
+			{ID: trustedIssuer + "#did:nuts:1", Issuer: trustedIssuer, Name: "Ziekenhuis Amsterdam", City: "Amsterdam"},
+			{ID: untrustedIssuer + "#did:nuts:1", Issuer: untrustedIssuer, Name: "Ziekenhuis A'dam", City: "Amsterdam"},
+		}, indexed["did:nuts:1"].Credentials)
+		assert.Equal(t, []string{"eOverdracht", "zorgnetwerk"}, indexed["did:nuts:2"].Services)
+		assert.Equal(t, []string{}, indexed["did:nuts:3"].Services)
+		trusted, err := index.TrustedIssuers()
+		require.NoError(t, err)
+		assert.Equal(t, []string{trustedIssuer}, trusted)
+		updated, err := index.Updated()
+		require.NoError(t, err)
+		assert.NotNil(t, updated)
+	})
+	t.Run("unchanged organizations aren't resolved again", func(t *testing.T) {
+		processed := refresh(t)
+
+		assert.Equal(t, 0, processed)
+		assert.Equal(t, map[string]int{"did:nuts:1": 1, "did:nuts:2": 1, "did:nuts:3": 1}, node.resolved)
+	})
+	t.Run("changed and removed organizations", func(t *testing.T) {
+		node.organizations = []domain.OrganizationConceptCredential{
+			node.organizations[1],
+			organizationCredential("did:nuts:3", trustedIssuer, "Huisartsenpraktijk Amsterdam", "Amsterdam"),
+		}
+
+		refresh(t)
+
+		assert.Equal(t, map[string]int{"did:nuts:1": 1, "did:nuts:2": 1, "did:nuts:3": 1}, node.resolved)
+		indexed := indexedByID(t, index)
+		assert.Len(t, indexed, 2)
+		assert.Len(t, indexed["did:nuts:1"].Credentials, 1)
+		assert.Equal(t, "Huisartsenpraktijk Amsterdam", indexed["did:nuts:3"].Credentials[0].Name)
+	})
+	t.Run("stale services are resolved again", func(t *testing.T) {
+		stale := indexedByID(t, index)["did:nuts:1"]
+		stale.ServicesResolved = time.Now().Add(-servicesMaxAge - time.Minute)
+		require.NoError(t, index.Update([]IndexedOrganization{stale}, nil, []string{trustedIssuer}, time.Now()))
+		node.services["did:nuts:1"] = []string{"eOverdracht", "zorgnetwerk"}
+
+		processed := refresh(t)
+
+		assert.Equal(t, 1, processed)
+		assert.Equal(t, 2, node.resolved["did:nuts:1"])
+		assert.Equal(t, []string{"eOverdracht", "zorgnetwerk"}, indexedByID(t, index)["did:nuts:1"].Services)
+	})
+	t.Run("previous services are kept when the DID document can't be resolved", func(t *testing.T) {
+		stale := indexedByID(t, index)["did:nuts:1"]
+		stale.ServicesResolved = time.Now().Add(-servicesMaxAge - time.Minute)
+		require.NoError(t, index.Update([]IndexedOrganization{stale}, nil, []string{trustedIssuer}, time.Now()))
+		delete(node.services, "did:nuts:1")
+
+		refresh(t)
+		refresh(t)
+
+		// Not resolved successfully, so it's retried on every refresh
+		assert.Equal(t, 4, node.resolved["did:nuts:1"])
+		indexed := indexedByID(t, index)["did:nuts:1"]
+		assert.Equal(t, []string{"eOverdracht", "zorgnetwerk"}, indexed.Services)
+		assert.Equal(t, stale.ServicesResolved.Unix(), indexed.ServicesResolved.Unix())
+	})
+	t.Run("trusted issuers are stored", func(t *testing.T) {
+		node.trusted = []string{trustedIssuer, untrustedIssuer}
+
+		refresh(t)
+
+		trusted, err := index.TrustedIssuers()
+		require.NoError(t, err)
+		assert.Equal(t, []string{trustedIssuer, untrustedIssuer}, trusted)
+	})
+}
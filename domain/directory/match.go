@@ -0,0 +1,105 @@
+package directory
+
+import (
+	"strings"
+	"unicode"
+)
+
+// matchScore returns how well the value matches the query, from 0 (no match) to 1 (exact match).
+// Values containing the query score highest. Otherwise every word of the query must match a word of the value
+// (or the start of it) with a few typos at most, e.g. "ziekenhuis" is matched by "zeikenhuis" and "ziek".
+func matchScore(query, value string) float64 {
+	query = normalize(query)
+	value = normalize(value)
+	switch {
+	case len(query) == 0 || query == value:
+		return 1
+	case strings.HasPrefix(value, query):
+		return 0.9
+	case strings.Contains(value, query):
+		return 0.8
+	}
+
+	valueWords := strings.Fields(value)
+	typos := 0
+	length := 0
+	for _, queryWord := range strings.Fields(query) {
+		best := -1
+		for _, valueWord := range valueWords {
+			distance := wordDistance([]rune(queryWord), []rune(valueWord))
+			if best == -1 || distance < best {
+				best = distance
+			}
+		}
+		if best == -1 || best > maxTypos(queryWord) {
+			return 0
+		}
+		typos += best
+		length += len([]rune(queryWord))
+	}
+	return 0.7 * (1 - float64(typos)/float64(length))
+}
+
+// normalize lower cases the value and replaces punctuation by spaces, so "St. Jansdal-Ziekenhuis" matches "st jansdal ziekenhuis".
+func normalize(value string) string {
+	return strings.Join(strings.Fields(strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return ' '
+	}, value)), " ")
+}
+
+// maxTypos returns the number of typos tolerated in a query word, which depends on its length.
+func maxTypos(word string) int {
+	switch length := len([]rune(word)); {
+	case length <= 3:
+		return 0
+	case length <= 7:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// wordDistance returns the edit distance between the query word and the value word, or the start of the value word
+// when the value word is longer, so partially typed words match.
+func wordDistance(queryWord, valueWord []rune) int {
+	distance := levenshtein(queryWord, valueWord)
+	if len(valueWord) > len(queryWord) {
+		if prefixDistance := levenshtein(queryWord, valueWord[:len(queryWord)]); prefixDistance < distance {
+			distance = prefixDistance
+		}
+	}
+	return distance
+}
+
+func levenshtein(a, b []rune) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
+
+func min(values ...int) int {
+	result := values[0]
+	for _, value := range values[1:] {
+		if value < result {
+			result = value
+		}
+	}
+	return result
+}
@@ -0,0 +1,56 @@
+package directory
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchScore(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		value    string
+		expected float64
+	}{
+		{name: "empty query", query: "", value: "Ziekenhuis Amsterdam", expected: 1},
+		{name: "exact, ignoring case and punctuation", query: "st jansdal-ziekenhuis", value: "St. Jansdal Ziekenhuis", expected: 1},
+		{name: "prefix", query: "ziekenhuis", value: "Ziekenhuis Amsterdam", expected: 0.9},
+		{name: "contains", query: "amsterdam", value: "Ziekenhuis Amsterdam", expected: 0.8},
+		{name: "words in another order", query: "amsterdam ziekenhuis", value: "Ziekenhuis Amsterdam", expected: 0.7},
+		{name: "partially typed words", query: "amst ziek", value: "Ziekenhuis Amsterdam", expected: 0.7},
+		{name: "typos", query: "zeikenhuis", value: "Ziekenhuis Amsterdam", expected: 0.7 * (1 - 2.0/10)},
+		{name: "typo in a partially typed word", query: "ziek amsterdm", value: "Ziekenhuis Amsterdam", expected: 0.7 * (1 - 1.0/12)},
+		{name: "too many typos", query: "zaikanhuis", value: "Ziekenhuis Amsterdam"},
+		{name: "no typos in short words", query: "amz ziek", value: "Ziekenhuis Amsterdam"},
+		{name: "every word must match", query: "ziekenhuis utrecht", value: "Ziekenhuis Amsterdam"},
+		{name: "no match", query: "huisarts", value: "Ziekenhuis Amsterdam"},
+		{name: "empty value", query: "ziekenhuis", value: ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.InDelta(t, test.expected, matchScore(test.query, test.value), 0.0001)
+		})
+	}
+}
+
+func TestWordDistance(t *testing.T) {
+	tests := []struct {
+		queryWord string
+		valueWord string
+		expected  int
+	}{
+		{queryWord: "ziekenhuis", valueWord: "ziekenhuis", expected: 0},
+		{queryWord: "ziek", valueWord: "ziekenhuis", expected: 0},
+		{queryWord: "zeik", valueWord: "ziekenhuis", expected: 2},
+		{queryWord: "ziekenhuizen", valueWord: "ziekenhuis", expected: 3},
+		{queryWord: "ziekenhuis", valueWord: "ziek", expected: 6},
+		{queryWord: "ziekenhuis", valueWord: "", expected: 10},
+		{queryWord: "äbc", valueWord: "abc", expected: 1},
+	}
+	for _, test := range tests {
+		t.Run(test.queryWord+"-"+test.valueWord, func(t *testing.T) {
+			assert.Equal(t, test.expected, wordDistance([]rune(test.queryWord), []rune(test.valueWord)))
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package directory
+
+import (
+	"encoding/json"
+	"time"
+
+	"go.etcd.io/bbolt"
+)
+
+const indexBucketName = "OrganizationIndex"
+
+const indexMetadataBucketName = "OrganizationIndexMetadata"
+
+var updatedKey = []byte("updated")
+
+var trustedIssuersKey = []byte("trustedIssuers")
+
+// IndexedOrganization is an organization in the local index, with its NutsOrganizationCredentials and compound services.
+type IndexedOrganization struct {
+	// ID is the DID of the organization.
+	ID          string              `json:"id"`
+	Credentials []IndexedCredential `json:"credentials"`
+	// Services contains the types of the compound services in the organization's DID document.
+	Services []string `json:"services"`
+	// ServicesResolved is when the DID document was last resolved. It's zero when it was never resolved successfully.
+	ServicesResolved time.Time `json:"servicesResolved"`
+}
+
+// IndexedCredential is a NutsOrganizationCredential of an organization in the local index.
+type IndexedCredential struct {
+	ID     string `json:"id"`
+	Issuer string `json:"issuer"`
+	Name   string `json:"name"`
+	City   string `json:"city"`
+}
+
+type IndexRepository interface {
+	// All returns all organizations in the index.
+	All() ([]IndexedOrganization, error)
+	// TrustedIssuers returns the trusted issuers of NutsOrganizationCredentials at the time the index was updated.
+	// They're only used when the Nuts node can't be reached, since trust may have changed after the update.
+	TrustedIssuers() ([]string, error)
+	// Updated returns when the index was last updated. Returns nil when it was never updated.
+	Updated() (*time.Time, error)
+	// Update stores and removes the given organizations, and the trusted issuers at the time of the update, in a single transaction.
+	Update(organizations []IndexedOrganization, removed []string, trustedIssuers []string, updated time.Time) error
+}
+
+type bboltIndexRepository struct {
+	DB *bbolt.DB
+}
+
+func NewBBoltIndexRepository(db *bbolt.DB) IndexRepository {
+	return &bboltIndexRepository{DB: db}
+}
+
+func (b bboltIndexRepository) All() ([]IndexedOrganization, error) {
+	result := []IndexedOrganization{}
+	err := b.DB.View(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte(indexBucketName))
+		if b == nil {
+			return nil
+		}
+		return b.ForEach(func(_, data []byte) error {
+			var organization IndexedOrganization
+			if err := json.Unmarshal(data, &organization); err != nil {
+				return err
+			}
+			result = append(result, organization)
+			return nil
+		})
+	})
+	return result, err
+}
+
+func (b bboltIndexRepository) TrustedIssuers() ([]string, error) {
+	result := []string{}
+	err := b.DB.View(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte(indexMetadataBucketName))
+		if b == nil {
+			return nil
+		}
+		data := b.Get(trustedIssuersKey)
+		if data == nil {
+			return nil
+		}
+		return json.Unmarshal(data, &result)
+	})
+	return result, err
+}
+
+func (b bboltIndexRepository) Updated() (*time.Time, error) {
+	var result *time.Time
+	err := b.DB.View(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte(indexMetadataBucketName))
+		if b == nil {
+			return nil
+		}
+		data := b.Get(updatedKey)
+		if data == nil {
+			return nil
+		}
+		result = &time.Time{}
+		return result.UnmarshalText(data)
+	})
+	return result, err
+}
+
+func (b bboltIndexRepository) Update(organizations []IndexedOrganization, removed []string, trustedIssuers []string, updated time.Time) error {
+	return b.DB.Update(func(tx *bbolt.Tx) error {
+		index, err := tx.CreateBucketIfNotExists([]byte(indexBucketName))
+		if err != nil {
+			return err
+		}
+		for _, organization := range organizations {
+			data, err := json.Marshal(organization)
+			if err != nil {
+				return err
+			}
+			if err := index.Put([]byte(organization.ID), data); err != nil {
+				return err
+			}
+		}
+		for _, id := range removed {
+			if err := index.Delete([]byte(id)); err != nil {
+				return err
+			}
+		}
+
+		metadata, err := tx.CreateBucketIfNotExists([]byte(indexMetadataBucketName))
+		if err != nil {
+			return err
+		}
+		trustedIssuersData, err := json.Marshal(trustedIssuers)
+		if err != nil {
+			return err
+		}
+		if err := metadata.Put(trustedIssuersKey, trustedIssuersData); err != nil {
+			return err
+		}
+		updatedData, err := updated.MarshalText()
+		if err != nil {
+			return err
+		}
+		return metadata.Put(updatedKey, updatedData)
+	})
+}
@@ -0,0 +1,315 @@
+package directory
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nuts-foundation/nuts-node/vcr/credential"
+	"github.com/nuts-foundation/nuts-registry-admin-demo/domain"
+	"github.com/sirupsen/logrus"
+)
+
+var ErrInvalidQuery = errors.New("invalid directory query")
+
+const (
+	TrustFilterTrusted   domain.SearchOrganizationDirectoryParamsTrust = "trusted"
+	TrustFilterUntrusted domain.SearchOrganizationDirectoryParamsTrust = "untrusted"
+)
+
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+)
+
+// documentLookupWorkers is the maximum number of DID documents which are resolved concurrently.
+const documentLookupWorkers = 8
+
+// OrganizationCredentials finds the NutsOrganizationCredentials and their trusted issuers on the Nuts node, see credentials.Service.
+type OrganizationCredentials interface {
+	AllOrganizations() ([]domain.OrganizationConceptCredential, error)
+	SearchOrganizations(name, city string) ([]domain.OrganizationConceptCredential, error)
+	TrustedIssuers(credentialType string) ([]string, error)
+}
+
+// Service searches the organizations which have a NutsOrganizationCredential, enriched with the compound services they offer.
+type Service struct {
+	CredentialService OrganizationCredentials
+	VDRClient         domain.VDRClient
+	// Index is the local index of organizations which is searched instead of the Nuts node, once it has been refreshed.
+	// If nil, the Nuts node is always searched.
+	Index IndexRepository
+}
+
+// candidate is a NutsOrganizationCredential which might match a search.
+type candidate struct {
+	subject string
+	issuer  string
+	name    string
+	city    string
+	id      string
+	// services contains the compound services of the subject, or nil if they're not resolved yet.
+	services []string
+}
+
+type match struct {
+	organization domain.DirectoryOrganization
+	score        float64
+	resolved     bool
+}
+
+// Search returns the requested page of organizations matching the query, the best matches first.
+// An organization with NutsOrganizationCredentials of multiple issuers is listed once, preferring a trusted issuer.
+// When searched in the local index, the page contains the time the index was updated.
+func (s Service) Search(query domain.SearchOrganizationDirectoryParams) (*domain.DirectoryPage, error) {
+	page, pageSize, err := validateQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	candidates, trusted, indexUpdated, err := s.candidates()
+	if err != nil {
+		return nil, err
+	}
+
+	bySubject := map[string]match{}
+	for _, curr := range candidates {
+		isTrusted := trusted[curr.issuer]
+		if query.Trust != nil && isTrusted != (*query.Trust == TrustFilterTrusted) {
+			continue
+		}
+		score := matchScore(stringValue(query.Query), curr.name)
+		if query.City != nil {
+			score *= matchScore(*query.City, curr.city)
+		}
+		if score == 0 {
+			continue
+		}
+		if existing, ok := bySubject[curr.subject]; ok && (existing.organization.Trusted || !isTrusted) {
+			continue
+		}
+		bySubject[curr.subject] = match{
+			organization: domain.DirectoryOrganization{
+				Id:       curr.subject,
+				Name:     curr.name,
+				City:     curr.city,
+				Issuer:   curr.issuer,
+				Trusted:  isTrusted,
+				Services: curr.services,
+			},
+			score:    score,
+			resolved: curr.services != nil,
+		}
+	}
+	matches := make([]match, 0, len(bySubject))
+	for _, curr := range bySubject {
+		matches = append(matches, curr)
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].score != matches[j].score {
+			return matches[i].score > matches[j].score
+		}
+		if matches[i].organization.Name != matches[j].organization.Name {
+			return matches[i].organization.Name < matches[j].organization.Name
+		}
+		return matches[i].organization.Id < matches[j].organization.Id
+	})
+
+	// Filtering on service requires the services of all matches, otherwise only those on the requested page are resolved
+	if query.Service != nil && len(*query.Service) > 0 {
+		s.resolveServices(matches)
+		filtered := matches[:0]
+		for _, curr := range matches {
+			if containsString(curr.organization.Services, *query.Service) {
+				filtered = append(filtered, curr)
+			}
+		}
+		matches = filtered
+	}
+
+	result := domain.DirectoryPage{
+		Total:         len(matches),
+		Page:          page,
+		PageSize:      pageSize,
+		Organizations: []domain.DirectoryOrganization{},
+		IndexUpdated:  indexUpdated,
+	}
+	start := (page - 1) * pageSize
+	if start >= len(matches) {
+		return &result, nil
+	}
+	end := start + pageSize
+	if end > len(matches) {
+		end = len(matches)
+	}
+	pageMatches := matches[start:end]
+	s.resolveServices(pageMatches)
+	for _, curr := range pageMatches {
+		result.Organizations = append(result.Organizations, curr.organization)
+	}
+	return &result, nil
+}
+
+// SearchOrganizations returns the organizations of which the name and city start with the given name and city (case-insensitive),
+// having a NutsOrganizationCredential of a trusted issuer. It returns the time the local index was updated,
+// or nil when the Nuts node was searched.
+func (s Service) SearchOrganizations(name, city string) ([]domain.OrganizationConceptCredential, *time.Time, error) {
+	if s.Index != nil {
+		candidates, trusted, indexUpdated, err := s.indexCandidates()
+		if err != nil {
+			return nil, nil, err
+		}
+		if indexUpdated != nil {
+			result := []domain.OrganizationConceptCredential{}
+			for _, curr := range candidates {
+				if !trusted[curr.issuer] || !hasPrefixFold(curr.name, name) || !hasPrefixFold(curr.city, city) {
+					continue
+				}
+				result = append(result, domain.OrganizationConceptCredential{
+					ID:           curr.id,
+					Issuer:       curr.issuer,
+					Organization: domain.Organization{Name: curr.name, City: curr.city},
+					Subject:      curr.subject,
+				})
+			}
+			return result, indexUpdated, nil
+		}
+	}
+	result, err := s.CredentialService.SearchOrganizations(name, city)
+	return result, nil, err
+}
+
+// candidates returns the NutsOrganizationCredentials to search and the trusted issuers, from the local index when it has been refreshed.
+// Otherwise they're fetched from the Nuts node, and the returned index update time is nil.
+func (s Service) candidates() ([]candidate, map[string]bool, *time.Time, error) {
+	if s.Index != nil {
+		candidates, trusted, updated, err := s.indexCandidates()
+		if err != nil || updated != nil {
+			return candidates, trusted, updated, err
+		}
+	}
+	organizations, err := s.CredentialService.AllOrganizations()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	trustedIssuers, err := s.CredentialService.TrustedIssuers(credential.NutsOrganizationCredentialType)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	candidates := make([]candidate, len(organizations))
+	for i, curr := range organizations {
+		candidates[i] = candidate{
+			subject: curr.Subject,
+			issuer:  curr.Issuer,
+			name:    curr.Organization.Name,
+			city:    curr.Organization.City,
+			id:      curr.ID,
+		}
+	}
+	return candidates, toSet(trustedIssuers), nil, nil
+}
+
+// resolveServices sets the services of the matched organizations which aren't resolved yet, resolving their DID documents concurrently.
+// Failures are logged and ignored, leaving the organization without services.
+func (s Service) resolveServices(matches []match) {
+	forEachConcurrently(len(matches), func(i int) {
+		curr := &matches[i]
+		if curr.resolved {
+			return
+		}
+		curr.resolved = true
+		curr.organization.Services = []string{}
+		services, err := s.compoundServices(curr.organization.Id)
+		if err != nil {
+			logrus.Warnf("Unable to resolve services of organization (did=%s): %v", curr.organization.Id, err)
+			return
+		}
+		curr.organization.Services = services
+	})
+}
+
+// compoundServices returns the sorted types of the compound services in the DID document.
+func (s Service) compoundServices(id string) ([]string, error) {
+	document, metadata, err := s.VDRClient.Get(id)
+	if err != nil {
+		return nil, domain.UnwrapAPIError(err)
+	}
+	result := []string{}
+	if metadata != nil && metadata.Deactivated {
+		return result, nil
+	}
+	for _, service := range document.Service {
+		if _, isCompound := service.ServiceEndpoint.(map[string]interface{}); isCompound && !containsString(result, service.Type) {
+			result = append(result, service.Type)
+		}
+	}
+	sort.Strings(result)
+	return result, nil
+}
+
+// forEachConcurrently calls fn for every index up to n, at most documentLookupWorkers at a time, and waits for them to finish.
+func forEachConcurrently(n int, fn func(i int)) {
+	var wg sync.WaitGroup
+	semaphore := make(chan struct{}, documentLookupWorkers)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+			fn(i)
+		}(i)
+	}
+	wg.Wait()
+}
+
+func validateQuery(query domain.SearchOrganizationDirectoryParams) (int, int, error) {
+	page := 1
+	if query.Page != nil {
+		page = *query.Page
+	}
+	if page < 1 {
+		return 0, 0, fmt.Errorf("%w: page must be at least 1", ErrInvalidQuery)
+	}
+	pageSize := DefaultPageSize
+	if query.PageSize != nil {
+		pageSize = *query.PageSize
+	}
+	if pageSize < 1 || pageSize > MaxPageSize {
+		return 0, 0, fmt.Errorf("%w: pageSize must be between 1 and %d", ErrInvalidQuery, MaxPageSize)
+	}
+	if query.Trust != nil && *query.Trust != TrustFilterTrusted && *query.Trust != TrustFilterUntrusted {
+		return 0, 0, fmt.Errorf("%w: trust must be %s or %s", ErrInvalidQuery, TrustFilterTrusted, TrustFilterUntrusted)
+	}
+	return page, pageSize, nil
+}
+
+func hasPrefixFold(value, prefix string) bool {
+	return strings.HasPrefix(strings.ToLower(value), strings.ToLower(prefix))
+}
+
+func stringValue(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
+
+func containsString(values []string, value string) bool {
+	for _, curr := range values {
+		if curr == value {
+			return true
+		}
+	}
+	return false
+}
+
+func toSet(values []string) map[string]bool {
+	result := make(map[string]bool, len(values))
+	for _, value := range values {
+		result[value] = true
+	}
+	return result
+}
@@ -0,0 +1,305 @@
+package directory
+
+import (
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	ssi "github.com/nuts-foundation/go-did"
+	"github.com/nuts-foundation/go-did/did"
+	vdrAPI "github.com/nuts-foundation/nuts-node/vdr/api/v1"
+	"github.com/nuts-foundation/nuts-registry-admin-demo/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.etcd.io/bbolt"
+	"go.uber.org/mock/gomock"
+)
+
+const (
+	trustedIssuer   = "did:nuts:trusted"
+	untrustedIssuer = "did:nuts:untrusted"
+)
+
+// testNode simulates the NutsOrganizationCredentials, trusted issuers and DID documents the Nuts node knows.
+type testNode struct {
+	mutex         sync.Mutex
+	organizations []domain.OrganizationConceptCredential
+	trusted       []string
+	trustedErr    error
+	// services contains the compound service types per DID, DIDs which aren't listed can't be resolved.
+	services map[string][]string
+	// resolved counts the DID document lookups per DID.
+	resolved map[string]int
+	// listed counts the calls to AllOrganizations.
+	listed int
+}
+
+func (n *testNode) AllOrganizations() ([]domain.OrganizationConceptCredential, error) {
+	n.listed++
+	return append([]domain.OrganizationConceptCredential{}, n.organizations...), nil
+}
+
+func (n *testNode) SearchOrganizations(name, city string) ([]domain.OrganizationConceptCredential, error) {
+	trusted := toSet(n.trusted)
+	result := []domain.OrganizationConceptCredential{}
+	for _, curr := range n.organizations {
+		if trusted[curr.Issuer] && hasPrefixFold(curr.Organization.Name, name) && hasPrefixFold(curr.Organization.City, city) {
+			result = append(result, curr)
+		}
+	}
+	return result, nil
+}
+
+func (n *testNode) TrustedIssuers(_ string) ([]string, error) {
+	return n.trusted, n.trustedErr
+}
+
+func (n *testNode) service(t *testing.T, index IndexRepository) Service {
+	ctrl := gomock.NewController(t)
+	vdrClient := domain.NewMockVDRClient(ctrl)
+	vdrClient.EXPECT().Get(gomock.Any()).DoAndReturn(func(id string) (*did.Document, *vdrAPI.DocumentMetadata, error) {
+		n.mutex.Lock()
+		defer n.mutex.Unlock()
+		if n.resolved == nil {
+			n.resolved = map[string]int{}
+		}
+		n.resolved[id]++
+		serviceTypes, ok := n.services[id]
+		if !ok {
+			return nil, nil, errors.New("unable to resolve DID document")
+		}
+		document := &did.Document{ID: did.MustParseDID(id)}
+		for _, serviceType := range serviceTypes {
+			document.Service = append(document.Service, did.Service{
+				ID:              ssi.MustParseURI(id + "#" + serviceType),
+				Type:            serviceType,
+				ServiceEndpoint: map[string]interface{}{"fhir": "did:nuts:sp/serviceEndpoint?type=fhir"},
+			})
+		}
+		// Endpoints which aren't compound services are ignored
+		document.Service = append(document.Service, did.Service{ID: ssi.MustParseURI(id + "#NutsComm"), Type: "NutsComm", ServiceEndpoint: "grpc://nuts.nl:5555"})
+		return document, nil, nil
+	}).AnyTimes()
+	return Service{CredentialService: n, VDRClient: vdrClient, Index: index}
+}
+
+func testIndex(t *testing.T) IndexRepository {
+	db, err := bbolt.Open(filepath.Join(t.TempDir(), "test.db"), 0600, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+	return NewBBoltIndexRepository(db)
+}
+
+func organizationCredential(subject, issuer, name, city string) domain.OrganizationConceptCredential {
+	return domain.OrganizationConceptCredential{
+		ID:           issuer + "#" + subject,
+		Issuer:       issuer,
+		Organization: domain.Organization{Name: name, City: city},
+		Subject:      subject,
+	}
+}
+
+func newTestNode() *testNode {
+	return &testNode{
+		organizations: []domain.OrganizationConceptCredential{
+			// The untrusted credential comes first, so the trusted one must be preferred over it
+			organizationCredential("did:nuts:1", untrustedIssuer, "Ziekenhuis A'dam", "Amsterdam"),
+			organizationCredential("did:nuts:1", trustedIssuer, "Ziekenhuis Amsterdam", "Amsterdam"),
+			organizationCredential("did:nuts:2", untrustedIssuer, "Utrecht Ziekenhuis", "Utrecht"),
+			organizationCredential("did:nuts:3", trustedIssuer, "Huisarts Amsterdam", "Amsterdam"),
+		},
+		trusted: []string{trustedIssuer},
+		services: map[string][]string{
+			"did:nuts:1": {"eOverdracht"},
+			"did:nuts:2": {"eOverdracht", "zorgnetwerk"},
+			"did:nuts:3": {},
+		},
+	}
+}
+
+func organizationIDs(page *domain.DirectoryPage) []string {
+	result := []string{}
+	for _, organization := range page.Organizations {
+		result = append(result, organization.Id)
+	}
+	return result
+}
+
+func intPtr(value int) *int {
+	return &value
+}
+
+func stringPtr(value string) *string {
+	return &value
+}
+
+func trustPtr(value domain.SearchOrganizationDirectoryParamsTrust) *domain.SearchOrganizationDirectoryParamsTrust {
+	return &value
+}
+
+func TestService_Search(t *testing.T) {
+	tests := []struct {
+		name          string
+		query         domain.SearchOrganizationDirectoryParams
+		expectedIDs   []string
+		expectedTotal int
+		expectedErr   string
+	}{
+		{name: "all, sorted by name", query: domain.SearchOrganizationDirectoryParams{}, expectedIDs: []string{"did:nuts:3", "did:nuts:2", "did:nuts:1"}, expectedTotal: 3},
+		{name: "best match first", query: domain.SearchOrganizationDirectoryParams{Query: stringPtr("ziekenhuis")}, expectedIDs: []string{"did:nuts:1", "did:nuts:2"}, expectedTotal: 2},
+		{name: "with typos", query: domain.SearchOrganizationDirectoryParams{Query: stringPtr("zeikenhuis")}, expectedIDs: []string{"did:nuts:2", "did:nuts:1"}, expectedTotal: 2},
+		{name: "only the matching credential", query: domain.SearchOrganizationDirectoryParams{Query: stringPtr("ziekenhuis a'dam")}, expectedIDs: []string{"did:nuts:1"}, expectedTotal: 1},
+		{name: "city", query: domain.SearchOrganizationDirectoryParams{Query: stringPtr("ziekenhuis"), City: stringPtr("utrecht")}, expectedIDs: []string{"did:nuts:2"}, expectedTotal: 1},
+		{name: "trusted", query: domain.SearchOrganizationDirectoryParams{Trust: trustPtr(TrustFilterTrusted)}, expectedIDs: []string{"did:nuts:3", "did:nuts:1"}, expectedTotal: 2},
+		{name: "untrusted", query: domain.SearchOrganizationDirectoryParams{Trust: trustPtr(TrustFilterUntrusted)}, expectedIDs: []string{"did:nuts:2", "did:nuts:1"}, expectedTotal: 2},
+		{name: "service", query: domain.SearchOrganizationDirectoryParams{Service: stringPtr("zorgnetwerk")}, expectedIDs: []string{"did:nuts:2"}, expectedTotal: 1},
+		{name: "first page", query: domain.SearchOrganizationDirectoryParams{PageSize: intPtr(2)}, expectedIDs: []string{"did:nuts:3", "did:nuts:2"}, expectedTotal: 3},
+		{name: "last page", query: domain.SearchOrganizationDirectoryParams{Page: intPtr(2), PageSize: intPtr(2)}, expectedIDs: []string{"did:nuts:1"}, expectedTotal: 3},
+		{name: "beyond the last page", query: domain.SearchOrganizationDirectoryParams{Page: intPtr(3), PageSize: intPtr(2)}, expectedIDs: []string{}, expectedTotal: 3},
+		{name: "no match", query: domain.SearchOrganizationDirectoryParams{Query: stringPtr("apotheek")}, expectedIDs: []string{}},
+		{name: "invalid page", query: domain.SearchOrganizationDirectoryParams{Page: intPtr(0)}, expectedErr: "invalid directory query: page must be at least 1"},
+		{name: "page size too small", query: domain.SearchOrganizationDirectoryParams{PageSize: intPtr(0)}, expectedErr: "invalid directory query: pageSize must be between 1 and 100"},
+		{name: "page size too large", query: domain.SearchOrganizationDirectoryParams{PageSize: intPtr(MaxPageSize + 1)}, expectedErr: "invalid directory query: pageSize must be between 1 and 100"},
+		{name: "invalid trust", query: domain.SearchOrganizationDirectoryParams{Trust: trustPtr("maybe")}, expectedErr: "invalid directory query: trust must be trusted or untrusted"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			service := newTestNode().service(t, nil)
+
+			result, err := service.Search(test.query)
+
+			if test.expectedErr != "" {
+				assert.ErrorIs(t, err, ErrInvalidQuery)
+				assert.EqualError(t, err, test.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedIDs, organizationIDs(result))
+			assert.Equal(t, test.expectedTotal, result.Total)
+			assert.Nil(t, result.IndexUpdated)
+		})
+	}
+}
+
+func TestService_Search_Organizations(t *testing.T) {
+	node := newTestNode()
+	delete(node.services, "did:nuts:3")
+	service := node.service(t, nil)
+
+	result, err := service.Search(domain.SearchOrganizationDirectoryParams{})
+
+	require.NoError(t, err)
+	assert.Equal(t, []domain.DirectoryOrganization{
+		{Id: "did:nuts:3", Name: "Huisarts Amsterdam", City: "Amsterdam", Issuer: trustedIssuer, Trusted: true, Services: []string{}},
+		{Id: "did:nuts:2", Name: "Utrecht Ziekenhuis", City: "Utrecht", Issuer: untrustedIssuer, Trusted: false, Services: []string{"eOverdracht", "zorgnetwerk"}},
+		{Id: "did:nuts:1", Name: "Ziekenhuis Amsterdam", City: "Amsterdam", Issuer: trustedIssuer, Trusted: true, Services: []string{"eOverdracht"}},
+	}, result.Organizations, "a trusted issuer is preferred and organizations which can't be resolved are listed without services")
+}
+
+func TestService_Search_Index(t *testing.T) {
+	t.Run("node is searched until the index is refreshed", func(t *testing.T) {
+		node := newTestNode()
+		service := node.service(t, testIndex(t))
+
+		result, err := service.Search(domain.SearchOrganizationDirectoryParams{})
+
+		require.NoError(t, err)
+		assert.Nil(t, result.IndexUpdated)
+		assert.Equal(t, 1, node.listed)
+	})
+	t.Run("index is searched once refreshed", func(t *testing.T) {
+		node := newTestNode()
+		service := node.service(t, testIndex(t))
+		require.NoError(t, service.RefreshIndex(func(int, int) {}))
+		node.organizations = nil
+
+		result, err := service.Search(domain.SearchOrganizationDirectoryParams{})
+
+		require.NoError(t, err)
+		assert.NotNil(t, result.IndexUpdated)
+		assert.Equal(t, 1, node.listed)
+		assert.Equal(t, []string{"did:nuts:3", "did:nuts:2", "did:nuts:1"}, organizationIDs(result))
+		assert.Equal(t, []string{"eOverdracht", "zorgnetwerk"}, result.Organizations[1].Services)
+	})
+	t.Run("trust is taken from the node", func(t *testing.T) {
+		node := newTestNode()
+		service := node.service(t, testIndex(t))
+		require.NoError(t, service.RefreshIndex(func(int, int) {}))
+		node.trusted = []string{untrustedIssuer}
+
+		result, err := service.Search(domain.SearchOrganizationDirectoryParams{Trust: trustPtr(TrustFilterTrusted)})
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"did:nuts:2", "did:nuts:1"}, organizationIDs(result))
+		assert.Equal(t, "Ziekenhuis A'dam", result.Organizations[1].Name)
+	})
+	t.Run("trust of the last refresh is used when the node can't be reached", func(t *testing.T) {
+		node := newTestNode()
+		service := node.service(t, testIndex(t))
+		require.NoError(t, service.RefreshIndex(func(int, int) {}))
+		node.trusted = nil
+		node.trustedErr = domain.ErrNutsNodeUnreachable
+
+		result, err := service.Search(domain.SearchOrganizationDirectoryParams{Trust: trustPtr(TrustFilterTrusted)})
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"did:nuts:3", "did:nuts:1"}, organizationIDs(result))
+	})
+	t.Run("other errors listing the trusted issuers are returned", func(t *testing.T) {
+		node := newTestNode()
+		service := node.service(t, testIndex(t))
+		require.NoError(t, service.RefreshIndex(func(int, int) {}))
+		node.trustedErr = domain.ErrNutsNodeUnauthorized
+
+		_, err := service.Search(domain.SearchOrganizationDirectoryParams{})
+
+		assert.ErrorIs(t, err, domain.ErrNutsNodeUnauthorized)
+	})
+}
+
+func TestService_SearchOrganizations(t *testing.T) {
+	t.Run("node", func(t *testing.T) {
+		service := newTestNode().service(t, nil)
+
+		result, indexUpdated, err := service.SearchOrganizations("zieken", "")
+
+		require.NoError(t, err)
+		assert.Nil(t, indexUpdated)
+		require.Len(t, result, 1)
+		assert.Equal(t, "did:nuts:1", result[0].Subject)
+	})
+	t.Run("index", func(t *testing.T) {
+		node := newTestNode()
+		service := node.service(t, testIndex(t))
+		require.NoError(t, service.RefreshIndex(func(int, int) {}))
+		node.organizations = nil
+
+		result, indexUpdated, err := service.SearchOrganizations("", "amsterdam")
+
+		require.NoError(t, err)
+		assert.NotNil(t, indexUpdated)
+		assert.ElementsMatch(t, []string{trustedIssuer + "#did:nuts:1", trustedIssuer + "#did:nuts:3"}, credentialIDs(result))
+	})
+	t.Run("index with trust changed after the refresh", func(t *testing.T) {
+		node := newTestNode()
+		service := node.service(t, testIndex(t))
+		require.NoError(t, service.RefreshIndex(func(int, int) {}))
+		node.trusted = []string{untrustedIssuer}
+
+		result, _, err := service.SearchOrganizations("ziekenhuis", "")
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{untrustedIssuer + "#did:nuts:1"}, credentialIDs(result))
+	})
+}
+
+func credentialIDs(credentials []domain.OrganizationConceptCredential) []string {
+	result := []string{}
+	for _, curr := range credentials {
+		result = append(result, curr.ID)
+	}
+	return result
+}
@@ -6,6 +6,59 @@ package domain
 import (
 	"encoding/json"
 	"fmt"
+	"time"
+)
+
+// Defines values for AuthorizationResourceAssuranceLevel.
+const (
+	AuthorizationResourceAssuranceLevelHigh AuthorizationResourceAssuranceLevel = "high"
+
+	AuthorizationResourceAssuranceLevelLow AuthorizationResourceAssuranceLevel = "low"
+
+	AuthorizationResourceAssuranceLevelSubstantial AuthorizationResourceAssuranceLevel = "substantial"
+)
+
+// Defines values for AuthorizationResourceOperations.
+const (
+	AuthorizationResourceOperationsCreate AuthorizationResourceOperations = "create"
+
+	AuthorizationResourceOperationsDelete AuthorizationResourceOperations = "delete"
+
+	AuthorizationResourceOperationsHistory AuthorizationResourceOperations = "history"
+
+	AuthorizationResourceOperationsPatch AuthorizationResourceOperations = "patch"
+
+	AuthorizationResourceOperationsRead AuthorizationResourceOperations = "read"
+
+	AuthorizationResourceOperationsSearchSystem AuthorizationResourceOperations = "search-system"
+
+	AuthorizationResourceOperationsSearchType AuthorizationResourceOperations = "search-type"
+
+	AuthorizationResourceOperationsUpdate AuthorizationResourceOperations = "update"
+
+	AuthorizationResourceOperationsVread AuthorizationResourceOperations = "vread"
+)
+
+// Defines values for DriftType.
+const (
+	DriftTypeCredentialMissing DriftType = "credential-missing"
+
+	DriftTypeCredentialOutdated DriftType = "credential-outdated"
+
+	DriftTypeCredentialUnexpected DriftType = "credential-unexpected"
+
+	DriftTypeDidUnresolvable DriftType = "did-unresolvable"
+
+	DriftTypeNutscommMismatch DriftType = "nutscomm-mismatch"
+
+	DriftTypeNutscommMissing DriftType = "nutscomm-missing"
+)
+
+// Defines values for IssueVCPreviewVisibility.
+const (
+	IssueVCPreviewVisibilityPrivate IssueVCPreviewVisibility = "private"
+
+	IssueVCPreviewVisibilityPublic IssueVCPreviewVisibility = "public"
 )
 
 // Defines values for IssueVCRequestVisibility.
@@ -15,6 +68,51 @@ const (
 	IssueVCRequestVisibilityPublic IssueVCRequestVisibility = "public"
 )
 
+// Defines values for JobStatus.
+const (
+	JobStatusFailed JobStatus = "failed"
+
+	JobStatusQueued JobStatus = "queued"
+
+	JobStatusRunning JobStatus = "running"
+
+	JobStatusSucceeded JobStatus = "succeeded"
+)
+
+// Defines values for RolloutAction.
+const (
+	RolloutActionDisable RolloutAction = "disable"
+
+	RolloutActionEnable RolloutAction = "enable"
+)
+
+// Defines values for RolloutStatus.
+const (
+	RolloutStatusCompleted RolloutStatus = "completed"
+
+	RolloutStatusFailed RolloutStatus = "failed"
+
+	RolloutStatusRunning RolloutStatus = "running"
+)
+
+// Defines values for RolloutRequestAction.
+const (
+	RolloutRequestActionDisable RolloutRequestAction = "disable"
+
+	RolloutRequestActionEnable RolloutRequestAction = "enable"
+)
+
+// Defines values for RolloutResultStatus.
+const (
+	RolloutResultStatusFailed RolloutResultStatus = "failed"
+
+	RolloutResultStatusPending RolloutResultStatus = "pending"
+
+	RolloutResultStatusSkipped RolloutResultStatus = "skipped"
+
+	RolloutResultStatusSucceeded RolloutResultStatus = "succeeded"
+)
+
 // Defines values for VCTemplateVisibility.
 const (
 	VCTemplateVisibilityPrivate VCTemplateVisibility = "private"
@@ -22,6 +120,85 @@ const (
 	VCTemplateVisibilityPublic VCTemplateVisibility = "public"
 )
 
+// ApplyServiceTemplateRequest defines model for ApplyServiceTemplateRequest.
+type ApplyServiceTemplateRequest struct {
+	// URLs of endpoints to register when missing on the service provider's DID document, by key.
+	Endpoints map[string]interface{} `json:"endpoints"`
+}
+
+// A NutsAuthorizationCredential issued by a customer.
+type Authorization struct {
+	ExpirationDate *time.Time `json:"expirationDate,omitempty"`
+
+	// ID of the credential.
+	Id           string    `json:"id"`
+	IssuanceDate time.Time `json:"issuanceDate"`
+
+	// DID of the organization which is authorized.
+	Organization string                  `json:"organization"`
+	Patient      *string                 `json:"patient,omitempty"`
+	PurposeOfUse string                  `json:"purposeOfUse"`
+	Resources    []AuthorizationResource `json:"resources"`
+}
+
+// AuthorizationRequest defines model for AuthorizationRequest.
+type AuthorizationRequest struct {
+	// DID of the organization which is authorized.
+	Organization string `json:"organization"`
+
+	// Identifier of the patient the authorization concerns.
+	Patient *string `json:"patient,omitempty"`
+
+	// The purpose for which the organization is authorized, which determines the access policy.
+	PurposeOfUse string                  `json:"purposeOfUse"`
+	Resources    []AuthorizationResource `json:"resources"`
+
+	// Moment the authorization starts, which becomes the issuance date of the credential. Defaults to the moment it's issued.
+	ValidFrom *time.Time `json:"validFrom,omitempty"`
+
+	// Moment the authorization expires.
+	ValidUntil time.Time `json:"validUntil"`
+}
+
+// A resource the authorized organization may access.
+type AuthorizationResource struct {
+	// The required assurance level of the user's authentication.
+	AssuranceLevel *AuthorizationResourceAssuranceLevel `json:"assuranceLevel,omitempty"`
+
+	// The FHIR operations allowed on the resource.
+	Operations []AuthorizationResourceOperations `json:"operations"`
+
+	// Path of the resource, relative to the base URL of the service.
+	Path string `json:"path"`
+
+	// Whether the resource may only be accessed in the context of an authenticated user.
+	UserContext bool `json:"userContext"`
+}
+
+// The required assurance level of the user's authentication.
+type AuthorizationResourceAssuranceLevel string
+
+// AuthorizationResourceOperations defines model for AuthorizationResource.Operations.
+type AuthorizationResourceOperations string
+
+// A reference in a compound service which can't be resolved.
+type BrokenServiceReference struct {
+	// Key of the reference in the compound service.
+	Key string `json:"key"`
+
+	// Why the reference can't be resolved.
+	Reason string `json:"reason"`
+
+	// The reference as found in the compound service.
+	Reference string `json:"reference"`
+
+	// ID of the compound service containing the reference.
+	ServiceId string `json:"serviceId"`
+
+	// Name of the compound service containing the reference.
+	ServiceName string `json:"serviceName"`
+}
+
 // CreateSessionRequest defines model for CreateSessionRequest.
 type CreateSessionRequest struct {
 	Password string `json:"password"`
@@ -33,8 +210,27 @@ type CreateSessionResponse struct {
 	Token string `json:"token"`
 }
 
+// A credential, including its revocation status.
+type Credential struct {
+	CredentialSubject map[string]interface{} `json:"credentialSubject"`
+	ExpirationDate    *time.Time             `json:"expirationDate,omitempty"`
+	Id                string                 `json:"id"`
+	IssuanceDate      time.Time              `json:"issuanceDate"`
+	Issuer            string                 `json:"issuer"`
+
+	// Present when the credential has been revoked.
+	Revocation *CredentialRevocation `json:"revocation,omitempty"`
+
+	// The DID of the credential subject.
+	Subject string `json:"subject"`
+	Type    string `json:"type"`
+}
+
 // CredentialIssuer defines model for CredentialIssuer.
 type CredentialIssuer struct {
+	// Name of the issuer's organization according to its NutsOrganizationCredential, if it has one.
+	OrganizationName *string `json:"organizationName,omitempty"`
+
 	// A service provider is a controller of other DID documents
 	ServiceProvider ServiceProvider `json:"serviceProvider"`
 	Trusted         bool            `json:"trusted"`
@@ -45,9 +241,21 @@ type CredentialIssuers struct {
 	AdditionalProperties map[string][]CredentialIssuer `json:"-"`
 }
 
+// Present when the credential has been revoked.
+type CredentialRevocation struct {
+	Date   time.Time `json:"date"`
+	Reason *string   `json:"reason,omitempty"`
+}
+
 // Subject of a Verifiable Credential identifying the holder and expressing claims.
 type CredentialSubject map[string]interface{}
 
+// CredentialSubjectError defines model for CredentialSubjectError.
+type CredentialSubjectError struct {
+	Error  string       `json:"error"`
+	Fields []FieldError `json:"fields"`
+}
+
 // A customer object
 type Customer struct {
 	// If a VC has been issued for this customer.
@@ -69,9 +277,68 @@ type Customer struct {
 	Name string `json:"name"`
 }
 
+// Selects the customers to process. Either all customers or a list of customer IDs must be selected,
+// which can be narrowed down to customers in a city.
+type CustomerSelector struct {
+	// Select all customers.
+	All *bool `json:"all,omitempty"`
+
+	// Only select customers in this city (case insensitive).
+	City *string `json:"city,omitempty"`
+
+	// Select the customers with these IDs.
+	CustomerIds *[]int `json:"customerIds,omitempty"`
+}
+
 // CustomersResponse defines model for CustomersResponse.
 type CustomersResponse []Customer
 
+// URLs of the customer's own endpoints by key in the compound service. When empty, the service is enabled in shared mode.
+type DedicatedEndpoints map[string]interface{}
+
+// An organization in the organization directory.
+type DirectoryOrganization struct {
+	City string `json:"city"`
+
+	// DID of the organization.
+	Id string `json:"id"`
+
+	// DID of the issuer of the organization's NutsOrganizationCredential.
+	Issuer string `json:"issuer"`
+	Name   string `json:"name"`
+
+	// Types of the compound services in the organization's DID document, sorted.
+	Services []string `json:"services"`
+
+	// Whether the issuer of the NutsOrganizationCredential is trusted.
+	Trusted bool `json:"trusted"`
+}
+
+// A page of organizations in the organization directory.
+type DirectoryPage struct {
+	// When the local index of organizations which was searched was updated. Absent when the Nuts node was searched.
+	IndexUpdated  *time.Time              `json:"indexUpdated,omitempty"`
+	Organizations []DirectoryOrganization `json:"organizations"`
+	Page          int                     `json:"page"`
+	PageSize      int                     `json:"pageSize"`
+
+	// Total number of organizations matching the search, on all pages.
+	Total int `json:"total"`
+}
+
+// A difference between a customer and its DID document or credentials on the Nuts network.
+type Drift struct {
+	CustomerId  int    `json:"customerId"`
+	Description string `json:"description"`
+
+	// Whether the drift can be repaired automatically.
+	Repairable bool      `json:"repairable"`
+	Type       DriftType `json:"type"`
+}
+
+// DriftType defines model for Drift.Type.
+type DriftType string
+
 // Endpoint defines model for Endpoint.
 type Endpoint struct {
 	// Embedded struct due to allOf(#/components/schemas/EndpointID)
@@ -97,6 +364,56 @@ type EndpointProperties struct {
 // Endpoints defines model for Endpoints.
 type Endpoints []Endpoint
 
+// A credential issued by the service provider which expires soon.
+type ExpiringCredential struct {
+	ExpirationDate time.Time `json:"expirationDate"`
+	Id             string    `json:"id"`
+	IssuanceDate   time.Time `json:"issuanceDate"`
+
+	// The DID of the credential subject.
+	Subject string `json:"subject"`
+	Type    string `json:"type"`
+}
+
+// FieldError defines model for FieldError.
+type FieldError struct {
+	// JSON Pointer to the invalid field, e.g. /organization/city
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// IssueVCFromTemplateRequest defines model for IssueVCFromTemplateRequest.
+type IssueVCFromTemplateRequest struct {
+	// Internal ID of the customer the VC is issued to.
+	CustomerId int `json:"customerId"`
+
+	// Internal ID of the customer which issues the VC. If omitted, the service provider issues the VC.
+	// The service provider must be a controller of the customer's DID.
+	IssuerCustomerId *int `json:"issuerCustomerId,omitempty"`
+
+	// Values for the placeholders in the template, by placeholder name.
+	Parameters *map[string]interface{} `json:"parameters,omitempty"`
+}
+
+// The unsigned credential as it would be issued, with the problems which would prevent or spoil issuance.
+type IssueVCPreview struct {
+	// A credential according to the W3C and Nuts specs.
+	Credential VerifiableCredential `json:"credential"`
+
+	// The problems found, the field being a JSON Pointer into the credential.
+	Problems []FieldError `json:"problems"`
+
+	// Whether the credential would be published on the network.
+	PublishToNetwork bool `json:"publishToNetwork"`
+
+	// Whether no problems were found.
+	Valid      bool                     `json:"valid"`
+	Visibility IssueVCPreviewVisibility `json:"visibility"`
+}
+
+// IssueVCPreviewVisibility defines model for IssueVCPreview.Visibility.
+type IssueVCPreviewVisibility string
+
 // A request for issuing a new Verifiable Credential.
 type IssueVCRequest struct {
 	// The resolvable context of the credentialSubject as URI. If omitted, the "https://nuts.nl/credentials/v1" context is used.
@@ -109,9 +426,16 @@ type IssueVCRequest struct {
 	// rfc3339 time string until when the credential is valid.
 	ExpirationDate *string `json:"expirationDate,omitempty"`
 
-	// DID according to Nuts specification.
+	// rfc3339 time string from when the credential is valid. Defaults to the moment it's issued.
+	IssuanceDate *string `json:"issuanceDate,omitempty"`
+
+	// DID according to Nuts specification. May be left empty when issuerCustomerId is given.
 	Issuer string `json:"issuer"`
 
+	// Internal ID of the customer which issues the credential, instead of the issuer's DID.
+	// The service provider must be a controller of the customer's DID.
+	IssuerCustomerId *int `json:"issuerCustomerId,omitempty"`
+
 	// If set, the node publishes this credential to the network. This is the default behaviour.
 	// When set to false, the caller is responsible for distributing the VC to a holder. When the issuer is
 	// also the holder, it then can be used to directly create a presentation (self issued).
@@ -130,6 +454,122 @@ type IssueVCRequest struct {
 // This field is mandatory if publishToNetwork is true to prevent accidents. It defaults to "private".
 type IssueVCRequestVisibility string
 
+// A background job, e.g. a rollout or the NutsComm synchronization.
+type Job struct {
+	// Number of times the job has been started.
+	Attempts int       `json:"attempts"`
+	Created  time.Time `json:"created"`
+
+	// The error of the last failed attempt.
+	Error       *string      `json:"error,omitempty"`
+	Id          string       `json:"id"`
+	MaxAttempts int          `json:"maxAttempts"`
+	Progress    *JobProgress `json:"progress,omitempty"`
+
+	// A failed attempt is retried (status queued) until the maximum number of attempts is reached.
+	Status  JobStatus `json:"status"`
+	Type    string    `json:"type"`
+	Updated time.Time `json:"updated"`
+}
+
+// A failed attempt is retried (status queued) until the maximum number of attempts is reached.
+type JobStatus string
+
+// JobProgress defines model for JobProgress.
+type JobProgress struct {
+	Processed int `json:"processed"`
+	Total     int `json:"total"`
+}
+
+// ReconcileReport defines model for ReconcileReport.
+type ReconcileReport struct {
+	Created time.Time `json:"created"`
+
+	// Number of customers that were checked.
+	Customers int     `json:"customers"`
+	Drift     []Drift `json:"drift"`
+}
+
+// RevocationResult defines model for RevocationResult.
+type RevocationResult struct {
+	// The reason the credential couldn't be revoked.
+	Error   *string `json:"error,omitempty"`
+	Id      string  `json:"id"`
+	Revoked bool    `json:"revoked"`
+}
+
+// RevokeVCsRequest defines model for RevokeVCsRequest.
+type RevokeVCsRequest struct {
+	Ids []string `json:"ids"`
+}
+
+// The progress of enabling or disabling a service for many customers.
+type Rollout struct {
+	Action RolloutAction `json:"action"`
+	Id     string        `json:"id"`
+
+	// ID of the background job processing the rollout.
+	JobId       *string         `json:"jobId,omitempty"`
+	Results     []RolloutResult `json:"results"`
+	ServiceType string          `json:"serviceType"`
+
+	// The rollout has failed when it couldn't be completed for one or more customers.
+	Status RolloutStatus `json:"status"`
+}
+
+// RolloutAction defines model for Rollout.Action.
+type RolloutAction string
+
+// The rollout has failed when it couldn't be completed for one or more customers.
+type RolloutStatus string
+
+// RolloutRequest defines model for RolloutRequest.
+type RolloutRequest struct {
+	// Whether to enable or disable the service for the selected customers.
+	Action RolloutRequestAction `json:"action"`
+
+	// Selects the customers to process. Either all customers or a list of customer IDs must be selected,
+	// which can be narrowed down to customers in a city.
+	Selector CustomerSelector `json:"selector"`
+}
+
+// Whether to enable or disable the service for the selected customers.
+type RolloutRequestAction string
+
+// The outcome of a rollout for a single customer.
+type RolloutResult struct {
+	CustomerId int     `json:"customerId"`
+	Error      *string `json:"error,omitempty"`
+
+	// The rollout is skipped for a customer when the service already was enabled or disabled.
+	Status RolloutResultStatus `json:"status"`
+}
+
+// The rollout is skipped for a customer when the service already was enabled or disabled.
+type RolloutResultStatus string
+
+// SearchVCsRequest defines model for SearchVCsRequest.
+type SearchVCsRequest struct {
+	// The JSON-LD context of the credential type. If omitted, the "https://nuts.nl/credentials/v1" context is used.
+	Context *string `json:"@context,omitempty"`
+
+	// Whether credentials of untrusted issuers are included.
+	AllowUntrustedIssuer *bool `json:"allowUntrustedIssuer,omitempty"`
+
+	// Fields of the credentialSubject the credentials must match, e.g. { "organization": { "city": "Amster*" } }.
+	// A trailing * matches any value starting with the given value.
+	CredentialSubject *map[string]interface{} `json:"credentialSubject,omitempty"`
+
+	// DID of the issuer of the credentials.
+	Issuer *string `json:"issuer,omitempty"`
+
+	// DID of the subject of the credentials.
+	Subject *string `json:"subject,omitempty"`
+
+	// Type of the credentials.
+	Type string `json:"type"`
+}
+
 // Service defines model for Service.
 type Service struct {
 	// Embedded struct due to allOf(#/components/schemas/ServiceID)
@@ -173,17 +613,86 @@ type ServiceProvider struct {
 	Website string `json:"website"`
 }
 
+// A well-known compound service from the service catalog.
+type ServiceTemplate struct {
+	// Description of the use case of the compound service.
+	Description string                    `json:"description"`
+	Endpoints   []ServiceTemplateEndpoint `json:"endpoints"`
+
+	// Name of the compound service.
+	Name string `json:"name"`
+}
+
+// An endpoint referred to by a compound service from the service catalog.
+type ServiceTemplateEndpoint struct {
+	Description string `json:"description"`
+
+	// Key of the reference in the compound service.
+	Key string `json:"key"`
+
+	// Whether the compound service must contain this endpoint.
+	Required bool `json:"required"`
+
+	// Type of the endpoint on the service provider's DID document the reference refers to.
+	Type string `json:"type"`
+}
+
 // Services defines model for Services.
 type Services []Service
 
+// The issuers which should be trusted, per credential type.
+type TrustPolicy struct {
+	CredentialTypes []TrustPolicyCredentialType `json:"credentialTypes"`
+}
+
+// TrustPolicyChange defines model for TrustPolicyChange.
+type TrustPolicyChange struct {
+	CredentialType string `json:"credentialType"`
+	Issuer         string `json:"issuer"`
+
+	// Whether the issuer becomes trusted or untrusted.
+	Trusted bool `json:"trusted"`
+}
+
+// TrustPolicyCredentialType defines model for TrustPolicyCredentialType.
+type TrustPolicyCredentialType struct {
+	// DIDs of the trusted issuers.
+	TrustedIssuers []string `json:"trustedIssuers"`
+	Type           string   `json:"type"`
+}
+
+// TrustPolicyResult defines model for TrustPolicyResult.
+type TrustPolicyResult struct {
+	Changes []TrustPolicyChange `json:"changes"`
+	DryRun  bool                `json:"dryRun"`
+
+	// Version of the applied policy. Absent on a dry-run.
+	Version *int `json:"version,omitempty"`
+}
+
+// TrustPolicyVersion defines model for TrustPolicyVersion.
+type TrustPolicyVersion struct {
+	// Moment the policy was applied.
+	Applied time.Time `json:"applied"`
+
+	// The issuers which should be trusted, per credential type.
+	Policy  TrustPolicy `json:"policy"`
+	Version int         `json:"version"`
+}
+
 // A template for a VC to be issued
 type VCTemplate struct {
 	// JSON-LD context of the Verifiable Credential
 	Context string `json:"context"`
 
-	// Example credential subject for the Verifiable Credential
+	// Example credential subject for the Verifiable Credential. String values may contain placeholders (e.g. <name>),
+	// which are replaced when issuing a VC from the template.
 	CredentialSubject map[string]interface{} `json:"credentialSubject"`
 
+	// JSON Schema the credentialSubject must conform to when issuing a VC of this type.
+	// If omitted, the credentialSubject isn't validated.
+	CredentialSubjectSchema *map[string]interface{} `json:"credentialSubjectSchema,omitempty"`
+
 	// Whether to publish the VC on the Nuts network after issuance.
 	PublishToNetwork bool `json:"publishToNetwork"`
 
@@ -206,17 +715,44 @@ type CreateSessionJSONBody CreateSessionRequest
 // UpdateCredentialIssuerJSONBody defines parameters for UpdateCredentialIssuer.
 type UpdateCredentialIssuerJSONBody CredentialIssuer
 
+// GetExpiringCredentialsParams defines parameters for GetExpiringCredentials.
+type GetExpiringCredentialsParams struct {
+	// Number of days, defaults to the configured renewal period.
+	Days *int `json:"days,omitempty"`
+}
+
+// GetCredentialIssuersParams defines parameters for GetCredentialIssuers.
+type GetCredentialIssuersParams struct {
+	// Credential type to list the issuers of.
+	Type *string `json:"type,omitempty"`
+}
+
 // ConnectCustomerJSONBody defines parameters for ConnectCustomer.
 type ConnectCustomerJSONBody Customer
 
+// ActivateCustomersJSONBody defines parameters for ActivateCustomers.
+type ActivateCustomersJSONBody CustomerSelector
+
 // UpdateCustomerJSONBody defines parameters for UpdateCustomer.
 type UpdateCustomerJSONBody Customer
 
+// IssueAuthorizationJSONBody defines parameters for IssueAuthorization.
+type IssueAuthorizationJSONBody AuthorizationRequest
+
 // EnableCustomerServiceJSONBody defines parameters for EnableCustomerService.
 type EnableCustomerServiceJSONBody struct {
 	// The did wich contains the referenced service.
-	Did  string `json:"did"`
-	Type string `json:"type"`
+	Did string `json:"did"`
+
+	// URLs of the customer's own endpoints by key in the compound service. When empty, the service is enabled in shared mode.
+	Endpoints *DedicatedEndpoints `json:"endpoints,omitempty"`
+	Type      string              `json:"type"`
+}
+
+// UpdateCustomerServiceJSONBody defines parameters for UpdateCustomerService.
+type UpdateCustomerServiceJSONBody struct {
+	// URLs of the customer's own endpoints by key in the compound service. When empty, the service is enabled in shared mode.
+	Endpoints *DedicatedEndpoints `json:"endpoints,omitempty"`
 }
 
 // SearchOrganizationsJSONBody defines parameters for SearchOrganizations.
@@ -225,18 +761,90 @@ type SearchOrganizationsJSONBody struct {
 	Name string `json:"name"`
 }
 
+// SearchOrganizationDirectoryParams defines parameters for SearchOrganizationDirectory.
+type SearchOrganizationDirectoryParams struct {
+	// Name of the organization, matched fuzzily. When empty, all organizations match.
+	Query *string `json:"query,omitempty"`
+
+	// City of the organization, matched fuzzily. When empty, organizations in any city match.
+	City *string `json:"city,omitempty"`
+
+	// Only return organizations of which the NutsOrganizationCredential is issued by a trusted or untrusted issuer.
+	Trust *SearchOrganizationDirectoryParamsTrust `json:"trust,omitempty"`
+
+	// Only return organizations offering the compound service of this type, e.g. eOverdracht-receiver.
+	Service *string `json:"service,omitempty"`
+
+	// Page number, starting at 1. Defaults to 1.
+	Page *int `json:"page,omitempty"`
+
+	// Number of organizations per page, at most 100. Defaults to 20.
+	PageSize *int `json:"pageSize,omitempty"`
+}
+
+// SearchOrganizationDirectoryParamsTrust defines parameters for SearchOrganizationDirectory.
+type SearchOrganizationDirectoryParamsTrust string
+
 // UpdateServiceProviderJSONBody defines parameters for UpdateServiceProvider.
 type UpdateServiceProviderJSONBody ServiceProvider
 
+// ApplyServiceTemplateJSONBody defines parameters for ApplyServiceTemplate.
+type ApplyServiceTemplateJSONBody ApplyServiceTemplateRequest
+
 // RegisterEndpointJSONBody defines parameters for RegisterEndpoint.
 type RegisterEndpointJSONBody EndpointProperties
 
 // AddServiceJSONBody defines parameters for AddService.
 type AddServiceJSONBody ServiceProperties
 
+// DeleteServiceParams defines parameters for DeleteService.
+type DeleteServiceParams struct {
+	// Remove the references to this compound service from customers' DID documents.
+	Cascade *bool `json:"cascade,omitempty"`
+}
+
+// UpdateServiceJSONBody defines parameters for UpdateService.
+type UpdateServiceJSONBody ServiceProperties
+
+// StartRolloutJSONBody defines parameters for StartRollout.
+type StartRolloutJSONBody RolloutRequest
+
+// ApplyTrustPolicyJSONBody defines parameters for ApplyTrustPolicy.
+type ApplyTrustPolicyJSONBody TrustPolicy
+
+// ApplyTrustPolicyParams defines parameters for ApplyTrustPolicy.
+type ApplyTrustPolicyParams struct {
+	// If true, only the changes are returned, without applying them.
+	DryRun *bool `json:"dryRun,omitempty"`
+}
+
+// RollbackTrustPolicyParams defines parameters for RollbackTrustPolicy.
+type RollbackTrustPolicyParams struct {
+	// If true, only the changes are returned, without applying them.
+	DryRun *bool `json:"dryRun,omitempty"`
+}
+
 // IssueVCJSONBody defines parameters for IssueVC.
 type IssueVCJSONBody IssueVCRequest
 
+// PreviewVCJSONBody defines parameters for PreviewVC.
+type PreviewVCJSONBody IssueVCRequest
+
+// RevokeVCsJSONBody defines parameters for RevokeVCs.
+type RevokeVCsJSONBody RevokeVCsRequest
+
+// SearchVCsJSONBody defines parameters for SearchVCs.
+type SearchVCsJSONBody SearchVCsRequest
+
+// CreateVCTemplateJSONBody defines parameters for CreateVCTemplate.
+type CreateVCTemplateJSONBody VCTemplate
+
+// UpdateVCTemplateJSONBody defines parameters for UpdateVCTemplate.
+type UpdateVCTemplateJSONBody VCTemplate
+
+// IssueVCFromTemplateJSONBody defines parameters for IssueVCFromTemplate.
+type IssueVCFromTemplateJSONBody IssueVCFromTemplateRequest
+
 // CreateSessionJSONRequestBody defines body for CreateSession for application/json ContentType.
 type CreateSessionJSONRequestBody CreateSessionJSONBody
 
@@ -246,27 +854,66 @@ type UpdateCredentialIssuerJSONRequestBody UpdateCredentialIssuerJSONBody
 // ConnectCustomerJSONRequestBody defines body for ConnectCustomer for application/json ContentType.
 type ConnectCustomerJSONRequestBody ConnectCustomerJSONBody
 
+// ActivateCustomersJSONRequestBody defines body for ActivateCustomers for application/json ContentType.
+type ActivateCustomersJSONRequestBody ActivateCustomersJSONBody
+
 // UpdateCustomerJSONRequestBody defines body for UpdateCustomer for application/json ContentType.
 type UpdateCustomerJSONRequestBody UpdateCustomerJSONBody
 
+// IssueAuthorizationJSONRequestBody defines body for IssueAuthorization for application/json ContentType.
+type IssueAuthorizationJSONRequestBody IssueAuthorizationJSONBody
+
 // EnableCustomerServiceJSONRequestBody defines body for EnableCustomerService for application/json ContentType.
 type EnableCustomerServiceJSONRequestBody EnableCustomerServiceJSONBody
 
+// UpdateCustomerServiceJSONRequestBody defines body for UpdateCustomerService for application/json ContentType.
+type UpdateCustomerServiceJSONRequestBody UpdateCustomerServiceJSONBody
+
 // SearchOrganizationsJSONRequestBody defines body for SearchOrganizations for application/json ContentType.
 type SearchOrganizationsJSONRequestBody SearchOrganizationsJSONBody
 
 // UpdateServiceProviderJSONRequestBody defines body for UpdateServiceProvider for application/json ContentType.
 type UpdateServiceProviderJSONRequestBody UpdateServiceProviderJSONBody
 
+// ApplyServiceTemplateJSONRequestBody defines body for ApplyServiceTemplate for application/json ContentType.
+type ApplyServiceTemplateJSONRequestBody ApplyServiceTemplateJSONBody
+
 // RegisterEndpointJSONRequestBody defines body for RegisterEndpoint for application/json ContentType.
 type RegisterEndpointJSONRequestBody RegisterEndpointJSONBody
 
 // AddServiceJSONRequestBody defines body for AddService for application/json ContentType.
 type AddServiceJSONRequestBody AddServiceJSONBody
 
+// UpdateServiceJSONRequestBody defines body for UpdateService for application/json ContentType.
+type UpdateServiceJSONRequestBody UpdateServiceJSONBody
+
+// StartRolloutJSONRequestBody defines body for StartRollout for application/json ContentType.
+type StartRolloutJSONRequestBody StartRolloutJSONBody
+
+// ApplyTrustPolicyJSONRequestBody defines body for ApplyTrustPolicy for application/json ContentType.
+type ApplyTrustPolicyJSONRequestBody ApplyTrustPolicyJSONBody
+
 // IssueVCJSONRequestBody defines body for IssueVC for application/json ContentType.
 type IssueVCJSONRequestBody IssueVCJSONBody
 
+// PreviewVCJSONRequestBody defines body for PreviewVC for application/json ContentType.
+type PreviewVCJSONRequestBody PreviewVCJSONBody
+
+// RevokeVCsJSONRequestBody defines body for RevokeVCs for application/json ContentType.
+type RevokeVCsJSONRequestBody RevokeVCsJSONBody
+
+// SearchVCsJSONRequestBody defines body for SearchVCs for application/json ContentType.
+type SearchVCsJSONRequestBody SearchVCsJSONBody
+
+// CreateVCTemplateJSONRequestBody defines body for CreateVCTemplate for application/json ContentType.
+type CreateVCTemplateJSONRequestBody CreateVCTemplateJSONBody
+
+// UpdateVCTemplateJSONRequestBody defines body for UpdateVCTemplate for application/json ContentType.
+type UpdateVCTemplateJSONRequestBody UpdateVCTemplateJSONBody
+
+// IssueVCFromTemplateJSONRequestBody defines body for IssueVCFromTemplate for application/json ContentType.
+type IssueVCFromTemplateJSONRequestBody IssueVCFromTemplateJSONBody
+
 // Getter for additional properties for CredentialIssuers. Returns the specified
 // element and whether it was found
 func (a CredentialIssuers) Get(fieldName string) (value []CredentialIssuer, found bool) {
@@ -0,0 +1,379 @@
+package jobs
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nuts-foundation/nuts-registry-admin-demo/domain"
+	"github.com/sirupsen/logrus"
+	"go.etcd.io/bbolt"
+)
+
+const jobBucketName = "Jobs"
+
+// DefaultWorkers is the default number of jobs processed in parallel.
+const DefaultWorkers = 2
+
+const defaultMaxAttempts = 3
+
+// defaultRetryDelay is multiplied by the number of attempts, to back off when a job keeps failing.
+const defaultRetryDelay = 10 * time.Second
+
+// defaultRetention is how long finished jobs are kept, scheduled jobs would otherwise fill the database.
+const defaultRetention = 7 * 24 * time.Hour
+
+// pruneInterval is how often finished jobs are checked for removal.
+const pruneInterval = time.Hour
+
+var ErrNotFound = errors.New("job not found")
+
+// ErrNotRunning is returned when a job is submitted to a queue which isn't started, e.g. when using the command line.
+var ErrNotRunning = errors.New("job queue isn't running")
+
+// ProgressFunc reports the progress of a job, e.g. the number of processed customers.
+type ProgressFunc func(processed int, total int)
+
+// Handler processes a job of a certain type. When it returns an error, the job is retried until the maximum number of attempts is reached.
+type Handler func(payload json.RawMessage, progress ProgressFunc) error
+
+// record is how a job is stored, the payload is only used by the job's handler.
+type record struct {
+	Job     domain.Job      `json:"job"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+// Queue is a persistent queue of background jobs, processed by a bounded number of workers.
+// Jobs which were queued or running when the application stopped are picked up again when the queue is started.
+type Queue struct {
+	DB          *bbolt.DB
+	Workers     int
+	MaxAttempts int
+	RetryDelay  time.Duration
+	// Retention is how long jobs are kept after they succeeded or failed. If 0, they're kept forever.
+	Retention time.Duration
+
+	handlers map[string]Handler
+	queue    chan string
+	once     sync.Once
+	// running is set when the workers are started, jobs can only be queued after that.
+	running    atomic.Bool
+	pruneMutex sync.Mutex
+	lastPrune  time.Time
+}
+
+func NewQueue(db *bbolt.DB, workers int) *Queue {
+	return &Queue{
+		DB:          db,
+		Workers:     workers,
+		MaxAttempts: defaultMaxAttempts,
+		RetryDelay:  defaultRetryDelay,
+		Retention:   defaultRetention,
+		handlers:    make(map[string]Handler),
+		queue:       make(chan string),
+	}
+}
+
+// Register registers the handler for jobs of the given type. Handlers must be registered before the queue is started.
+func (q *Queue) Register(jobType string, handler Handler) {
+	q.handlers[jobType] = handler
+}
+
+// Start starts the workers and requeues the jobs which weren't finished. Only the first call has effect,
+// so unfinished jobs aren't queued twice.
+func (q *Queue) Start() error {
+	var err error
+	q.once.Do(func() {
+		err = q.start()
+	})
+	return err
+}
+
+func (q *Queue) start() error {
+	q.prune(time.Now())
+	var unfinished []string
+	jobs, err := q.List()
+	if err != nil {
+		return err
+	}
+	for _, job := range jobs {
+		if job.Status == domain.JobStatusQueued || job.Status == domain.JobStatusRunning {
+			unfinished = append(unfinished, job.Id)
+		}
+	}
+	workers := q.Workers
+	if workers < 1 {
+		workers = DefaultWorkers
+	}
+	for i := 0; i < workers; i++ {
+		go q.work()
+	}
+	q.running.Store(true)
+	for _, id := range unfinished {
+		logrus.Infof("Resuming unfinished job (id=%s)", id)
+		q.enqueue(id, 0)
+	}
+	return nil
+}
+
+// Submit stores a new job of the given type and queues it for processing.
+// It returns ErrNotRunning when the queue isn't started, since the job would never be processed.
+func (q *Queue) Submit(jobType string, payload interface{}) (*domain.Job, error) {
+	if !q.running.Load() {
+		return nil, ErrNotRunning
+	}
+	if _, ok := q.handlers[jobType]; !ok {
+		return nil, fmt.Errorf("unknown job type: %s", jobType)
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	r := record{
+		Job: domain.Job{
+			Id:          uuid.New().String(),
+			Type:        jobType,
+			Status:      domain.JobStatusQueued,
+			MaxAttempts: q.MaxAttempts,
+			Created:     now,
+			Updated:     now,
+		},
+		Payload: data,
+	}
+	if err := q.save(r); err != nil {
+		return nil, err
+	}
+	q.enqueue(r.Job.Id, 0)
+	q.prune(now)
+	return &r.Job, nil
+}
+
+// prune removes the jobs which succeeded or failed longer than the retention ago.
+// It runs at most once per pruneInterval, failures are logged.
+func (q *Queue) prune(now time.Time) {
+	if q.Retention <= 0 {
+		return
+	}
+	q.pruneMutex.Lock()
+	if !q.lastPrune.IsZero() && now.Sub(q.lastPrune) < pruneInterval {
+		q.pruneMutex.Unlock()
+		return
+	}
+	q.lastPrune = now
+	q.pruneMutex.Unlock()
+
+	removed := 0
+	err := q.DB.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte(jobBucketName))
+		if b == nil {
+			return nil
+		}
+		// Keys can't be deleted while iterating the bucket
+		var expired [][]byte
+		err := b.ForEach(func(key, data []byte) error {
+			var r record
+			if err := json.Unmarshal(data, &r); err != nil {
+				return err
+			}
+			finished := r.Job.Status == domain.JobStatusSucceeded || r.Job.Status == domain.JobStatusFailed
+			if finished && r.Job.Updated.Before(now.Add(-q.Retention)) {
+				expired = append(expired, key)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for _, key := range expired {
+			if err := b.Delete(key); err != nil {
+				return err
+			}
+		}
+		removed = len(expired)
+		return nil
+	})
+	if err != nil {
+		logrus.Warnf("Unable to remove finished jobs: %v", err)
+		return
+	}
+	if removed > 0 {
+		logrus.Debugf("Removed %d finished job(s)", removed)
+	}
+}
+
+// Get returns the job with the given ID.
+func (q *Queue) Get(id string) (*domain.Job, error) {
+	r, err := q.load(id)
+	if err != nil {
+		return nil, err
+	}
+	return &r.Job, nil
+}
+
+// List returns all jobs, most recent first.
+func (q *Queue) List() ([]domain.Job, error) {
+	result := []domain.Job{}
+	err := q.DB.View(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte(jobBucketName))
+		if b == nil {
+			return nil
+		}
+		return b.ForEach(func(_, data []byte) error {
+			var r record
+			if err := json.Unmarshal(data, &r); err != nil {
+				return err
+			}
+			result = append(result, r.Job)
+			return nil
+		})
+	})
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Created.After(result[j].Created)
+	})
+	return result, err
+}
+
+// enqueue queues the job for the workers after the delay. The queue must be running, otherwise nothing receives the job.
+func (q *Queue) enqueue(id string, delay time.Duration) {
+	// Don't block the caller when all workers are busy
+	time.AfterFunc(delay, func() {
+		q.queue <- id
+	})
+}
+
+func (q *Queue) work() {
+	for id := range q.queue {
+		q.process(id)
+	}
+}
+
+func (q *Queue) process(id string) {
+	r, err := q.update(id, func(job *domain.Job) {
+		job.Status = domain.JobStatusRunning
+		job.Attempts++
+	})
+	if err != nil {
+		logrus.Errorf("Unable to start job (id=%s): %v", id, err)
+		return
+	}
+
+	err = q.handle(*r)
+
+	_, updateErr := q.update(id, func(job *domain.Job) {
+		if err == nil {
+			job.Status = domain.JobStatusSucceeded
+			job.Error = nil
+			return
+		}
+		msg := err.Error()
+		job.Error = &msg
+		if job.Attempts < job.MaxAttempts {
+			job.Status = domain.JobStatusQueued
+		} else {
+			job.Status = domain.JobStatusFailed
+		}
+	})
+	if updateErr != nil {
+		logrus.Errorf("Unable to store job result (id=%s): %v", id, updateErr)
+		return
+	}
+	if err != nil {
+		logrus.Warnf("Job failed (id=%s, type=%s, attempt=%d/%d): %v", id, r.Job.Type, r.Job.Attempts, r.Job.MaxAttempts, err)
+		if r.Job.Attempts < r.Job.MaxAttempts {
+			q.enqueue(id, time.Duration(r.Job.Attempts)*q.RetryDelay)
+		}
+	}
+}
+
+// handle invokes the handler for the job, turning a panic into an error so the worker survives it.
+func (q *Queue) handle(r record) (err error) {
+	handler, ok := q.handlers[r.Job.Type]
+	if !ok {
+		return fmt.Errorf("unknown job type: %s", r.Job.Type)
+	}
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("job panicked: %v", recovered)
+		}
+	}()
+	return handler(r.Payload, func(processed int, total int) {
+		_, err := q.update(r.Job.Id, func(job *domain.Job) {
+			job.Progress = &domain.JobProgress{Processed: processed, Total: total}
+		})
+		if err != nil {
+			logrus.Warnf("Unable to store job progress (id=%s): %v", r.Job.Id, err)
+		}
+	})
+}
+
+func (q *Queue) load(id string) (*record, error) {
+	var result *record
+	err := q.DB.View(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte(jobBucketName))
+		if b == nil {
+			return nil
+		}
+		data := b.Get([]byte(id))
+		if data == nil {
+			return nil
+		}
+		result = &record{}
+		return json.Unmarshal(data, result)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if result == nil {
+		return nil, ErrNotFound
+	}
+	return result, nil
+}
+
+func (q *Queue) save(r record) error {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	return q.DB.Update(func(tx *bbolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists([]byte(jobBucketName))
+		if err != nil {
+			return err
+		}
+		return b.Put([]byte(r.Job.Id), data)
+	})
+}
+
+// update changes the stored job in a single transaction and returns the updated record.
+func (q *Queue) update(id string, updateFn func(job *domain.Job)) (*record, error) {
+	var result record
+	err := q.DB.Update(func(tx *bbolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists([]byte(jobBucketName))
+		if err != nil {
+			return err
+		}
+		data := b.Get([]byte(id))
+		if data == nil {
+			return ErrNotFound
+		}
+		if err := json.Unmarshal(data, &result); err != nil {
+			return err
+		}
+		updateFn(&result.Job)
+		result.Job.Updated = time.Now()
+		data, err = json.Marshal(result)
+		if err != nil {
+			return err
+		}
+		return b.Put([]byte(id), data)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
@@ -0,0 +1,214 @@
+package jobs
+
+import (
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/nuts-foundation/nuts-registry-admin-demo/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.etcd.io/bbolt"
+)
+
+const testJobType = "test"
+
+func testQueue(t *testing.T) *Queue {
+	db, err := bbolt.Open(filepath.Join(t.TempDir(), "test.db"), 0600, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+	queue := NewQueue(db, 1)
+	queue.RetryDelay = time.Millisecond
+	return queue
+}
+
+// waitForJob waits until the job is finished, and returns it.
+func waitForJob(t *testing.T, queue *Queue, id string) domain.Job {
+	var job *domain.Job
+	require.Eventually(t, func() bool {
+		var err error
+		job, err = queue.Get(id)
+		require.NoError(t, err)
+		return job.Status == domain.JobStatusSucceeded || job.Status == domain.JobStatusFailed
+	}, 5*time.Second, time.Millisecond)
+	return *job
+}
+
+func TestQueue_Submit(t *testing.T) {
+	tests := []struct {
+		name string
+		// failures is the number of attempts which fail before the job succeeds.
+		failures         int
+		panics           bool
+		expectedStatus   domain.JobStatus
+		expectedAttempts int
+		expectedErr      string
+	}{
+		{name: "succeeds", expectedStatus: domain.JobStatusSucceeded, expectedAttempts: 1},
+		{name: "succeeds after retry", failures: 2, expectedStatus: domain.JobStatusSucceeded, expectedAttempts: 3},
+		{name: "fails after all attempts", failures: 3, expectedStatus: domain.JobStatusFailed, expectedAttempts: 3, expectedErr: "failed"},
+		{name: "panics", panics: true, expectedStatus: domain.JobStatusFailed, expectedAttempts: 3, expectedErr: "job panicked: boom"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			queue := testQueue(t)
+			var attempts atomic.Int32
+			queue.Register(testJobType, func(payload json.RawMessage, progress ProgressFunc) error {
+				assert.JSONEq(t, `{"value":"payload"}`, string(payload))
+				progress(1, 2)
+				if test.panics {
+					panic("boom")
+				}
+				if int(attempts.Add(1)) <= test.failures {
+					return errors.New("failed")
+				}
+				return nil
+			})
+			require.NoError(t, queue.Start())
+
+			job, err := queue.Submit(testJobType, map[string]string{"value": "payload"})
+			require.NoError(t, err)
+			result := waitForJob(t, queue, job.Id)
+
+			assert.Equal(t, test.expectedStatus, result.Status)
+			assert.Equal(t, test.expectedAttempts, result.Attempts)
+			assert.Equal(t, &domain.JobProgress{Processed: 1, Total: 2}, result.Progress)
+			if test.expectedErr == "" {
+				assert.Nil(t, result.Error)
+			} else {
+				require.NotNil(t, result.Error)
+				assert.Equal(t, test.expectedErr, *result.Error)
+			}
+		})
+	}
+}
+
+func TestQueue_Submit_Errors(t *testing.T) {
+	t.Run("not running", func(t *testing.T) {
+		queue := testQueue(t)
+		queue.Register(testJobType, func(json.RawMessage, ProgressFunc) error { return nil })
+
+		job, err := queue.Submit(testJobType, nil)
+
+		assert.ErrorIs(t, err, ErrNotRunning)
+		assert.Nil(t, job)
+		jobs, err := queue.List()
+		require.NoError(t, err)
+		assert.Empty(t, jobs)
+	})
+	t.Run("unknown job type", func(t *testing.T) {
+		queue := testQueue(t)
+		require.NoError(t, queue.Start())
+
+		_, err := queue.Submit("unknown", nil)
+
+		assert.EqualError(t, err, "unknown job type: unknown")
+	})
+}
+
+func TestQueue_Start(t *testing.T) {
+	queue := testQueue(t)
+	var handled atomic.Int32
+	queue.Register(testJobType, func(json.RawMessage, ProgressFunc) error {
+		handled.Add(1)
+		return nil
+	})
+	// Jobs as left behind by an application which stopped while processing them
+	now := time.Now()
+	for _, job := range []domain.Job{
+		{Id: "queued", Type: testJobType, Status: domain.JobStatusQueued, MaxAttempts: 3, Created: now},
+		{Id: "running", Type: testJobType, Status: domain.JobStatusRunning, Attempts: 1, MaxAttempts: 3, Created: now},
+		{Id: "failed", Type: testJobType, Status: domain.JobStatusFailed, Attempts: 3, MaxAttempts: 3, Created: now, Updated: now},
+	} {
+		require.NoError(t, queue.save(record{Job: job}))
+	}
+
+	require.NoError(t, queue.Start())
+
+	assert.Equal(t, domain.JobStatusSucceeded, waitForJob(t, queue, "queued").Status)
+	resumed := waitForJob(t, queue, "running")
+	assert.Equal(t, domain.JobStatusSucceeded, resumed.Status)
+	assert.Equal(t, 2, resumed.Attempts)
+	failed, err := queue.Get("failed")
+	require.NoError(t, err)
+	assert.Equal(t, domain.JobStatusFailed, failed.Status)
+	assert.Equal(t, int32(2), handled.Load())
+}
+
+func TestQueue_Start_Twice(t *testing.T) {
+	queue := testQueue(t)
+	var handled atomic.Int32
+	queue.Register(testJobType, func(json.RawMessage, ProgressFunc) error {
+		handled.Add(1)
+		return nil
+	})
+	require.NoError(t, queue.save(record{Job: domain.Job{Id: "queued", Type: testJobType, Status: domain.JobStatusQueued, MaxAttempts: 3, Created: time.Now()}}))
+
+	require.NoError(t, queue.Start())
+	require.NoError(t, queue.Start())
+
+	assert.Equal(t, 1, waitForJob(t, queue, "queued").Attempts)
+	assert.Never(t, func() bool {
+		return handled.Load() > 1
+	}, 100*time.Millisecond, time.Millisecond)
+}
+
+func TestQueue_prune(t *testing.T) {
+	now := time.Now()
+	old := now.Add(-defaultRetention - time.Hour)
+	tests := []struct {
+		name     string
+		job      domain.Job
+		expected bool
+	}{
+		{name: "succeeded long ago", job: domain.Job{Status: domain.JobStatusSucceeded, Updated: old}, expected: false},
+		{name: "failed long ago", job: domain.Job{Status: domain.JobStatusFailed, Updated: old}, expected: false},
+		{name: "succeeded recently", job: domain.Job{Status: domain.JobStatusSucceeded, Updated: now.Add(-time.Hour)}, expected: true},
+		{name: "queued long ago", job: domain.Job{Status: domain.JobStatusQueued, Updated: old}, expected: true},
+		{name: "running long ago", job: domain.Job{Status: domain.JobStatusRunning, Updated: old}, expected: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			queue := testQueue(t)
+			test.job.Id = "job"
+			require.NoError(t, queue.save(record{Job: test.job}))
+
+			queue.prune(now)
+
+			_, err := queue.Get("job")
+			if test.expected {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, ErrNotFound)
+			}
+		})
+	}
+	t.Run("at most once per interval", func(t *testing.T) {
+		queue := testQueue(t)
+		queue.prune(now)
+		require.NoError(t, queue.save(record{Job: domain.Job{Id: "job", Status: domain.JobStatusSucceeded, Updated: old}}))
+
+		queue.prune(now.Add(pruneInterval / 2))
+		_, err := queue.Get("job")
+		assert.NoError(t, err)
+
+		queue.prune(now.Add(pruneInterval))
+		_, err = queue.Get("job")
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+	t.Run("kept forever without retention", func(t *testing.T) {
+		queue := testQueue(t)
+		queue.Retention = 0
+		require.NoError(t, queue.save(record{Job: domain.Job{Id: "job", Status: domain.JobStatusSucceeded, Updated: old}}))
+
+		queue.prune(now)
+
+		_, err := queue.Get("job")
+		assert.NoError(t, err)
+	})
+}
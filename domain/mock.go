@@ -0,0 +1,194 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: nodeclient.go
+
+// Package domain is a generated GoMock package.
+package domain
+
+import (
+	reflect "reflect"
+
+	go_did "github.com/nuts-foundation/go-did"
+	did "github.com/nuts-foundation/go-did/did"
+	v1 "github.com/nuts-foundation/nuts-node/didman/api/v1"
+	v10 "github.com/nuts-foundation/nuts-node/vdr/api/v1"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockVDRClient is a mock of VDRClient interface.
+type MockVDRClient struct {
+	ctrl     *gomock.Controller
+	recorder *MockVDRClientMockRecorder
+}
+
+// MockVDRClientMockRecorder is the mock recorder for MockVDRClient.
+type MockVDRClientMockRecorder struct {
+	mock *MockVDRClient
+}
+
+// NewMockVDRClient creates a new mock instance.
+func NewMockVDRClient(ctrl *gomock.Controller) *MockVDRClient {
+	mock := &MockVDRClient{ctrl: ctrl}
+	mock.recorder = &MockVDRClientMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockVDRClient) EXPECT() *MockVDRClientMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockVDRClient) Create(createRequest v10.DIDCreateRequest) (*did.Document, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", createRequest)
+	ret0, _ := ret[0].(*did.Document)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockVDRClientMockRecorder) Create(createRequest interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockVDRClient)(nil).Create), createRequest)
+}
+
+// Get mocks base method.
+func (m *MockVDRClient) Get(DID string) (*did.Document, *v10.DocumentMetadata, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", DID)
+	ret0, _ := ret[0].(*did.Document)
+	ret1, _ := ret[1].(*v10.DocumentMetadata)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockVDRClientMockRecorder) Get(DID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockVDRClient)(nil).Get), DID)
+}
+
+// MockDIDManClient is a mock of DIDManClient interface.
+type MockDIDManClient struct {
+	ctrl     *gomock.Controller
+	recorder *MockDIDManClientMockRecorder
+}
+
+// MockDIDManClientMockRecorder is the mock recorder for MockDIDManClient.
+type MockDIDManClientMockRecorder struct {
+	mock *MockDIDManClient
+}
+
+// NewMockDIDManClient creates a new mock instance.
+func NewMockDIDManClient(ctrl *gomock.Controller) *MockDIDManClient {
+	mock := &MockDIDManClient{ctrl: ctrl}
+	mock.recorder = &MockDIDManClientMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockDIDManClient) EXPECT() *MockDIDManClientMockRecorder {
+	return m.recorder
+}
+
+// AddCompoundService mocks base method.
+func (m *MockDIDManClient) AddCompoundService(did, serviceType string, references map[string]string) (*v1.CompoundService, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddCompoundService", did, serviceType, references)
+	ret0, _ := ret[0].(*v1.CompoundService)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddCompoundService indicates an expected call of AddCompoundService.
+func (mr *MockDIDManClientMockRecorder) AddCompoundService(did, serviceType, references interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddCompoundService", reflect.TypeOf((*MockDIDManClient)(nil).AddCompoundService), did, serviceType, references)
+}
+
+// AddEndpoint mocks base method.
+func (m *MockDIDManClient) AddEndpoint(did, endpointType, endpointURL string) (*v1.Endpoint, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddEndpoint", did, endpointType, endpointURL)
+	ret0, _ := ret[0].(*v1.Endpoint)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddEndpoint indicates an expected call of AddEndpoint.
+func (mr *MockDIDManClientMockRecorder) AddEndpoint(did, endpointType, endpointURL interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddEndpoint", reflect.TypeOf((*MockDIDManClient)(nil).AddEndpoint), did, endpointType, endpointURL)
+}
+
+// DeleteEndpointsByType mocks base method.
+func (m *MockDIDManClient) DeleteEndpointsByType(did, endpointType string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteEndpointsByType", did, endpointType)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteEndpointsByType indicates an expected call of DeleteEndpointsByType.
+func (mr *MockDIDManClientMockRecorder) DeleteEndpointsByType(did, endpointType interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteEndpointsByType", reflect.TypeOf((*MockDIDManClient)(nil).DeleteEndpointsByType), did, endpointType)
+}
+
+// DeleteService mocks base method.
+func (m *MockDIDManClient) DeleteService(id go_did.URI) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteService", id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteService indicates an expected call of DeleteService.
+func (mr *MockDIDManClientMockRecorder) DeleteService(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteService", reflect.TypeOf((*MockDIDManClient)(nil).DeleteService), id)
+}
+
+// GetCompoundServices mocks base method.
+func (m *MockDIDManClient) GetCompoundServices(did string) ([]v1.CompoundService, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCompoundServices", did)
+	ret0, _ := ret[0].([]v1.CompoundService)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCompoundServices indicates an expected call of GetCompoundServices.
+func (mr *MockDIDManClientMockRecorder) GetCompoundServices(did interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCompoundServices", reflect.TypeOf((*MockDIDManClient)(nil).GetCompoundServices), did)
+}
+
+// GetContactInformation mocks base method.
+func (m *MockDIDManClient) GetContactInformation(did string) (*v1.ContactInformation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetContactInformation", did)
+	ret0, _ := ret[0].(*v1.ContactInformation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetContactInformation indicates an expected call of GetContactInformation.
+func (mr *MockDIDManClientMockRecorder) GetContactInformation(did interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetContactInformation", reflect.TypeOf((*MockDIDManClient)(nil).GetContactInformation), did)
+}
+
+// UpdateContactInformation mocks base method.
+func (m *MockDIDManClient) UpdateContactInformation(did string, information v1.ContactInformation) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateContactInformation", did, information)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateContactInformation indicates an expected call of UpdateContactInformation.
+func (mr *MockDIDManClientMockRecorder) UpdateContactInformation(did, information interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateContactInformation", reflect.TypeOf((*MockDIDManClient)(nil).UpdateContactInformation), did, information)
+}
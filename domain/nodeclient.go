@@ -0,0 +1,130 @@
+package domain
+
+import (
+	"sync/atomic"
+	"time"
+
+	ssi "github.com/nuts-foundation/go-did"
+	"github.com/nuts-foundation/go-did/did"
+	"github.com/nuts-foundation/nuts-node/core"
+	didmanAPI "github.com/nuts-foundation/nuts-node/didman/api/v1"
+	vdrAPI "github.com/nuts-foundation/nuts-node/vdr/api/v1"
+)
+
+//go:generate mockgen -destination=mock.go -package=domain -source=nodeclient.go
+
+// VDRClient contains the calls to the VDR API of the Nuts node.
+type VDRClient interface {
+	Create(createRequest vdrAPI.DIDCreateRequest) (*did.Document, error)
+	Get(DID string) (*did.Document, *vdrAPI.DocumentMetadata, error)
+}
+
+// DIDManClient contains the calls to the DIDMan API of the Nuts node.
+type DIDManClient interface {
+	AddEndpoint(did, endpointType, endpointURL string) (*didmanAPI.Endpoint, error)
+	DeleteEndpointsByType(did, endpointType string) error
+	GetCompoundServices(did string) ([]didmanAPI.CompoundService, error)
+	AddCompoundService(did, serviceType string, references map[string]string) (*didmanAPI.CompoundService, error)
+	DeleteService(id ssi.URI) error
+	UpdateContactInformation(did string, information didmanAPI.ContactInformation) error
+	GetContactInformation(did string) (*didmanAPI.ContactInformation, error)
+}
+
+// NodeClients creates the clients of the Nuts node APIs. The timeout can be changed while running,
+// which applies to every call made after the change.
+type NodeClients struct {
+	address        string
+	timeout        atomic.Int64
+	tokenGenerator core.AuthorizationTokenGenerator
+}
+
+// NewNodeClients returns the clients of the Nuts node at the given address.
+func NewNodeClients(address string, timeout time.Duration, tokenGenerator core.AuthorizationTokenGenerator) *NodeClients {
+	result := &NodeClients{address: address, tokenGenerator: tokenGenerator}
+	result.SetTimeout(timeout)
+	return result
+}
+
+// Timeout returns the timeout of calls to the Nuts node.
+func (c *NodeClients) Timeout() time.Duration {
+	return time.Duration(c.timeout.Load())
+}
+
+// SetTimeout changes the timeout of calls to the Nuts node.
+func (c *NodeClients) SetTimeout(timeout time.Duration) {
+	c.timeout.Store(int64(timeout))
+}
+
+// ClientConfig returns the config to create a client of the Nuts node with, using the current timeout.
+func (c *NodeClients) ClientConfig() core.ClientConfig {
+	return core.ClientConfig{Address: c.address, Timeout: c.Timeout()}
+}
+
+// TokenGenerator returns the generator of the tokens to authenticate to the Nuts node with.
+func (c *NodeClients) TokenGenerator() core.AuthorizationTokenGenerator {
+	return c.tokenGenerator
+}
+
+// VDR returns the client of the VDR API.
+func (c *NodeClients) VDR() VDRClient {
+	return vdrClient{clients: c}
+}
+
+// DIDMan returns the client of the DIDMan API.
+func (c *NodeClients) DIDMan() DIDManClient {
+	return didmanClient{clients: c}
+}
+
+// vdrClient creates a client for every call, so it uses the timeout at the time of the call.
+type vdrClient struct {
+	clients *NodeClients
+}
+
+func (v vdrClient) client() vdrAPI.HTTPClient {
+	return vdrAPI.HTTPClient{ClientConfig: v.clients.ClientConfig(), TokenGenerator: v.clients.tokenGenerator}
+}
+
+func (v vdrClient) Create(createRequest vdrAPI.DIDCreateRequest) (*did.Document, error) {
+	return v.client().Create(createRequest)
+}
+
+func (v vdrClient) Get(DID string) (*did.Document, *vdrAPI.DocumentMetadata, error) {
+	return v.client().Get(DID)
+}
+
+// didmanClient creates a client for every call, so it uses the timeout at the time of the call.
+type didmanClient struct {
+	clients *NodeClients
+}
+
+func (d didmanClient) client() didmanAPI.HTTPClient {
+	return didmanAPI.HTTPClient{ClientConfig: d.clients.ClientConfig(), TokenGenerator: d.clients.tokenGenerator}
+}
+
+func (d didmanClient) AddEndpoint(did, endpointType, endpointURL string) (*didmanAPI.Endpoint, error) {
+	return d.client().AddEndpoint(did, endpointType, endpointURL)
+}
+
+func (d didmanClient) DeleteEndpointsByType(did, endpointType string) error {
+	return d.client().DeleteEndpointsByType(did, endpointType)
+}
+
+func (d didmanClient) GetCompoundServices(did string) ([]didmanAPI.CompoundService, error) {
+	return d.client().GetCompoundServices(did)
+}
+
+func (d didmanClient) AddCompoundService(did, serviceType string, references map[string]string) (*didmanAPI.CompoundService, error) {
+	return d.client().AddCompoundService(did, serviceType, references)
+}
+
+func (d didmanClient) DeleteService(id ssi.URI) error {
+	return d.client().DeleteService(id)
+}
+
+func (d didmanClient) UpdateContactInformation(did string, information didmanAPI.ContactInformation) error {
+	return d.client().UpdateContactInformation(did, information)
+}
+
+func (d didmanClient) GetContactInformation(did string) (*didmanAPI.ContactInformation, error) {
+	return d.client().GetContactInformation(did)
+}
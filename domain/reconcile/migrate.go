@@ -0,0 +1,68 @@
+package reconcile
+
+import (
+	"fmt"
+
+	"github.com/nuts-foundation/nuts-registry-admin-demo/domain"
+	"github.com/sirupsen/logrus"
+	"go.etcd.io/bbolt"
+)
+
+const migrationBucketName = "Migrations"
+
+// activeMigration is the key of the migration which stores whether customers are active.
+const activeMigration = "customer-active"
+
+// MigrateActive stores whether customers are active, based on the NutsOrganizationCredentials issued to them.
+// Customers stored before the reconciler existed don't have this stored, since it was derived from their credentials,
+// so they would appear inactive. It's done once, when it fails it's done again on the next start.
+func (s *Service) MigrateActive(db *bbolt.DB) error {
+	migrated := false
+	err := db.View(func(tx *bbolt.Tx) error {
+		if b := tx.Bucket([]byte(migrationBucketName)); b != nil {
+			migrated = b.Get([]byte(activeMigration)) != nil
+		}
+		return nil
+	})
+	if err != nil || migrated {
+		return err
+	}
+
+	allCustomers, err := s.CustomerService.Repository.All()
+	if err != nil {
+		return err
+	}
+	expected, err := s.expectedState()
+	if err != nil {
+		return err
+	}
+	for _, customer := range allCustomers {
+		if customer.Did == nil {
+			continue
+		}
+		allCredentials, err := s.CredentialService.GetOrganizationCredentials(customer)
+		if err != nil {
+			return fmt.Errorf("unable to fetch credentials of customer (id=%d): %w", customer.Id, err)
+		}
+		active := len(issuedBy(allCredentials, expected.issuer)) > 0
+		if active == customer.Active {
+			continue
+		}
+		_, err = s.CustomerService.Repository.Update(customer.Id, func(c domain.Customer) (*domain.Customer, error) {
+			c.Active = active
+			return &c, nil
+		})
+		if err != nil {
+			return err
+		}
+		logrus.Infof("Stored whether customer is active (id=%d, active=%t)", customer.Id, active)
+	}
+
+	return db.Update(func(tx *bbolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists([]byte(migrationBucketName))
+		if err != nil {
+			return err
+		}
+		return b.Put([]byte(activeMigration), []byte("done"))
+	})
+}
@@ -0,0 +1,280 @@
+package reconcile
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/nuts-foundation/go-did/did"
+	"github.com/nuts-foundation/nuts-registry-admin-demo/domain"
+	"github.com/nuts-foundation/nuts-registry-admin-demo/domain/credentials"
+	"github.com/nuts-foundation/nuts-registry-admin-demo/domain/customers"
+	"github.com/nuts-foundation/nuts-registry-admin-demo/domain/jobs"
+	"github.com/nuts-foundation/nuts-registry-admin-demo/domain/sp"
+	"github.com/sirupsen/logrus"
+)
+
+// RepairJobType is the type of the job which repairs the drift between the customers and the Nuts network.
+const RepairJobType = "reconcile-repair"
+
+// Service compares the customers with their DID documents and NutsOrganizationCredentials on the Nuts network.
+type Service struct {
+	CustomerService   customers.Service
+	CredentialService credentials.Service
+	SPService         sp.Service
+	Jobs              *jobs.Queue
+}
+
+// NewService creates the reconcile service and registers the repair job handler on the job queue.
+func NewService(customerService customers.Service, credentialService credentials.Service, spService sp.Service, jobQueue *jobs.Queue) *Service {
+	service := &Service{
+		CustomerService:   customerService,
+		CredentialService: credentialService,
+		SPService:         spService,
+		Jobs:              jobQueue,
+	}
+	jobQueue.Register(RepairJobType, service.handleRepairJob)
+	return service
+}
+
+// Schedule periodically checks for drift. When autoRepair is set, a repair job is submitted instead,
+// which repairs the drift it finds.
+func (s *Service) Schedule(interval time.Duration, autoRepair bool) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if autoRepair {
+				if _, err := s.Repair(); err != nil {
+					logrus.Errorf("Unable to submit reconcile repair job: %v", err)
+				}
+				continue
+			}
+			report, err := s.Check()
+			if err != nil {
+				logrus.Errorf("Unable to check for drift: %v", err)
+				continue
+			}
+			if len(report.Drift) > 0 {
+				logrus.Warnf("Customers have drifted from the Nuts network (customers=%d, drift=%d)", report.Customers, len(report.Drift))
+			}
+		}
+	}()
+}
+
+// Repair submits a job which repairs all repairable drift.
+func (s *Service) Repair() (*domain.Job, error) {
+	return s.Jobs.Submit(RepairJobType, struct{}{})
+}
+
+// Check compares every customer connected to a DID with its DID document and NutsOrganizationCredentials.
+func (s *Service) Check() (*domain.ReconcileReport, error) {
+	allCustomers, err := s.CustomerService.Repository.All()
+	if err != nil {
+		return nil, err
+	}
+	expected, err := s.expectedState()
+	if err != nil {
+		return nil, err
+	}
+
+	report := domain.ReconcileReport{
+		Created: time.Now(),
+		Drift:   []domain.Drift{},
+	}
+	for _, customer := range allCustomers {
+		if customer.Did == nil {
+			// Not connected to a DID, so there's nothing on the network to compare with
+			continue
+		}
+		report.Customers++
+		drift, err := s.checkCustomer(customer, expected)
+		if err != nil {
+			return nil, fmt.Errorf("unable to check customer (id=%d): %w", customer.Id, err)
+		}
+		report.Drift = append(report.Drift, drift...)
+	}
+	return &report, nil
+}
+
+// expectedState describes what the service provider expects to find on the customers' DID documents and credentials.
+type expectedState struct {
+	// issuer is the DID of the service provider, which issues the customers' NutsOrganizationCredentials.
+	issuer string
+	// nutsCommReference is the reference to the service provider's NutsComm service.
+	// It's empty when the service provider doesn't have a resolvable NutsComm service.
+	nutsCommReference string
+}
+
+func (s *Service) expectedState() (expectedState, error) {
+	result := expectedState{}
+	serviceProvider, err := s.SPService.Get()
+	if err != nil {
+		return result, err
+	}
+	if serviceProvider == nil {
+		return result, nil
+	}
+	result.issuer = serviceProvider.Id
+	spDocument, _, err := s.CustomerService.VDRClient.Get(serviceProvider.Id)
+	if err != nil {
+		return result, domain.UnwrapAPIError(err)
+	}
+	if _, _, err := spDocument.ResolveEndpointURL(domain.NutsCommService); err != nil {
+		// No (valid) NutsComm service, so customers can't refer to it
+		return result, nil
+	}
+	result.nutsCommReference, err = customers.ServiceReference(serviceProvider.Id, domain.NutsCommService)
+	return result, err
+}
+
+func (s *Service) checkCustomer(customer domain.Customer, expected expectedState) ([]domain.Drift, error) {
+	var result []domain.Drift
+	document, _, err := s.CustomerService.VDRClient.Get(*customer.Did)
+	if err != nil {
+		result = append(result, domain.Drift{
+			CustomerId:  customer.Id,
+			Type:        domain.DriftTypeDidUnresolvable,
+			Description: fmt.Sprintf("DID document can't be resolved: %s", domain.UnwrapAPIError(err)),
+		})
+	} else if drift := checkNutsCommService(customer, *document, expected); drift != nil {
+		result = append(result, *drift)
+	}
+
+	credentialDrift, err := s.checkCredentials(customer, expected)
+	if err != nil {
+		return nil, err
+	}
+	if credentialDrift != nil {
+		result = append(result, *credentialDrift)
+	}
+	return result, nil
+}
+
+// checkNutsCommService checks whether the customer's DID document refers to the service provider's NutsComm service.
+func checkNutsCommService(customer domain.Customer, document did.Document, expected expectedState) *domain.Drift {
+	if len(expected.nutsCommReference) == 0 {
+		return nil
+	}
+	for _, service := range document.Service {
+		if service.Type != domain.NutsCommService {
+			continue
+		}
+		var reference string
+		_ = service.UnmarshalServiceEndpoint(&reference)
+		if reference == expected.nutsCommReference {
+			return nil
+		}
+		return &domain.Drift{
+			CustomerId:  customer.Id,
+			Type:        domain.DriftTypeNutscommMismatch,
+			Description: fmt.Sprintf("NutsComm service refers to %s instead of %s", reference, expected.nutsCommReference),
+			Repairable:  true,
+		}
+	}
+	return &domain.Drift{
+		CustomerId:  customer.Id,
+		Type:        domain.DriftTypeNutscommMissing,
+		Description: "DID document doesn't contain a NutsComm service",
+		Repairable:  true,
+	}
+}
+
+// checkCredentials checks whether the NutsOrganizationCredentials issued by the service provider match the customer.
+func (s *Service) checkCredentials(customer domain.Customer, expected expectedState) (*domain.Drift, error) {
+	allCredentials, err := s.CredentialService.GetOrganizationCredentials(customer)
+	if err != nil {
+		return nil, err
+	}
+	return credentialDrift(customer, issuedBy(allCredentials, expected.issuer), expected), nil
+}
+
+// issuedBy returns the credentials issued by the given issuer. When the issuer is unknown, all credentials are returned.
+func issuedBy(allCredentials []domain.OrganizationConceptCredential, issuer string) []domain.OrganizationConceptCredential {
+	var result []domain.OrganizationConceptCredential
+	for _, curr := range allCredentials {
+		if len(issuer) == 0 || curr.Issuer == issuer {
+			result = append(result, curr)
+		}
+	}
+	return result
+}
+
+// credentialDrift compares the credentials issued to the customer with whether the customer is active.
+// Credentials are only re-issued automatically, never revoked: a customer which has credentials but isn't active is reported only.
+func credentialDrift(customer domain.Customer, issued []domain.OrganizationConceptCredential, expected expectedState) *domain.Drift {
+	drift := domain.Drift{CustomerId: customer.Id, Repairable: customer.City != nil && len(expected.issuer) > 0}
+	switch {
+	case !customer.Active && len(issued) > 0:
+		drift.Type = domain.DriftTypeCredentialUnexpected
+		drift.Description = fmt.Sprintf("customer isn't active, but has %d NutsOrganizationCredential(s)", len(issued))
+		drift.Repairable = false
+	case !customer.Active:
+		return nil
+	case len(issued) == 0:
+		drift.Type = domain.DriftTypeCredentialMissing
+		drift.Description = "customer is active, but has no NutsOrganizationCredential"
+	case len(issued) > 1:
+		drift.Type = domain.DriftTypeCredentialOutdated
+		drift.Description = fmt.Sprintf("customer has %d NutsOrganizationCredentials instead of 1", len(issued))
+	case customer.City == nil || issued[0].Organization.Name != customer.Name || issued[0].Organization.City != *customer.City:
+		drift.Type = domain.DriftTypeCredentialOutdated
+		drift.Description = fmt.Sprintf("NutsOrganizationCredential contains %s (%s), which doesn't match the customer", issued[0].Organization.Name, issued[0].Organization.City)
+	default:
+		return nil
+	}
+	return &drift
+}
+
+// handleRepairJob checks for drift and repairs all repairable drift.
+// It returns an error when drift couldn't be repaired, so the job is retried.
+func (s *Service) handleRepairJob(_ json.RawMessage, progress jobs.ProgressFunc) error {
+	report, err := s.Check()
+	if err != nil {
+		return err
+	}
+	var repairable []domain.Drift
+	for _, drift := range report.Drift {
+		if drift.Repairable {
+			repairable = append(repairable, drift)
+		}
+	}
+
+	failures := 0
+	for i, drift := range repairable {
+		if err := s.repair(drift); err != nil {
+			logrus.Warnf("Unable to repair drift (customer=%d, type=%s): %v", drift.CustomerId, drift.Type, err)
+			failures++
+		} else {
+			logrus.Infof("Repaired drift (customer=%d, type=%s)", drift.CustomerId, drift.Type)
+		}
+		progress(i+1, len(repairable))
+	}
+	if failures > 0 {
+		return fmt.Errorf("unable to repair %d of %d drifts", failures, len(repairable))
+	}
+	return nil
+}
+
+func (s *Service) repair(drift domain.Drift) error {
+	customer, err := s.CustomerService.Repository.FindByID(drift.CustomerId)
+	if err != nil {
+		return err
+	}
+	switch drift.Type {
+	case domain.DriftTypeNutscommMismatch, domain.DriftTypeNutscommMissing:
+		serviceProvider, err := s.SPService.Get()
+		if err != nil {
+			return err
+		}
+		if drift.Type == domain.DriftTypeNutscommMismatch {
+			if err := s.CustomerService.DIDManClient.DeleteEndpointsByType(*customer.Did, domain.NutsCommService); err != nil {
+				return fmt.Errorf("unable to remove NutsComm service from DID Document: %w", err)
+			}
+		}
+		return s.CustomerService.RegisterNutsCommService(customer.Id, serviceProvider.Id)
+	case domain.DriftTypeCredentialMissing, domain.DriftTypeCredentialOutdated:
+		return s.CredentialService.ManageNutsOrgCredential(*customer, true)
+	}
+	return fmt.Errorf("drift can't be repaired (type=%s)", drift.Type)
+}
@@ -0,0 +1,223 @@
+package reconcile
+
+import (
+	"path/filepath"
+	"testing"
+
+	ssi "github.com/nuts-foundation/go-did"
+	"github.com/nuts-foundation/go-did/did"
+	vdrAPI "github.com/nuts-foundation/nuts-node/vdr/api/v1"
+	"github.com/nuts-foundation/nuts-registry-admin-demo/domain"
+	"github.com/nuts-foundation/nuts-registry-admin-demo/domain/customers"
+	"github.com/nuts-foundation/nuts-registry-admin-demo/domain/sp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.etcd.io/bbolt"
+	"go.uber.org/mock/gomock"
+)
+
+const testSPDID = "did:nuts:sp"
+
+func stringPtr(value string) *string {
+	return &value
+}
+
+func testDB(t *testing.T) *bbolt.DB {
+	db, err := bbolt.Open(filepath.Join(t.TempDir(), "test.db"), 0600, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+	return db
+}
+
+func organizationCredential(issuer string, name string, city string) domain.OrganizationConceptCredential {
+	return domain.OrganizationConceptCredential{Issuer: issuer, Organization: domain.Organization{Name: name, City: city}}
+}
+
+func TestCredentialDrift(t *testing.T) {
+	active := domain.Customer{Id: 1, Name: "Care", City: stringPtr("Utrecht"), Did: stringPtr("did:nuts:1"), Active: true}
+	inactive := domain.Customer{Id: 1, Name: "Care", City: stringPtr("Utrecht"), Did: stringPtr("did:nuts:1")}
+	withoutCity := domain.Customer{Id: 1, Name: "Care", Did: stringPtr("did:nuts:1"), Active: true}
+	matching := organizationCredential(testSPDID, "Care", "Utrecht")
+	expected := expectedState{issuer: testSPDID}
+	tests := []struct {
+		name               string
+		customer           domain.Customer
+		issued             []domain.OrganizationConceptCredential
+		expected           expectedState
+		expectedType       domain.DriftType
+		expectedRepairable bool
+	}{
+		{name: "active with matching credential", customer: active, issued: []domain.OrganizationConceptCredential{matching}, expected: expected},
+		{name: "inactive without credential", customer: inactive, expected: expected},
+		{name: "inactive with credential", customer: inactive, issued: []domain.OrganizationConceptCredential{matching}, expected: expected, expectedType: domain.DriftTypeCredentialUnexpected},
+		{name: "active without credential", customer: active, expected: expected, expectedType: domain.DriftTypeCredentialMissing, expectedRepairable: true},
+		{
+			name:               "multiple credentials",
+			customer:           active,
+			issued:             []domain.OrganizationConceptCredential{matching, matching},
+			expected:           expected,
+			expectedType:       domain.DriftTypeCredentialOutdated,
+			expectedRepairable: true,
+		},
+		{
+			name:               "name changed",
+			customer:           active,
+			issued:             []domain.OrganizationConceptCredential{organizationCredential(testSPDID, "Old", "Utrecht")},
+			expected:           expected,
+			expectedType:       domain.DriftTypeCredentialOutdated,
+			expectedRepairable: true,
+		},
+		{
+			name:         "customer without city can't be repaired",
+			customer:     withoutCity,
+			issued:       []domain.OrganizationConceptCredential{matching},
+			expected:     expected,
+			expectedType: domain.DriftTypeCredentialOutdated,
+		},
+		{name: "without service provider it can't be repaired", customer: active, expectedType: domain.DriftTypeCredentialMissing},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			drift := credentialDrift(test.customer, test.issued, test.expected)
+
+			if len(test.expectedType) == 0 {
+				assert.Nil(t, drift)
+				return
+			}
+			require.NotNil(t, drift)
+			assert.Equal(t, test.customer.Id, drift.CustomerId)
+			assert.Equal(t, test.expectedType, drift.Type)
+			assert.Equal(t, test.expectedRepairable, drift.Repairable)
+		})
+	}
+}
+
+func TestIssuedBy(t *testing.T) {
+	bySP := organizationCredential(testSPDID, "Care", "Utrecht")
+	byOther := organizationCredential("did:nuts:other", "Care", "Utrecht")
+
+	assert.Equal(t, []domain.OrganizationConceptCredential{bySP}, issuedBy([]domain.OrganizationConceptCredential{bySP, byOther}, testSPDID))
+	assert.Equal(t, []domain.OrganizationConceptCredential{bySP, byOther}, issuedBy([]domain.OrganizationConceptCredential{bySP, byOther}, ""))
+	assert.Empty(t, issuedBy(nil, testSPDID))
+}
+
+func TestCheckNutsCommService(t *testing.T) {
+	customer := domain.Customer{Id: 1, Did: stringPtr("did:nuts:1")}
+	reference := testSPDID + "/serviceEndpoint?type=NutsComm"
+	expected := expectedState{issuer: testSPDID, nutsCommReference: reference}
+	nutsComm := func(endpoint string) did.Service {
+		return did.Service{ID: ssi.MustParseURI("did:nuts:1#1"), Type: domain.NutsCommService, ServiceEndpoint: endpoint}
+	}
+	tests := []struct {
+		name               string
+		services           []did.Service
+		expected           expectedState
+		expectedType       domain.DriftType
+		expectedRepairable bool
+	}{
+		{name: "refers to the service provider", services: []did.Service{nutsComm(reference)}, expected: expected},
+		{name: "missing", expected: expected, expectedType: domain.DriftTypeNutscommMissing, expectedRepairable: true},
+		{name: "refers to another service", services: []did.Service{nutsComm("did:nuts:other/serviceEndpoint?type=NutsComm")}, expected: expected, expectedType: domain.DriftTypeNutscommMismatch, expectedRepairable: true},
+		{name: "service provider has no NutsComm service", expected: expectedState{issuer: testSPDID}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			drift := checkNutsCommService(customer, did.Document{ID: did.MustParseDID("did:nuts:1"), Service: test.services}, test.expected)
+
+			if len(test.expectedType) == 0 {
+				assert.Nil(t, drift)
+				return
+			}
+			require.NotNil(t, drift)
+			assert.Equal(t, test.expectedType, drift.Type)
+			assert.Equal(t, test.expectedRepairable, drift.Repairable)
+		})
+	}
+}
+
+// testService returns a reconcile service for the given customers, of which the service provider has a NutsComm service when nutsComm is set.
+func testService(t *testing.T, spRegistered bool, nutsComm bool, allCustomers ...domain.Customer) *Service {
+	ctrl := gomock.NewController(t)
+	customerRepository := customers.NewFlatFileRepository(filepath.Join(t.TempDir(), "customers.json"))
+	for _, customer := range allCustomers {
+		_, err := customerRepository.NewCustomer(customer)
+		require.NoError(t, err)
+	}
+	vdrClient := domain.NewMockVDRClient(ctrl)
+	vdrClient.EXPECT().Get(testSPDID).DoAndReturn(func(id string) (*did.Document, *vdrAPI.DocumentMetadata, error) {
+		document := &did.Document{ID: did.MustParseDID(id)}
+		if nutsComm {
+			document.Service = []did.Service{{ID: ssi.MustParseURI(id + "#1"), Type: domain.NutsCommService, ServiceEndpoint: "grpc://nuts.example.com:5555"}}
+		}
+		return document, nil, nil
+	}).AnyTimes()
+	didmanClient := domain.NewMockDIDManClient(ctrl)
+	didmanClient.EXPECT().GetContactInformation(testSPDID).Return(nil, nil).AnyTimes()
+	spRepository := sp.NewMockRepository(ctrl)
+	if spRegistered {
+		spDID := did.MustParseDID(testSPDID)
+		spRepository.EXPECT().Get().Return(&spDID, nil).AnyTimes()
+		spRepository.EXPECT().Set(testSPDID).Return(nil).AnyTimes()
+	} else {
+		spRepository.EXPECT().Get().Return(nil, nil).AnyTimes()
+	}
+	return &Service{
+		CustomerService: customers.Service{Repository: customerRepository, VDRClient: vdrClient, DIDManClient: didmanClient},
+		SPService:       sp.Service{Repository: spRepository, VDRClient: vdrClient, DIDManClient: didmanClient},
+	}
+}
+
+func TestService_expectedState(t *testing.T) {
+	tests := []struct {
+		name         string
+		spRegistered bool
+		nutsComm     bool
+		expected     expectedState
+	}{
+		{name: "service provider with NutsComm service", spRegistered: true, nutsComm: true, expected: expectedState{issuer: testSPDID, nutsCommReference: testSPDID + "/serviceEndpoint?type=NutsComm"}},
+		{name: "service provider without NutsComm service", spRegistered: true, expected: expectedState{issuer: testSPDID}},
+		{name: "no service provider"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			expected, err := testService(t, test.spRegistered, test.nutsComm).expectedState()
+
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, expected)
+		})
+	}
+}
+
+func TestService_MigrateActive(t *testing.T) {
+	migrated := func(t *testing.T, db *bbolt.DB) bool {
+		var result bool
+		require.NoError(t, db.View(func(tx *bbolt.Tx) error {
+			b := tx.Bucket([]byte(migrationBucketName))
+			result = b != nil && b.Get([]byte(activeMigration)) != nil
+			return nil
+		}))
+		return result
+	}
+
+	t.Run("customers without DID are skipped", func(t *testing.T) {
+		db := testDB(t)
+		service := testService(t, true, true, domain.Customer{Id: 1, Name: "Not connected"})
+
+		require.NoError(t, service.MigrateActive(db))
+
+		assert.True(t, migrated(t, db))
+		customer, err := service.CustomerService.Repository.FindByID(1)
+		require.NoError(t, err)
+		assert.False(t, customer.Active)
+	})
+	t.Run("done once", func(t *testing.T) {
+		db := testDB(t)
+		require.NoError(t, testService(t, true, true).MigrateActive(db))
+		// Without customer repository, the migration would panic when it were done again
+		service := &Service{}
+
+		assert.NoError(t, service.MigrateActive(db))
+	})
+}
@@ -0,0 +1,55 @@
+package rollout
+
+import (
+	"encoding/json"
+
+	"github.com/nuts-foundation/nuts-registry-admin-demo/domain"
+	"go.etcd.io/bbolt"
+)
+
+const rolloutBucketName = "Rollouts"
+
+type Repository interface {
+	// Get returns the rollout with the given ID. Returns nil when it doesn't exist.
+	Get(id string) (*domain.Rollout, error)
+	Save(rollout domain.Rollout) error
+}
+
+type bboltRepository struct {
+	DB *bbolt.DB
+}
+
+func NewBBoltRepository(db *bbolt.DB) Repository {
+	return &bboltRepository{DB: db}
+}
+
+func (b bboltRepository) Get(id string) (*domain.Rollout, error) {
+	var result *domain.Rollout
+	err := b.DB.View(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte(rolloutBucketName))
+		if b == nil {
+			return nil
+		}
+		data := b.Get([]byte(id))
+		if data == nil {
+			return nil
+		}
+		result = &domain.Rollout{}
+		return json.Unmarshal(data, result)
+	})
+	return result, err
+}
+
+func (b bboltRepository) Save(rollout domain.Rollout) error {
+	data, err := json.Marshal(rollout)
+	if err != nil {
+		return err
+	}
+	return b.DB.Update(func(tx *bbolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists([]byte(rolloutBucketName))
+		if err != nil {
+			return err
+		}
+		return b.Put([]byte(rollout.Id), data)
+	})
+}
@@ -0,0 +1,307 @@
+package rollout
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/nuts-foundation/nuts-registry-admin-demo/domain"
+	"github.com/nuts-foundation/nuts-registry-admin-demo/domain/customers"
+	"github.com/nuts-foundation/nuts-registry-admin-demo/domain/jobs"
+	"github.com/nuts-foundation/nuts-registry-admin-demo/domain/sp"
+	"github.com/sirupsen/logrus"
+)
+
+// defaultConcurrency is the number of customers processed in parallel by a rollout.
+const defaultConcurrency = 5
+
+// JobType is the type of the job which processes a rollout.
+const JobType = "rollout"
+
+var ErrNotFound = errors.New("rollout not found")
+
+var ErrRunning = errors.New("rollout is still running")
+
+var ErrInvalidRequest = errors.New("invalid rollout request")
+
+// jobPayload is the payload of a rollout job.
+type jobPayload struct {
+	RolloutID string `json:"rolloutId"`
+}
+
+// Service enables or disables a service for many customers at once, using background jobs.
+type Service struct {
+	Repository      Repository
+	CustomerService customers.Service
+	SPService       sp.Service
+	Jobs            *jobs.Queue
+	// Concurrency is the number of customers processed in parallel by a rollout.
+	Concurrency int
+}
+
+// NewService creates the rollout service and registers the rollout job handler on the job queue.
+func NewService(repository Repository, customerService customers.Service, spService sp.Service, jobQueue *jobs.Queue) *Service {
+	service := &Service{
+		Repository:      repository,
+		CustomerService: customerService,
+		SPService:       spService,
+		Jobs:            jobQueue,
+		Concurrency:     defaultConcurrency,
+	}
+	jobQueue.Register(JobType, service.handleJob)
+	return service
+}
+
+// Start starts a rollout of the service of the given type for the selected customers.
+// It returns the rollout, which is processed by a background job.
+func (s *Service) Start(serviceType string, request domain.RolloutRequest) (*domain.Rollout, error) {
+	action := domain.RolloutAction(request.Action)
+	if action != domain.RolloutActionEnable && action != domain.RolloutActionDisable {
+		return nil, fmt.Errorf("%w: unknown action: %s", ErrInvalidRequest, request.Action)
+	}
+	// A service which doesn't exist (anymore) can still be disabled, but not enabled
+	if action == domain.RolloutActionEnable {
+		if err := s.checkServiceType(serviceType); err != nil {
+			return nil, err
+		}
+	}
+	selectedCustomers, err := s.CustomerService.SelectCustomers(request.Selector)
+	if errors.Is(err, customers.ErrInvalidSelector) {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidRequest, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rollout := domain.Rollout{
+		Id:          uuid.New().String(),
+		ServiceType: serviceType,
+		Action:      action,
+		Status:      domain.RolloutStatusRunning,
+		Results:     make([]domain.RolloutResult, len(selectedCustomers)),
+	}
+	for i, customer := range selectedCustomers {
+		rollout.Results[i] = domain.RolloutResult{CustomerId: customer.Id, Status: domain.RolloutResultStatusPending}
+	}
+	if err := s.submit(&rollout); err != nil {
+		return nil, err
+	}
+	return &rollout, nil
+}
+
+// checkServiceType returns an ErrInvalidRequest when the service provider has no compound service of the given type,
+// so customers don't get references to a service which doesn't exist.
+func (s *Service) checkServiceType(serviceType string) error {
+	serviceProvider, err := s.SPService.Get()
+	if err != nil {
+		return err
+	}
+	if serviceProvider == nil {
+		return fmt.Errorf("%w: service provider not configured", ErrInvalidRequest)
+	}
+	services, err := s.SPService.GetServices()
+	if err != nil {
+		return err
+	}
+	for _, service := range services {
+		if service.Name == serviceType {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: service provider has no compound service of type %s", ErrInvalidRequest, serviceType)
+}
+
+// Get returns the rollout with the given ID.
+func (s *Service) Get(id string) (*domain.Rollout, error) {
+	rollout, err := s.Repository.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if rollout == nil {
+		return nil, ErrNotFound
+	}
+	return rollout, nil
+}
+
+// Resume processes the customers of a rollout for which it didn't succeed yet,
+// e.g. because it failed after all attempts of its job.
+func (s *Service) Resume(id string) (*domain.Rollout, error) {
+	rollout, err := s.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if rollout.JobId != nil {
+		job, err := s.Jobs.Get(*rollout.JobId)
+		if err != nil && !errors.Is(err, jobs.ErrNotFound) {
+			return nil, err
+		}
+		if job != nil && (job.Status == domain.JobStatusQueued || job.Status == domain.JobStatusRunning) {
+			return nil, ErrRunning
+		}
+	}
+	rollout.Status = domain.RolloutStatusRunning
+	if err := s.submit(rollout); err != nil {
+		return nil, err
+	}
+	return rollout, nil
+}
+
+// submit stores the rollout and submits the job processing it.
+// When the job can't be submitted, the rollout is stored as failed, so it doesn't appear to be running forever.
+func (s *Service) submit(rollout *domain.Rollout) error {
+	if err := s.Repository.Save(*rollout); err != nil {
+		return err
+	}
+	job, err := s.Jobs.Submit(JobType, jobPayload{RolloutID: rollout.Id})
+	if err != nil {
+		rollout.Status = domain.RolloutStatusFailed
+		if saveErr := s.Repository.Save(*rollout); saveErr != nil {
+			logrus.Errorf("Unable to store rollout (id=%s): %v", rollout.Id, saveErr)
+		}
+		return fmt.Errorf("unable to submit rollout job: %w", err)
+	}
+	rollout.JobId = &job.Id
+	return s.Repository.Save(*rollout)
+}
+
+// handleJob processes the rollout of a job. Customers for which a previous attempt failed are processed again.
+// It returns an error when the rollout failed for one or more customers, so the job is retried.
+func (s *Service) handleJob(payload json.RawMessage, progress jobs.ProgressFunc) error {
+	var p jobPayload
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return err
+	}
+	rollout, err := s.Get(p.RolloutID)
+	if err != nil {
+		return err
+	}
+	rollout.Status = domain.RolloutStatusRunning
+	for i, result := range rollout.Results {
+		if result.Status == domain.RolloutResultStatusFailed {
+			rollout.Results[i] = domain.RolloutResult{CustomerId: result.CustomerId, Status: domain.RolloutResultStatusPending}
+		}
+	}
+	if err := s.Repository.Save(*rollout); err != nil {
+		return err
+	}
+	return s.process(*rollout, progress)
+}
+
+// process applies the rollout to every pending customer, using a bounded number of workers.
+// The progress is stored after every customer, so an interrupted rollout can be resumed.
+func (s *Service) process(rollout domain.Rollout, progress jobs.ProgressFunc) error {
+	var spID string
+	if rollout.Action == domain.RolloutActionEnable {
+		serviceProvider, err := s.SPService.Get()
+		if err == nil && serviceProvider == nil {
+			err = errors.New("service provider not configured")
+		}
+		if err != nil {
+			rollout.Status = domain.RolloutStatusFailed
+			if err := s.Repository.Save(rollout); err != nil {
+				logrus.Errorf("Unable to store rollout (id=%s): %v", rollout.Id, err)
+			}
+			return fmt.Errorf("unable to start rollout: %w", err)
+		}
+		spID = serviceProvider.Id
+	}
+
+	concurrency := s.Concurrency
+	if concurrency < 1 {
+		concurrency = defaultConcurrency
+	}
+	processed := 0
+	indices := make(chan int)
+	mutex := sync.Mutex{}
+	wg := sync.WaitGroup{}
+	wg.Add(concurrency)
+	for i := 0; i < concurrency; i++ {
+		go func() {
+			defer wg.Done()
+			for idx := range indices {
+				result := s.apply(rollout.ServiceType, rollout.Action, spID, rollout.Results[idx].CustomerId)
+
+				mutex.Lock()
+				rollout.Results[idx] = result
+				if err := s.Repository.Save(rollout); err != nil {
+					logrus.Errorf("Unable to store rollout progress (id=%s): %v", rollout.Id, err)
+				}
+				processed++
+				progress(processed, len(rollout.Results))
+				mutex.Unlock()
+			}
+		}()
+	}
+	for i, result := range rollout.Results {
+		if result.Status == domain.RolloutResultStatusPending {
+			indices <- i
+		} else {
+			mutex.Lock()
+			processed++
+			mutex.Unlock()
+		}
+	}
+	close(indices)
+	wg.Wait()
+
+	failures := 0
+	for _, result := range rollout.Results {
+		if result.Status == domain.RolloutResultStatusFailed {
+			failures++
+		}
+	}
+	rollout.Status = domain.RolloutStatusCompleted
+	if failures > 0 {
+		rollout.Status = domain.RolloutStatusFailed
+	}
+	if err := s.Repository.Save(rollout); err != nil {
+		return fmt.Errorf("unable to store rollout: %w", err)
+	}
+	logrus.Infof("Rollout finished (id=%s, type=%s, action=%s, status=%s)", rollout.Id, rollout.ServiceType, rollout.Action, rollout.Status)
+	if failures > 0 {
+		return fmt.Errorf("rollout failed for %d of %d customers", failures, len(rollout.Results))
+	}
+	return nil
+}
+
+// apply enables or disables the service for a single customer.
+// Customers for which the service already is enabled (or disabled) are skipped.
+func (s *Service) apply(serviceType string, action domain.RolloutAction, spID string, customerID int) domain.RolloutResult {
+	result := domain.RolloutResult{CustomerId: customerID}
+	enabled, err := s.isEnabled(customerID, serviceType)
+	if err == nil {
+		switch {
+		case enabled == (action == domain.RolloutActionEnable):
+			result.Status = domain.RolloutResultStatusSkipped
+			return result
+		case action == domain.RolloutActionEnable:
+			err = s.CustomerService.EnableService(customerID, spID, serviceType)
+		default:
+			err = s.CustomerService.DisableService(customerID, serviceType)
+		}
+	}
+	if err != nil {
+		logrus.Warnf("Rollout failed for customer (id=%d, type=%s, action=%s): %v", customerID, serviceType, action, err)
+		msg := err.Error()
+		result.Error = &msg
+		result.Status = domain.RolloutResultStatusFailed
+		return result
+	}
+	result.Status = domain.RolloutResultStatusSucceeded
+	return result
+}
+
+func (s *Service) isEnabled(customerID int, serviceType string) (bool, error) {
+	services, err := s.CustomerService.GetServices(customerID)
+	if err != nil {
+		return false, err
+	}
+	for _, service := range services {
+		if service.Type == serviceType {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,247 @@
+package rollout
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	ssi "github.com/nuts-foundation/go-did"
+	"github.com/nuts-foundation/go-did/did"
+	didmanAPI "github.com/nuts-foundation/nuts-node/didman/api/v1"
+	vdrAPI "github.com/nuts-foundation/nuts-node/vdr/api/v1"
+	"github.com/nuts-foundation/nuts-registry-admin-demo/domain"
+	"github.com/nuts-foundation/nuts-registry-admin-demo/domain/customers"
+	"github.com/nuts-foundation/nuts-registry-admin-demo/domain/jobs"
+	"github.com/nuts-foundation/nuts-registry-admin-demo/domain/sp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.etcd.io/bbolt"
+	"go.uber.org/mock/gomock"
+)
+
+const testServiceType = "eOverdracht"
+
+func stringPtr(value string) *string {
+	return &value
+}
+
+func testDB(t *testing.T) *bbolt.DB {
+	db, err := bbolt.Open(filepath.Join(t.TempDir(), "test.db"), 0600, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+	return db
+}
+
+// testService returns a rollout service for customers 1 to 3. Customer 1 has the service enabled,
+// the DID document of customer 3 can't be resolved. The service provider is only registered when spRegistered is set.
+func testService(t *testing.T, spRegistered bool) *Service {
+	ctrl := gomock.NewController(t)
+	customerRepository := customers.NewFlatFileRepository(filepath.Join(t.TempDir(), "customers.json"))
+	for i, did := range []string{"did:nuts:1", "did:nuts:2", "did:nuts:3"} {
+		_, err := customerRepository.NewCustomer(domain.Customer{Id: i + 1, Name: did, Did: stringPtr(did)})
+		require.NoError(t, err)
+	}
+	vdrClient := domain.NewMockVDRClient(ctrl)
+	vdrClient.EXPECT().Get(gomock.Any()).DoAndReturn(func(id string) (*did.Document, *vdrAPI.DocumentMetadata, error) {
+		document := &did.Document{ID: did.MustParseDID(id)}
+		switch id {
+		case "did:nuts:1":
+			document.Service = []did.Service{{ID: ssi.MustParseURI(id + "#1"), Type: testServiceType, ServiceEndpoint: "did:nuts:sp/serviceEndpoint?type=" + testServiceType}}
+		case "did:nuts:3":
+			return nil, nil, errors.New("node unavailable")
+		}
+		return document, nil, nil
+	}).AnyTimes()
+	didmanClient := domain.NewMockDIDManClient(ctrl)
+	didmanClient.EXPECT().AddEndpoint(gomock.Any(), testServiceType, "did:nuts:sp/serviceEndpoint?type="+testServiceType).Return(&didmanAPI.Endpoint{}, nil).AnyTimes()
+	didmanClient.EXPECT().DeleteEndpointsByType(gomock.Any(), testServiceType).Return(nil).AnyTimes()
+	didmanClient.EXPECT().GetContactInformation("did:nuts:sp").Return(nil, nil).AnyTimes()
+	didmanClient.EXPECT().GetCompoundServices("did:nuts:sp").Return([]didmanAPI.CompoundService{
+		{ID: ssi.MustParseURI("did:nuts:sp#1"), Type: testServiceType, ServiceEndpoint: map[string]interface{}{"fhir": "https://example.com/fhir"}},
+	}, nil).AnyTimes()
+	spRepository := sp.NewMockRepository(ctrl)
+	if spRegistered {
+		spDID := did.MustParseDID("did:nuts:sp")
+		spRepository.EXPECT().Get().Return(&spDID, nil).AnyTimes()
+		spRepository.EXPECT().Set("did:nuts:sp").Return(nil).AnyTimes()
+	} else {
+		spRepository.EXPECT().Get().Return(nil, nil).AnyTimes()
+	}
+
+	return &Service{
+		Repository:      NewBBoltRepository(testDB(t)),
+		CustomerService: customers.Service{Repository: customerRepository, VDRClient: vdrClient, DIDManClient: didmanClient},
+		SPService:       sp.Service{Repository: spRepository, VDRClient: vdrClient, DIDManClient: didmanClient},
+		Concurrency:     2,
+	}
+}
+
+// recordingRepository records the rollouts which are saved.
+type recordingRepository struct {
+	Repository
+	saved []domain.Rollout
+}
+
+func (r *recordingRepository) Save(rollout domain.Rollout) error {
+	r.saved = append(r.saved, rollout)
+	return r.Repository.Save(rollout)
+}
+
+func TestService_process(t *testing.T) {
+	pending := func(customerIDs ...int) []domain.RolloutResult {
+		var results []domain.RolloutResult
+		for _, id := range customerIDs {
+			results = append(results, domain.RolloutResult{CustomerId: id, Status: domain.RolloutResultStatusPending})
+		}
+		return results
+	}
+	tests := []struct {
+		name             string
+		action           domain.RolloutAction
+		spNotRegistered  bool
+		results          []domain.RolloutResult
+		expectedStatus   domain.RolloutStatus
+		expectedResults  map[int]domain.RolloutResultStatus
+		expectedProgress int
+		expectedErr      string
+	}{
+		{
+			name:             "enable",
+			action:           domain.RolloutActionEnable,
+			results:          pending(1, 2),
+			expectedStatus:   domain.RolloutStatusCompleted,
+			expectedResults:  map[int]domain.RolloutResultStatus{1: domain.RolloutResultStatusSkipped, 2: domain.RolloutResultStatusSucceeded},
+			expectedProgress: 2,
+		},
+		{
+			name:             "disable",
+			action:           domain.RolloutActionDisable,
+			results:          pending(1, 2),
+			expectedStatus:   domain.RolloutStatusCompleted,
+			expectedResults:  map[int]domain.RolloutResultStatus{1: domain.RolloutResultStatusSucceeded, 2: domain.RolloutResultStatusSkipped},
+			expectedProgress: 2,
+		},
+		{
+			name:             "failure for a customer",
+			action:           domain.RolloutActionEnable,
+			results:          pending(1, 2, 3),
+			expectedStatus:   domain.RolloutStatusFailed,
+			expectedResults:  map[int]domain.RolloutResultStatus{1: domain.RolloutResultStatusSkipped, 2: domain.RolloutResultStatusSucceeded, 3: domain.RolloutResultStatusFailed},
+			expectedProgress: 3,
+			expectedErr:      "rollout failed for 1 of 3 customers",
+		},
+		{
+			name:   "processed customers are kept",
+			action: domain.RolloutActionDisable,
+			results: []domain.RolloutResult{
+				{CustomerId: 2, Status: domain.RolloutResultStatusSucceeded},
+				{CustomerId: 1, Status: domain.RolloutResultStatusPending},
+			},
+			expectedStatus:   domain.RolloutStatusCompleted,
+			expectedResults:  map[int]domain.RolloutResultStatus{1: domain.RolloutResultStatusSucceeded, 2: domain.RolloutResultStatusSucceeded},
+			expectedProgress: 2,
+		},
+		{
+			name:            "service provider not registered",
+			action:          domain.RolloutActionEnable,
+			spNotRegistered: true,
+			results:         pending(1, 2),
+			expectedStatus:  domain.RolloutStatusFailed,
+			expectedResults: map[int]domain.RolloutResultStatus{1: domain.RolloutResultStatusPending, 2: domain.RolloutResultStatusPending},
+			expectedErr:     "unable to start rollout: service provider not configured",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			service := testService(t, !test.spNotRegistered)
+			rollout := domain.Rollout{Id: "1", ServiceType: testServiceType, Action: test.action, Status: domain.RolloutStatusRunning, Results: test.results}
+			lastProgress := 0
+
+			err := service.process(rollout, func(processed int, total int) {
+				assert.Equal(t, len(test.results), total)
+				lastProgress = processed
+			})
+
+			if test.expectedErr != "" {
+				assert.EqualError(t, err, test.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, test.expectedProgress, lastProgress)
+			stored, err := service.Get("1")
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedStatus, stored.Status)
+			statuses := make(map[int]domain.RolloutResultStatus)
+			for _, result := range stored.Results {
+				statuses[result.CustomerId] = result.Status
+			}
+			assert.Equal(t, test.expectedResults, statuses)
+		})
+	}
+}
+
+func TestService_Start(t *testing.T) {
+	all := true
+	request := domain.RolloutRequest{Action: domain.RolloutRequestAction(domain.RolloutActionEnable), Selector: domain.CustomerSelector{All: &all}}
+
+	t.Run("job can't be submitted", func(t *testing.T) {
+		service := testService(t, true)
+		// The queue isn't started, so jobs can't be submitted
+		service.Jobs = jobs.NewQueue(testDB(t), 1)
+		service.Jobs.Register(JobType, service.handleJob)
+		repository := &recordingRepository{Repository: service.Repository}
+		service.Repository = repository
+
+		rollout, err := service.Start(testServiceType, request)
+
+		assert.ErrorIs(t, err, jobs.ErrNotRunning)
+		assert.Nil(t, rollout)
+		require.NotEmpty(t, repository.saved)
+		stored, err := service.Get(repository.saved[0].Id)
+		require.NoError(t, err)
+		assert.Equal(t, domain.RolloutStatusFailed, stored.Status)
+	})
+	t.Run("invalid action", func(t *testing.T) {
+		service := testService(t, true)
+
+		_, err := service.Start(testServiceType, domain.RolloutRequest{Action: "other", Selector: request.Selector})
+
+		assert.ErrorIs(t, err, ErrInvalidRequest)
+	})
+	t.Run("unknown service type", func(t *testing.T) {
+		service := testService(t, true)
+		repository := &recordingRepository{Repository: service.Repository}
+		service.Repository = repository
+
+		_, err := service.Start("other", request)
+
+		assert.ErrorIs(t, err, ErrInvalidRequest)
+		assert.EqualError(t, err, "invalid rollout request: service provider has no compound service of type other")
+		assert.Empty(t, repository.saved)
+	})
+	t.Run("unknown service type can be disabled", func(t *testing.T) {
+		service := testService(t, true)
+		// The queue isn't started, so the rollout is accepted up to submitting the job
+		service.Jobs = jobs.NewQueue(testDB(t), 1)
+
+		_, err := service.Start("other", domain.RolloutRequest{Action: domain.RolloutRequestAction(domain.RolloutActionDisable), Selector: request.Selector})
+
+		assert.ErrorIs(t, err, jobs.ErrNotRunning)
+	})
+	t.Run("service provider not configured", func(t *testing.T) {
+		service := testService(t, false)
+
+		_, err := service.Start(testServiceType, request)
+
+		assert.ErrorIs(t, err, ErrInvalidRequest)
+	})
+	t.Run("invalid selector", func(t *testing.T) {
+		service := testService(t, true)
+
+		_, err := service.Start(testServiceType, domain.RolloutRequest{Action: request.Action})
+
+		assert.ErrorIs(t, err, ErrInvalidRequest)
+	})
+}
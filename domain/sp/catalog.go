@@ -0,0 +1,164 @@
+package sp
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"net/url"
+
+	"github.com/knadh/koanf"
+	"github.com/knadh/koanf/parsers/yaml"
+	"github.com/knadh/koanf/providers/file"
+	"github.com/knadh/koanf/providers/rawbytes"
+	"github.com/nuts-foundation/go-did/did"
+	"github.com/nuts-foundation/nuts-registry-admin-demo/domain"
+	"github.com/sirupsen/logrus"
+)
+
+//go:embed catalog.yaml
+var defaultCatalog []byte
+
+var ErrTemplateNotFound = errors.New("service template not found")
+
+var ErrServiceExists = errors.New("compound service already exists")
+
+// LoadCatalog loads the catalog of well-known compound services from the given YAML file.
+// If no file is given, the built-in catalog is loaded.
+func LoadCatalog(filePath string) ([]domain.ServiceTemplate, error) {
+	var provider koanf.Provider = rawbytes.Provider(defaultCatalog)
+	if len(filePath) > 0 {
+		provider = file.Provider(filePath)
+	}
+	k := koanf.New(".")
+	if err := k.Load(provider, yaml.Parser()); err != nil {
+		return nil, fmt.Errorf("unable to load service catalog: %w", err)
+	}
+	catalog := struct {
+		Services []domain.ServiceTemplate `json:"services"`
+	}{}
+	if err := k.UnmarshalWithConf("", &catalog, koanf.UnmarshalConf{Tag: "json"}); err != nil {
+		return nil, fmt.Errorf("unable to unmarshal service catalog: %w", err)
+	}
+
+	names := make(map[string]bool, len(catalog.Services))
+	for _, template := range catalog.Services {
+		if len(template.Name) == 0 {
+			return nil, errors.New("invalid service catalog: service template without name")
+		}
+		if names[template.Name] {
+			return nil, fmt.Errorf("invalid service catalog: duplicate service template (name=%s)", template.Name)
+		}
+		names[template.Name] = true
+		for _, endpoint := range template.Endpoints {
+			if len(endpoint.Key) == 0 || len(endpoint.Type) == 0 {
+				return nil, fmt.Errorf("invalid service catalog: endpoint without key or type (name=%s)", template.Name)
+			}
+		}
+	}
+	return catalog.Services, nil
+}
+
+// ApplyTemplate creates the compound service described by the service template with the given name.
+// Endpoints of the template which are missing on the service provider's DID document are registered first,
+// using the URL given for the endpoint's key.
+func (svc Service) ApplyTemplate(name string, endpointURLs map[string]interface{}) (*domain.Service, error) {
+	var template *domain.ServiceTemplate
+	for i, curr := range svc.Catalog {
+		if curr.Name == name {
+			template = &svc.Catalog[i]
+			break
+		}
+	}
+	if template == nil {
+		return nil, ErrTemplateNotFound
+	}
+
+	spDID, err := svc.Repository.Get()
+	if err != nil {
+		return nil, err
+	}
+	if spDID == nil {
+		return nil, errNoServiceProvider
+	}
+	document, _, err := svc.VDRClient.Get(spDID.String())
+	if err != nil {
+		return nil, domain.UnwrapAPIError(err)
+	}
+	if hasService(*document, template.Name) {
+		return nil, ErrServiceExists
+	}
+
+	// Determine which endpoints need to be registered, before changing anything
+	reasons := make(map[string]string)
+	references := make(map[string]interface{}, len(template.Endpoints))
+	missingEndpoints := make([]domain.EndpointProperties, 0)
+	templateKeys := make(map[string]bool, len(template.Endpoints))
+	for _, endpoint := range template.Endpoints {
+		templateKeys[endpoint.Key] = true
+		reference := endpointReference(*spDID, endpoint.Type)
+		if hasService(*document, endpoint.Type) {
+			references[endpoint.Key] = reference
+			continue
+		}
+		endpointURL, _ := endpointURLs[endpoint.Key].(string)
+		if len(endpointURL) == 0 {
+			if endpoint.Required {
+				reasons[endpoint.Key] = fmt.Sprintf("no endpoint of type %s registered, its URL must be provided", endpoint.Type)
+			}
+			continue
+		}
+		if parsedURL, err := url.Parse(endpointURL); err != nil || !parsedURL.IsAbs() || parsedURL.Host == "" {
+			reasons[endpoint.Key] = "endpoint URL must be an absolute URL"
+			continue
+		}
+		missingEndpoints = append(missingEndpoints, domain.EndpointProperties{Type: endpoint.Type, Url: endpointURL})
+		references[endpoint.Key] = reference
+	}
+	for key := range endpointURLs {
+		if !templateKeys[key] {
+			reasons[key] = "not part of the service template"
+		}
+	}
+	if len(reasons) > 0 {
+		return nil, BrokenReferencesError{Reasons: reasons}
+	}
+
+	// Endpoints registered for the template are removed again when a later step fails, so they aren't left behind
+	var registered []string
+	for _, endpoint := range missingEndpoints {
+		if _, err := svc.DIDManClient.AddEndpoint(spDID.String(), endpoint.Type, endpoint.Url); err != nil {
+			svc.removeEndpoints(*spDID, registered)
+			return nil, fmt.Errorf("unable to register endpoint (type=%s): %w", endpoint.Type, domain.UnwrapAPIError(err))
+		}
+		registered = append(registered, endpoint.Type)
+	}
+	service, err := svc.AddService(domain.ServiceProperties{Name: template.Name, ServiceEndpoint: references})
+	if err != nil {
+		svc.removeEndpoints(*spDID, registered)
+		return nil, err
+	}
+	return service, nil
+}
+
+// removeEndpoints removes the endpoints of the given types from the DID document. Failures are logged.
+func (svc Service) removeEndpoints(spDID did.DID, endpointTypes []string) {
+	for _, endpointType := range endpointTypes {
+		if err := svc.DIDManClient.DeleteEndpointsByType(spDID.String(), endpointType); err != nil {
+			logrus.Errorf("Unable to remove endpoint registered for service template (type=%s): %v", endpointType, err)
+		}
+	}
+}
+
+// endpointReference returns the reference to the endpoint of the given type on the DID document of the given DID.
+func endpointReference(id did.DID, endpointType string) string {
+	return fmt.Sprintf("%s/%s?type=%s", id.String(), serviceEndpointPath, endpointType)
+}
+
+func hasService(document did.Document, serviceType string) bool {
+	for _, service := range document.Service {
+		if service.Type == serviceType {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,160 @@
+package sp
+
+import (
+	"errors"
+	"testing"
+
+	ssi "github.com/nuts-foundation/go-did"
+	"github.com/nuts-foundation/go-did/did"
+	didmanAPI "github.com/nuts-foundation/nuts-node/didman/api/v1"
+	vdrAPI "github.com/nuts-foundation/nuts-node/vdr/api/v1"
+	"github.com/nuts-foundation/nuts-registry-admin-demo/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+var testCatalog = []domain.ServiceTemplate{
+	{
+		Name: "eOverdracht-sender",
+		Endpoints: []domain.ServiceTemplateEndpoint{
+			{Key: "oauth", Type: "oauth-request-accesstoken", Required: true},
+			{Key: "fhir", Type: "eOverdracht-fhir", Required: true},
+			{Key: "notification", Type: "eOverdracht-notification"},
+		},
+	},
+}
+
+// testNode simulates the DID document of the service provider on the Nuts node, to which endpoints can be added.
+type testNode struct {
+	document did.Document
+	// failAddEndpoint contains the endpoint types of which the registration fails.
+	failAddEndpoint map[string]bool
+	// failAddService makes adding the compound service fail.
+	failAddService bool
+	deleted        []string
+}
+
+func (n *testNode) service(t *testing.T) Service {
+	ctrl := gomock.NewController(t)
+	spDID := did.MustParseDID("did:nuts:sp")
+	repository := NewMockRepository(ctrl)
+	repository.EXPECT().Get().Return(&spDID, nil).AnyTimes()
+	vdrClient := domain.NewMockVDRClient(ctrl)
+	vdrClient.EXPECT().Get("did:nuts:sp").DoAndReturn(func(id string) (*did.Document, *vdrAPI.DocumentMetadata, error) {
+		document := n.document
+		return &document, &vdrAPI.DocumentMetadata{}, nil
+	}).AnyTimes()
+	didmanClient := domain.NewMockDIDManClient(ctrl)
+	didmanClient.EXPECT().AddEndpoint("did:nuts:sp", gomock.Any(), gomock.Any()).DoAndReturn(func(id, endpointType, endpointURL string) (*didmanAPI.Endpoint, error) {
+		if n.failAddEndpoint[endpointType] {
+			return nil, errors.New("failed")
+		}
+		n.document.Service = append(n.document.Service, did.Service{ID: ssi.MustParseURI("did:nuts:sp#" + endpointType), Type: endpointType, ServiceEndpoint: endpointURL})
+		return &didmanAPI.Endpoint{}, nil
+	}).AnyTimes()
+	didmanClient.EXPECT().DeleteEndpointsByType("did:nuts:sp", gomock.Any()).DoAndReturn(func(id, endpointType string) error {
+		n.deleted = append(n.deleted, endpointType)
+		return nil
+	}).AnyTimes()
+	didmanClient.EXPECT().AddCompoundService("did:nuts:sp", gomock.Any(), gomock.Any()).DoAndReturn(func(id, serviceType string, references map[string]string) (*didmanAPI.CompoundService, error) {
+		if n.failAddService {
+			return nil, errors.New("failed")
+		}
+		endpoint := make(map[string]interface{}, len(references))
+		for key, reference := range references {
+			endpoint[key] = reference
+		}
+		return &didmanAPI.CompoundService{ID: ssi.MustParseURI("did:nuts:sp#" + serviceType), Type: serviceType, ServiceEndpoint: endpoint}, nil
+	}).AnyTimes()
+	return Service{Repository: repository, VDRClient: vdrClient, DIDManClient: didmanClient, Catalog: testCatalog}
+}
+
+func TestService_ApplyTemplate(t *testing.T) {
+	oauthEndpoint := did.Service{ID: ssi.MustParseURI("did:nuts:sp#1"), Type: "oauth-request-accesstoken", ServiceEndpoint: "https://example.com/oauth"}
+	tests := []struct {
+		name         string
+		template     string
+		endpointURLs map[string]interface{}
+		services     []did.Service
+		node         testNode
+		expectedErr  string
+		expected     map[string]interface{}
+		// expectedDeleted contains the endpoint types which are expected to be removed again.
+		expectedDeleted []string
+	}{
+		{
+			name:        "unknown template",
+			template:    "unknown",
+			expectedErr: ErrTemplateNotFound.Error(),
+		},
+		{
+			name:        "service exists",
+			template:    "eOverdracht-sender",
+			services:    []did.Service{{ID: ssi.MustParseURI("did:nuts:sp#2"), Type: "eOverdracht-sender", ServiceEndpoint: map[string]interface{}{}}},
+			expectedErr: ErrServiceExists.Error(),
+		},
+		{
+			name:         "missing required URL",
+			template:     "eOverdracht-sender",
+			endpointURLs: map[string]interface{}{"oauth": "https://example.com/oauth"},
+			expectedErr:  "compound service contains broken references: fhir (no endpoint of type eOverdracht-fhir registered, its URL must be provided)",
+		},
+		{
+			name:         "relative URL",
+			template:     "eOverdracht-sender",
+			endpointURLs: map[string]interface{}{"oauth": "https://example.com/oauth", "fhir": "/fhir"},
+			expectedErr:  "compound service contains broken references: fhir (endpoint URL must be an absolute URL)",
+		},
+		{
+			name:         "unknown key",
+			template:     "eOverdracht-sender",
+			endpointURLs: map[string]interface{}{"oauth": "https://example.com/oauth", "fhir": "https://example.com/fhir", "other": "https://example.com"},
+			expectedErr:  "compound service contains broken references: other (not part of the service template)",
+		},
+		{
+			name:         "registers missing endpoints",
+			template:     "eOverdracht-sender",
+			endpointURLs: map[string]interface{}{"fhir": "https://example.com/fhir"},
+			services:     []did.Service{oauthEndpoint},
+			expected: map[string]interface{}{
+				"oauth": "did:nuts:sp/serviceEndpoint?type=oauth-request-accesstoken",
+				"fhir":  "did:nuts:sp/serviceEndpoint?type=eOverdracht-fhir",
+			},
+		},
+		{
+			name:            "registering endpoint fails",
+			template:        "eOverdracht-sender",
+			endpointURLs:    map[string]interface{}{"oauth": "https://example.com/oauth", "fhir": "https://example.com/fhir"},
+			node:            testNode{failAddEndpoint: map[string]bool{"eOverdracht-fhir": true}},
+			expectedErr:     "unable to register endpoint (type=eOverdracht-fhir): failed",
+			expectedDeleted: []string{"oauth-request-accesstoken"},
+		},
+		{
+			name:            "adding service fails",
+			template:        "eOverdracht-sender",
+			endpointURLs:    map[string]interface{}{"fhir": "https://example.com/fhir", "notification": "https://example.com/notification"},
+			services:        []did.Service{oauthEndpoint},
+			node:            testNode{failAddService: true},
+			expectedErr:     "failed",
+			expectedDeleted: []string{"eOverdracht-fhir", "eOverdracht-notification"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			node := test.node
+			node.document = did.Document{ID: did.MustParseDID("did:nuts:sp"), Service: test.services}
+
+			service, err := node.service(t).ApplyTemplate(test.template, test.endpointURLs)
+
+			assert.Equal(t, test.expectedDeleted, node.deleted)
+			if test.expectedErr != "" {
+				assert.EqualError(t, err, test.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.template, service.Name)
+			assert.Equal(t, test.expected, service.ServiceEndpoint)
+		})
+	}
+}
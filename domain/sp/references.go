@@ -0,0 +1,126 @@
+package sp
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/nuts-foundation/go-did/did"
+	"github.com/nuts-foundation/nuts-registry-admin-demo/domain"
+)
+
+const serviceEndpointPath = "serviceEndpoint"
+
+// BrokenReferencesError is returned when a compound service contains references which can't be resolved.
+type BrokenReferencesError struct {
+	// Reasons maps the key of every broken reference to the reason why it is broken.
+	Reasons map[string]string
+}
+
+func (e BrokenReferencesError) Error() string {
+	keys := make([]string, 0, len(e.Reasons))
+	for key := range e.Reasons {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	descriptions := make([]string, len(keys))
+	for i, key := range keys {
+		descriptions[i] = fmt.Sprintf("%s (%s)", key, e.Reasons[key])
+	}
+	return "compound service contains broken references: " + strings.Join(descriptions, ", ")
+}
+
+// CheckServiceReferences scans all compound services of the service provider for references that can't be resolved.
+func (svc Service) CheckServiceReferences() ([]domain.BrokenServiceReference, error) {
+	spDID, err := svc.Repository.Get()
+	if err != nil {
+		return nil, err
+	}
+	if spDID == nil {
+		return nil, errNoServiceProvider
+	}
+	document, _, err := svc.VDRClient.Get(spDID.String())
+	if err != nil {
+		return nil, domain.UnwrapAPIError(err)
+	}
+
+	result := []domain.BrokenServiceReference{}
+	for _, service := range document.Service {
+		serviceEndpoint, ok := service.ServiceEndpoint.(map[string]interface{})
+		if !ok {
+			// Not a compound service
+			continue
+		}
+		reasons := checkReferences(*document, serviceEndpoint)
+		keys := make([]string, 0, len(reasons))
+		for key := range reasons {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			result = append(result, domain.BrokenServiceReference{
+				ServiceId:   service.ID.String(),
+				ServiceName: service.Type,
+				Key:         key,
+				Reference:   fmt.Sprintf("%v", serviceEndpoint[key]),
+				Reason:      reasons[key],
+			})
+		}
+	}
+	return result, nil
+}
+
+// validateReferences checks whether all references of the compound service can be resolved against the service provider's DID document.
+func (svc Service) validateReferences(spDID did.DID, serviceEndpoint map[string]interface{}) error {
+	document, _, err := svc.VDRClient.Get(spDID.String())
+	if err != nil {
+		return domain.UnwrapAPIError(err)
+	}
+	if reasons := checkReferences(*document, serviceEndpoint); len(reasons) > 0 {
+		return BrokenReferencesError{Reasons: reasons}
+	}
+	return nil
+}
+
+// checkReferences checks every reference of the compound service. A reference is valid when it's an absolute URL,
+// or refers to an existing endpoint on the given DID document (e.g. did:nuts:123/serviceEndpoint?type=fhir).
+// It returns the reason for every broken reference, keyed by its key in the compound service.
+func checkReferences(document did.Document, serviceEndpoint map[string]interface{}) map[string]string {
+	reasons := make(map[string]string)
+	for key, value := range serviceEndpoint {
+		reference, ok := value.(string)
+		if !ok {
+			reasons[key] = "reference must be a string"
+			continue
+		}
+		if reason := checkReference(document, reference); reason != "" {
+			reasons[key] = reason
+		}
+	}
+	return reasons
+}
+
+func checkReference(document did.Document, reference string) string {
+	if !strings.HasPrefix(reference, "did:") {
+		parsedURL, err := url.Parse(reference)
+		if err != nil || !parsedURL.IsAbs() || parsedURL.Host == "" {
+			return "reference must be an absolute URL or of the form <did>/serviceEndpoint?type=<type>"
+		}
+		return ""
+	}
+
+	referenceURL, err := did.ParseDIDURL(reference)
+	if err != nil || referenceURL.Path != serviceEndpointPath || referenceURL.Query.Get("type") == "" {
+		return "reference must be an absolute URL or of the form <did>/serviceEndpoint?type=<type>"
+	}
+	if !referenceURL.WithoutURL().Equals(document.ID) {
+		return fmt.Sprintf("reference must refer to the service provider's DID (%s)", document.ID)
+	}
+	endpointType := referenceURL.Query.Get("type")
+	_, _, err = document.ResolveEndpointURL(endpointType)
+	if err != nil {
+		return fmt.Sprintf("no resolvable endpoint of type %s: %s", endpointType, err)
+	}
+	return ""
+}
@@ -0,0 +1,55 @@
+package sp
+
+import (
+	"testing"
+
+	ssi "github.com/nuts-foundation/go-did"
+	"github.com/nuts-foundation/go-did/did"
+	"github.com/stretchr/testify/assert"
+)
+
+func testSPDocument() did.Document {
+	return did.Document{
+		ID: did.MustParseDID("did:nuts:sp"),
+		Service: []did.Service{
+			{ID: ssi.MustParseURI("did:nuts:sp#1"), Type: "fhir", ServiceEndpoint: "https://example.com/fhir"},
+			{ID: ssi.MustParseURI("did:nuts:sp#2"), Type: "eOverdracht", ServiceEndpoint: map[string]interface{}{"fhir": "did:nuts:sp/serviceEndpoint?type=fhir"}},
+		},
+	}
+}
+
+func TestCheckReferences(t *testing.T) {
+	tests := []struct {
+		name           string
+		reference      interface{}
+		expectedReason string
+	}{
+		{name: "endpoint of the service provider", reference: "did:nuts:sp/serviceEndpoint?type=fhir"},
+		{name: "absolute URL", reference: "https://example.com/oauth"},
+		{name: "not a string", reference: 42, expectedReason: "reference must be a string"},
+		{name: "relative URL", reference: "/oauth", expectedReason: "reference must be an absolute URL or of the form <did>/serviceEndpoint?type=<type>"},
+		{name: "without type", reference: "did:nuts:sp/serviceEndpoint", expectedReason: "reference must be an absolute URL or of the form <did>/serviceEndpoint?type=<type>"},
+		{name: "other path", reference: "did:nuts:sp/other?type=fhir", expectedReason: "reference must be an absolute URL or of the form <did>/serviceEndpoint?type=<type>"},
+		{name: "other DID", reference: "did:nuts:other/serviceEndpoint?type=fhir", expectedReason: "reference must refer to the service provider's DID (did:nuts:sp)"},
+		{name: "unknown endpoint", reference: "did:nuts:sp/serviceEndpoint?type=oauth", expectedReason: "no resolvable endpoint of type oauth"},
+		{name: "compound service instead of endpoint", reference: "did:nuts:sp/serviceEndpoint?type=eOverdracht", expectedReason: "no resolvable endpoint of type eOverdracht"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			reasons := checkReferences(testSPDocument(), map[string]interface{}{"key": test.reference})
+
+			if test.expectedReason == "" {
+				assert.Empty(t, reasons)
+				return
+			}
+			assert.Len(t, reasons, 1)
+			assert.Contains(t, reasons["key"], test.expectedReason)
+		})
+	}
+}
+
+func TestBrokenReferencesError_Error(t *testing.T) {
+	err := BrokenReferencesError{Reasons: map[string]string{"oauth": "reason 2", "fhir": "reason 1"}}
+
+	assert.EqualError(t, err, "compound service contains broken references: fhir (reason 1), oauth (reason 2)")
+}
@@ -9,6 +9,8 @@ import (
 const serviceProviderBucketName = "ServiceProvider"
 const defaultServiceProviderKey = "default"
 
+//go:generate mockgen -destination=mock.go -package=sp -source=repository.go
+
 type Repository interface {
 	// Get returns the DID of the Service Provider.
 	Get() (*did.DID, error)
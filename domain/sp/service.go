@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"github.com/nuts-foundation/go-did/did"
+	"github.com/sirupsen/logrus"
 	"strings"
 
 	ssi "github.com/nuts-foundation/go-did"
@@ -12,11 +13,20 @@ import (
 	"github.com/nuts-foundation/nuts-registry-admin-demo/domain"
 )
 
+var ErrServiceNotFound = errors.New("compound service not found")
+
+// ErrInvalidCompoundService is returned when the Nuts node returns a compound service with an unexpected endpoint.
+var ErrInvalidCompoundService = errors.New("invalid compound service")
+
+var errNoServiceProvider = errors.New("no service-provider registered")
+
 type Service struct {
 	Repository   Repository
-	VDRClient    vdrAPI.HTTPClient
-	DIDManClient didmanAPI.HTTPClient
+	VDRClient    domain.VDRClient
+	DIDManClient domain.DIDManClient
 	VendorDID    *did.DID
+	// Catalog contains the templates of well-known compound services.
+	Catalog []domain.ServiceTemplate
 }
 
 // Get tries to find the default service provider from the database.
@@ -157,7 +167,7 @@ func (svc Service) GetServices() (domain.Services, error) {
 		return nil, err
 	}
 	if spDID == nil {
-		return nil, errors.New("no service-provider registered")
+		return nil, errNoServiceProvider
 	}
 	services, err := svc.DIDManClient.GetCompoundServices(spDID.String())
 	if err != nil {
@@ -165,35 +175,115 @@ func (svc Service) GetServices() (domain.Services, error) {
 	}
 	compoundServices := domain.Services{}
 	for _, service := range services {
-		compoundServices = append(compoundServices, domain.Service{
-			ServiceID: domain.ServiceID{Id: service.ID.String()},
-			ServiceProperties: domain.ServiceProperties{
-				ServiceEndpoint: service.ServiceEndpoint.(map[string]interface{}),
-				Name:            service.Type,
-			},
-		})
+		compoundService, err := toService(service)
+		if err != nil {
+			return nil, err
+		}
+		compoundServices = append(compoundServices, compoundService)
 	}
 	return compoundServices, nil
 }
 
+// GetService returns the compound service with the given ID.
+// Returns nil when the service provider has no such compound service.
+func (svc Service) GetService(id ssi.URI) (*domain.Service, error) {
+	services, err := svc.GetServices()
+	if err != nil {
+		return nil, err
+	}
+	for _, service := range services {
+		if service.Id == id.String() {
+			return &service, nil
+		}
+	}
+	return nil, nil
+}
+
+// AddService adds a compound service to the service provider's DID document.
+// It returns a BrokenReferencesError when a reference doesn't resolve to an endpoint of the service provider or an absolute URL.
 func (svc Service) AddService(service domain.ServiceProperties) (*domain.Service, error) {
 	spDID, err := svc.Repository.Get()
 	if err != nil {
 		return nil, err
 	}
+	if spDID == nil {
+		return nil, errNoServiceProvider
+	}
+	if err := svc.validateReferences(*spDID, service.ServiceEndpoint); err != nil {
+		return nil, err
+	}
+	return svc.addCompoundService(*spDID, service)
+}
+
+// UpdateService replaces the compound service with the given ID.
+// The Nuts node can't update a compound service in place, so it is removed and added again, which yields a new service ID.
+func (svc Service) UpdateService(id ssi.URI, service domain.ServiceProperties) (*domain.Service, error) {
+	spDID, err := svc.Repository.Get()
+	if err != nil {
+		return nil, err
+	}
+	if spDID == nil {
+		return nil, errNoServiceProvider
+	}
+	current, err := svc.GetService(id)
+	if err != nil {
+		return nil, err
+	}
+	if current == nil {
+		return nil, ErrServiceNotFound
+	}
+	if err := svc.validateReferences(*spDID, service.ServiceEndpoint); err != nil {
+		return nil, err
+	}
+	if err := svc.DeleteService(id); err != nil {
+		return nil, err
+	}
+	updated, err := svc.addCompoundService(*spDID, service)
+	if err != nil {
+		// Restore the original compound service, so it isn't lost when the update is rejected
+		if _, restoreErr := svc.addCompoundService(*spDID, current.ServiceProperties); restoreErr != nil {
+			logrus.Errorf("Unable to restore compound service after failed update (type=%s): %v", current.Name, restoreErr)
+		}
+		return nil, err
+	}
+	return updated, nil
+}
+
+func (svc Service) addCompoundService(spDID did.DID, service domain.ServiceProperties) (*domain.Service, error) {
 	endpoints := make(map[string]string, len(service.ServiceEndpoint))
 	for key, val := range service.ServiceEndpoint {
-		endpoints[key] = val.(string)
+		reference, ok := val.(string)
+		if !ok {
+			return nil, BrokenReferencesError{Reasons: map[string]string{key: "reference must be a string"}}
+		}
+		endpoints[key] = reference
 	}
 	cs, err := svc.DIDManClient.AddCompoundService(spDID.String(), service.Name, endpoints)
+	if err != nil {
+		return nil, domain.UnwrapAPIError(err)
+	}
+
+	result, err := toService(*cs)
 	if err != nil {
 		return nil, err
 	}
+	return &result, nil
+}
+
+// DeleteService removes the compound service with the given ID from the service provider's DID document.
+func (svc Service) DeleteService(id ssi.URI) error {
+	return svc.DIDManClient.DeleteService(id)
+}
 
-	return &domain.Service{
+func toService(cs didmanAPI.CompoundService) (domain.Service, error) {
+	endpoints, ok := cs.ServiceEndpoint.(map[string]interface{})
+	if !ok {
+		return domain.Service{}, fmt.Errorf("%w: compound service %s has an endpoint of type %T", ErrInvalidCompoundService, cs.ID.String(), cs.ServiceEndpoint)
+	}
+	return domain.Service{
 		ServiceID: domain.ServiceID{Id: cs.ID.String()},
 		ServiceProperties: domain.ServiceProperties{
-			ServiceEndpoint: cs.ServiceEndpoint.(map[string]interface{}),
+			ServiceEndpoint: endpoints,
 			Name:            cs.Type,
 		},
 	}, nil
@@ -0,0 +1,36 @@
+package sp
+
+import (
+	"testing"
+
+	ssi "github.com/nuts-foundation/go-did"
+	didmanAPI "github.com/nuts-foundation/nuts-node/didman/api/v1"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToService(t *testing.T) {
+	id := ssi.MustParseURI("did:nuts:sp#1")
+	tests := []struct {
+		name      string
+		endpoint  interface{}
+		expectErr bool
+	}{
+		{name: "references", endpoint: map[string]interface{}{"oauth": "did:nuts:sp/serviceEndpoint?type=oauth"}},
+		{name: "URL instead of references", endpoint: "https://example.com", expectErr: true},
+		{name: "no endpoint", endpoint: nil, expectErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			service, err := toService(didmanAPI.CompoundService{ID: id, Type: "eOverdracht", ServiceEndpoint: test.endpoint})
+
+			if test.expectErr {
+				assert.ErrorIs(t, err, ErrInvalidCompoundService)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, "did:nuts:sp#1", service.Id)
+			assert.Equal(t, "eOverdracht", service.Name)
+			assert.Equal(t, test.endpoint, service.ServiceEndpoint)
+		})
+	}
+}
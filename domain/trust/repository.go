@@ -0,0 +1,91 @@
+package trust
+
+import (
+	"encoding/binary"
+	"encoding/json"
+
+	"github.com/nuts-foundation/nuts-registry-admin-demo/domain"
+	"go.etcd.io/bbolt"
+)
+
+const policyBucketName = "TrustPolicies"
+
+type Repository interface {
+	// All returns all applied policies, the most recent first.
+	All() ([]domain.TrustPolicyVersion, error)
+	// Get returns the policy with the given version. Returns nil when it doesn't exist.
+	Get(version int) (*domain.TrustPolicyVersion, error)
+	// Add stores the policy as a new version, which is set on the given policy version.
+	Add(policy *domain.TrustPolicyVersion) error
+}
+
+type bboltRepository struct {
+	DB *bbolt.DB
+}
+
+func NewBBoltRepository(db *bbolt.DB) Repository {
+	return &bboltRepository{DB: db}
+}
+
+func (b bboltRepository) All() ([]domain.TrustPolicyVersion, error) {
+	result := []domain.TrustPolicyVersion{}
+	err := b.DB.View(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte(policyBucketName))
+		if b == nil {
+			return nil
+		}
+		// Keys are big endian version numbers, so iterating backwards returns the most recent first
+		cursor := b.Cursor()
+		for key, data := cursor.Last(); key != nil; key, data = cursor.Prev() {
+			var policy domain.TrustPolicyVersion
+			if err := json.Unmarshal(data, &policy); err != nil {
+				return err
+			}
+			result = append(result, policy)
+		}
+		return nil
+	})
+	return result, err
+}
+
+func (b bboltRepository) Get(version int) (*domain.TrustPolicyVersion, error) {
+	var result *domain.TrustPolicyVersion
+	err := b.DB.View(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte(policyBucketName))
+		if b == nil || version < 1 {
+			return nil
+		}
+		data := b.Get(versionKey(uint64(version)))
+		if data == nil {
+			return nil
+		}
+		result = &domain.TrustPolicyVersion{}
+		return json.Unmarshal(data, result)
+	})
+	return result, err
+}
+
+func (b bboltRepository) Add(policy *domain.TrustPolicyVersion) error {
+	return b.DB.Update(func(tx *bbolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists([]byte(policyBucketName))
+		if err != nil {
+			return err
+		}
+		version, err := b.NextSequence()
+		if err != nil {
+			return err
+		}
+		policy.Version = int(version)
+		data, err := json.Marshal(policy)
+		if err != nil {
+			return err
+		}
+		return b.Put(versionKey(version), data)
+	})
+}
+
+func versionKey(version uint64) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, version)
+	return key
+}
@@ -0,0 +1,228 @@
+package trust
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/knadh/koanf"
+	"github.com/knadh/koanf/parsers/yaml"
+	"github.com/knadh/koanf/providers/rawbytes"
+	"github.com/nuts-foundation/go-did/did"
+	"github.com/nuts-foundation/nuts-registry-admin-demo/domain"
+	"github.com/sirupsen/logrus"
+)
+
+var ErrNotFound = errors.New("trust policy not found")
+
+var ErrInvalidPolicy = errors.New("invalid trust policy")
+
+// IssuerTrust lists and changes the issuers the Nuts node trusts per credential type, see credentials.Service.
+type IssuerTrust interface {
+	TrustedIssuers(credentialType string) ([]string, error)
+	SetIssuerTrust(credentialType string, issuer string, trusted bool) error
+}
+
+// Service applies declarative trust policies to the Nuts node, keeping every applied policy as a version.
+type Service struct {
+	CredentialService IssuerTrust
+	Repository        Repository
+	// ManagedCredentialTypes are the credential types of which the trusted issuers are managed,
+	// their issuers are untrusted when rolling back to a version which doesn't list them.
+	ManagedCredentialTypes []string
+}
+
+// ParsePolicy parses a trust policy in YAML or JSON (which is a subset of YAML).
+func ParsePolicy(data []byte) (*domain.TrustPolicy, error) {
+	k := koanf.New(".")
+	if err := k.Load(rawbytes.Provider(data), yaml.Parser()); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidPolicy, err)
+	}
+	// Convert through JSON, so the generated JSON tags are used
+	jsonData, err := json.Marshal(k.Raw())
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidPolicy, err)
+	}
+	policy := domain.TrustPolicy{}
+	if err := json.Unmarshal(jsonData, &policy); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidPolicy, err)
+	}
+	return &policy, nil
+}
+
+// ApplyFile applies the trust policy in the given YAML file, if it differs from the issuers the node trusts.
+func (s Service) ApplyFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("unable to read trust policy file: %w", err)
+	}
+	policy, err := ParsePolicy(data)
+	if err != nil {
+		return err
+	}
+	if err := validatePolicy(*policy); err != nil {
+		return err
+	}
+	changes, err := s.Diff(*policy)
+	if err != nil {
+		return err
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+	_, err = s.Apply(*policy, false)
+	return err
+}
+
+func (s Service) All() ([]domain.TrustPolicyVersion, error) {
+	return s.Repository.All()
+}
+
+func (s Service) Get(version int) (*domain.TrustPolicyVersion, error) {
+	policy, err := s.Repository.Get(version)
+	if err != nil {
+		return nil, err
+	}
+	if policy == nil {
+		return nil, ErrNotFound
+	}
+	return policy, nil
+}
+
+// Apply trusts the issuers of every credential type in the policy and untrusts the other issuers the node trusts for those types.
+// On a dry-run, only the changes are returned. Otherwise the policy is stored as a new version.
+func (s Service) Apply(policy domain.TrustPolicy, dryRun bool) (*domain.TrustPolicyResult, error) {
+	if err := validatePolicy(policy); err != nil {
+		return nil, err
+	}
+	changes, err := s.Diff(policy)
+	if err != nil {
+		return nil, err
+	}
+	return s.apply(policy, changes, dryRun)
+}
+
+// Rollback applies the policy of the given version again, replacing the current policy:
+// the issuers of the credential types which aren't in that version, but are in the current version or are managed, are untrusted.
+func (s Service) Rollback(version int, dryRun bool) (*domain.TrustPolicyResult, error) {
+	target, err := s.Get(version)
+	if err != nil {
+		return nil, err
+	}
+	versions, err := s.Repository.All()
+	if err != nil {
+		return nil, err
+	}
+	// Types of the target come first, so the target's trusted issuers are kept
+	policy := domain.TrustPolicy{CredentialTypes: append([]domain.TrustPolicyCredentialType{}, target.Policy.CredentialTypes...)}
+	listed := map[string]bool{}
+	for _, credentialType := range policy.CredentialTypes {
+		listed[credentialType.Type] = true
+	}
+	otherTypes := append([]string{}, s.ManagedCredentialTypes...)
+	if len(versions) > 0 {
+		for _, credentialType := range versions[0].Policy.CredentialTypes {
+			otherTypes = append(otherTypes, credentialType.Type)
+		}
+	}
+	for _, credentialType := range otherTypes {
+		if len(credentialType) > 0 && !listed[credentialType] {
+			listed[credentialType] = true
+			policy.CredentialTypes = append(policy.CredentialTypes, domain.TrustPolicyCredentialType{Type: credentialType, TrustedIssuers: []string{}})
+		}
+	}
+	changes, err := s.Diff(policy)
+	if err != nil {
+		return nil, err
+	}
+	return s.apply(target.Policy, changes, dryRun)
+}
+
+// apply makes the changes on the Nuts node and stores the policy as a new version, unless it's a dry-run.
+func (s Service) apply(policy domain.TrustPolicy, changes []domain.TrustPolicyChange, dryRun bool) (*domain.TrustPolicyResult, error) {
+	result := domain.TrustPolicyResult{DryRun: dryRun, Changes: changes}
+	if dryRun {
+		return &result, nil
+	}
+
+	for i, change := range changes {
+		if err := s.CredentialService.SetIssuerTrust(change.CredentialType, change.Issuer, change.Trusted); err != nil {
+			return nil, fmt.Errorf("unable to apply trust policy, applied %d of %d changes (type=%s, issuer=%s): %w", i, len(changes), change.CredentialType, change.Issuer, err)
+		}
+	}
+	version := domain.TrustPolicyVersion{Applied: time.Now(), Policy: policy}
+	if err := s.Repository.Add(&version); err != nil {
+		return nil, err
+	}
+	logrus.Infof("Applied trust policy (version=%d, changes=%d)", version.Version, len(changes))
+	result.Version = &version.Version
+	return &result, nil
+}
+
+// Diff compares the policy with the issuers the node trusts, returning the changes needed to apply the policy.
+// The changes are sorted by credential type, issuers to trust coming first.
+func (s Service) Diff(policy domain.TrustPolicy) ([]domain.TrustPolicyChange, error) {
+	changes := []domain.TrustPolicyChange{}
+	credentialTypes := append([]domain.TrustPolicyCredentialType{}, policy.CredentialTypes...)
+	sort.SliceStable(credentialTypes, func(i, j int) bool {
+		return credentialTypes[i].Type < credentialTypes[j].Type
+	})
+	for _, credentialType := range credentialTypes {
+		current, err := s.CredentialService.TrustedIssuers(credentialType.Type)
+		if err != nil {
+			return nil, fmt.Errorf("unable to list trusted issuers (type=%s): %w", credentialType.Type, err)
+		}
+		trusted := toSet(current)
+		wanted := toSet(credentialType.TrustedIssuers)
+		for _, issuer := range sortedKeys(wanted) {
+			if !trusted[issuer] {
+				changes = append(changes, domain.TrustPolicyChange{CredentialType: credentialType.Type, Issuer: issuer, Trusted: true})
+			}
+		}
+		for _, issuer := range sortedKeys(trusted) {
+			if !wanted[issuer] {
+				changes = append(changes, domain.TrustPolicyChange{CredentialType: credentialType.Type, Issuer: issuer, Trusted: false})
+			}
+		}
+	}
+	return changes, nil
+}
+
+func validatePolicy(policy domain.TrustPolicy) error {
+	seen := map[string]bool{}
+	for _, credentialType := range policy.CredentialTypes {
+		if len(credentialType.Type) == 0 {
+			return fmt.Errorf("%w: credential type must be given", ErrInvalidPolicy)
+		}
+		if seen[credentialType.Type] {
+			return fmt.Errorf("%w: credential type is listed more than once: %s", ErrInvalidPolicy, credentialType.Type)
+		}
+		seen[credentialType.Type] = true
+		for _, issuer := range credentialType.TrustedIssuers {
+			if _, err := did.ParseDID(issuer); err != nil {
+				return fmt.Errorf("%w: invalid issuer DID for %s: %s", ErrInvalidPolicy, credentialType.Type, err)
+			}
+		}
+	}
+	return nil
+}
+
+func toSet(values []string) map[string]bool {
+	result := make(map[string]bool, len(values))
+	for _, value := range values {
+		result[value] = true
+	}
+	return result
+}
+
+func sortedKeys(set map[string]bool) []string {
+	result := make([]string, 0, len(set))
+	for key := range set {
+		result = append(result, key)
+	}
+	sort.Strings(result)
+	return result
+}
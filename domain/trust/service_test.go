@@ -0,0 +1,227 @@
+package trust
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/nuts-foundation/nuts-registry-admin-demo/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.etcd.io/bbolt"
+)
+
+const (
+	orgType  = "NutsOrganizationCredential"
+	authType = "NutsAuthorizationCredential"
+	zorgType = "ZorgCredential"
+	issuerA  = "did:nuts:A"
+	issuerB  = "did:nuts:B"
+	issuerC  = "did:nuts:C"
+)
+
+// testNode simulates the issuers the Nuts node trusts per credential type.
+type testNode struct {
+	trusted map[string]map[string]bool
+	// failIssuer makes changing the trust of that issuer fail.
+	failIssuer string
+}
+
+func newTestNode(trusted map[string][]string) *testNode {
+	result := &testNode{trusted: map[string]map[string]bool{}}
+	for credentialType, issuers := range trusted {
+		for _, issuer := range issuers {
+			_ = result.SetIssuerTrust(credentialType, issuer, true)
+		}
+	}
+	return result
+}
+
+func (n *testNode) TrustedIssuers(credentialType string) ([]string, error) {
+	return sortedKeys(n.trusted[credentialType]), nil
+}
+
+func (n *testNode) SetIssuerTrust(credentialType string, issuer string, trusted bool) error {
+	if issuer == n.failIssuer {
+		return errors.New("node unavailable")
+	}
+	if n.trusted[credentialType] == nil {
+		n.trusted[credentialType] = map[string]bool{}
+	}
+	if trusted {
+		n.trusted[credentialType][issuer] = true
+	} else {
+		delete(n.trusted[credentialType], issuer)
+	}
+	return nil
+}
+
+func testService(t *testing.T, node *testNode, managedTypes ...string) Service {
+	db, err := bbolt.Open(filepath.Join(t.TempDir(), "test.db"), 0600, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+	return Service{CredentialService: node, Repository: NewBBoltRepository(db), ManagedCredentialTypes: managedTypes}
+}
+
+func testPolicy(issuers map[string][]string) domain.TrustPolicy {
+	result := domain.TrustPolicy{}
+	for credentialType, trusted := range issuers {
+		result.CredentialTypes = append(result.CredentialTypes, domain.TrustPolicyCredentialType{Type: credentialType, TrustedIssuers: trusted})
+	}
+	return result
+}
+
+func TestService_Diff(t *testing.T) {
+	tests := []struct {
+		name     string
+		trusted  map[string][]string
+		policy   map[string][]string
+		expected []domain.TrustPolicyChange
+	}{
+		{
+			name:     "nothing changes",
+			trusted:  map[string][]string{orgType: {issuerA}},
+			policy:   map[string][]string{orgType: {issuerA}},
+			expected: []domain.TrustPolicyChange{},
+		},
+		{
+			name:    "trust and untrust, sorted by type with trusted issuers first",
+			trusted: map[string][]string{orgType: {issuerA, issuerB}, authType: {issuerA}},
+			policy:  map[string][]string{orgType: {issuerC, issuerA}, authType: {issuerB}},
+			expected: []domain.TrustPolicyChange{
+				{CredentialType: authType, Issuer: issuerB, Trusted: true},
+				{CredentialType: authType, Issuer: issuerA, Trusted: false},
+				{CredentialType: orgType, Issuer: issuerC, Trusted: true},
+				{CredentialType: orgType, Issuer: issuerB, Trusted: false},
+			},
+		},
+		{
+			name:     "types not in the policy are left alone",
+			trusted:  map[string][]string{orgType: {issuerA}, zorgType: {issuerB}},
+			policy:   map[string][]string{orgType: {issuerA}},
+			expected: []domain.TrustPolicyChange{},
+		},
+		{
+			name:     "type without trusted issuers",
+			trusted:  map[string][]string{zorgType: {issuerB}},
+			policy:   map[string][]string{zorgType: {}},
+			expected: []domain.TrustPolicyChange{{CredentialType: zorgType, Issuer: issuerB, Trusted: false}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			service := testService(t, newTestNode(test.trusted))
+
+			changes, err := service.Diff(testPolicy(test.policy))
+
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, changes)
+		})
+	}
+}
+
+func TestService_Apply(t *testing.T) {
+	policy := testPolicy(map[string][]string{orgType: {issuerA, issuerC}})
+
+	t.Run("dry-run", func(t *testing.T) {
+		node := newTestNode(map[string][]string{orgType: {issuerA, issuerB}})
+		service := testService(t, node)
+
+		result, err := service.Apply(policy, true)
+
+		require.NoError(t, err)
+		assert.True(t, result.DryRun)
+		assert.Nil(t, result.Version)
+		assert.Len(t, result.Changes, 2)
+		trusted, _ := node.TrustedIssuers(orgType)
+		assert.Equal(t, []string{issuerA, issuerB}, trusted)
+		versions, _ := service.All()
+		assert.Empty(t, versions)
+	})
+	t.Run("applied and stored as a new version", func(t *testing.T) {
+		node := newTestNode(map[string][]string{orgType: {issuerA, issuerB}})
+		service := testService(t, node)
+
+		result, err := service.Apply(policy, false)
+
+		require.NoError(t, err)
+		require.NotNil(t, result.Version)
+		assert.Equal(t, 1, *result.Version)
+		trusted, _ := node.TrustedIssuers(orgType)
+		assert.Equal(t, []string{issuerA, issuerC}, trusted)
+		stored, err := service.Get(1)
+		require.NoError(t, err)
+		assert.Equal(t, policy, stored.Policy)
+	})
+	t.Run("invalid policy", func(t *testing.T) {
+		service := testService(t, newTestNode(nil))
+
+		_, err := service.Apply(testPolicy(map[string][]string{orgType: {"not a DID"}}), false)
+
+		assert.ErrorIs(t, err, ErrInvalidPolicy)
+	})
+	t.Run("node fails halfway", func(t *testing.T) {
+		node := newTestNode(map[string][]string{orgType: {issuerB}})
+		node.failIssuer = issuerC
+		service := testService(t, node)
+
+		_, err := service.Apply(policy, false)
+
+		assert.EqualError(t, err, "unable to apply trust policy, applied 1 of 3 changes (type=NutsOrganizationCredential, issuer=did:nuts:C): node unavailable")
+		versions, _ := service.All()
+		assert.Empty(t, versions)
+	})
+}
+
+func TestService_Rollback(t *testing.T) {
+	t.Run("types which aren't in the target version are untrusted", func(t *testing.T) {
+		node := newTestNode(nil)
+		service := testService(t, node)
+		target := testPolicy(map[string][]string{orgType: {issuerA}})
+		_, err := service.Apply(target, false)
+		require.NoError(t, err)
+		_, err = service.Apply(testPolicy(map[string][]string{orgType: {issuerB}, zorgType: {issuerC}}), false)
+		require.NoError(t, err)
+
+		result, err := service.Rollback(1, false)
+
+		require.NoError(t, err)
+		assert.Equal(t, []domain.TrustPolicyChange{
+			{CredentialType: orgType, Issuer: issuerA, Trusted: true},
+			{CredentialType: orgType, Issuer: issuerB, Trusted: false},
+			{CredentialType: zorgType, Issuer: issuerC, Trusted: false},
+		}, result.Changes)
+		trusted, _ := node.TrustedIssuers(orgType)
+		assert.Equal(t, []string{issuerA}, trusted)
+		trusted, _ = node.TrustedIssuers(zorgType)
+		assert.Empty(t, trusted)
+		require.NotNil(t, result.Version)
+		stored, err := service.Get(*result.Version)
+		require.NoError(t, err)
+		assert.Equal(t, target, stored.Policy)
+	})
+	t.Run("issuers of managed types the node trusts are untrusted", func(t *testing.T) {
+		node := newTestNode(nil)
+		service := testService(t, node, orgType, authType)
+		_, err := service.Apply(testPolicy(map[string][]string{orgType: {issuerA}}), false)
+		require.NoError(t, err)
+		// Trusted outside of the policy, e.g. using the issuers API
+		require.NoError(t, node.SetIssuerTrust(authType, issuerB, true))
+
+		result, err := service.Rollback(1, true)
+
+		require.NoError(t, err)
+		assert.Equal(t, []domain.TrustPolicyChange{{CredentialType: authType, Issuer: issuerB, Trusted: false}}, result.Changes)
+		trusted, _ := node.TrustedIssuers(authType)
+		assert.Equal(t, []string{issuerB}, trusted, "dry-run shouldn't change the node")
+	})
+	t.Run("unknown version", func(t *testing.T) {
+		service := testService(t, newTestNode(nil))
+
+		_, err := service.Rollback(1, false)
+
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+}
@@ -0,0 +1,132 @@
+package users
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// Account is a user account which can log in to the web interface.
+type Account struct {
+	Username string `json:"username"`
+	// PasswordHash is the bcrypt hash of the password.
+	PasswordHash string `json:"passwordHash"`
+}
+
+type Repository interface {
+	// All returns all accounts, sorted by username.
+	All() ([]Account, error)
+	// Find returns the account with the given username. Returns nil when it doesn't exist.
+	Find(username string) (*Account, error)
+	// Save stores the account, replacing the account with the same username.
+	Save(account Account) error
+}
+
+type flatFileRepo struct {
+	filepath string
+	mutex    sync.Mutex
+}
+
+// NewFlatFileRepository returns a repository which stores the accounts in a JSON file. The file is created when the first account is saved.
+// The file is read on every call, so accounts added while the server is running can log in right away.
+func NewFlatFileRepository(filepath string) Repository {
+	return &flatFileRepo{filepath: filepath}
+}
+
+func (db *flatFileRepo) All() ([]Account, error) {
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+
+	records, err := db.readAll()
+	if err != nil {
+		return nil, err
+	}
+	result := make([]Account, 0, len(records))
+	for _, account := range records {
+		result = append(result, account)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Username < result[j].Username
+	})
+	return result, nil
+}
+
+func (db *flatFileRepo) Find(username string) (*Account, error) {
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+
+	records, err := db.readAll()
+	if err != nil {
+		return nil, err
+	}
+	account, ok := records[username]
+	if !ok {
+		return nil, nil
+	}
+	return &account, nil
+}
+
+func (db *flatFileRepo) Save(account Account) error {
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+
+	records, err := db.readAll()
+	if err != nil {
+		return err
+	}
+	records[account.Username] = account
+	bytes, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return fmt.Errorf("unable to marshal user accounts to json: %w", err)
+	}
+	if err = db.writeFile(bytes); err != nil {
+		return fmt.Errorf("unable to write user accounts to file: %w", err)
+	}
+	return nil
+}
+
+// writeFile writes the data to a temporary file which then replaces the file,
+// so the file isn't left truncated when writing fails, and readers never see a partially written file.
+func (db *flatFileRepo) writeFile(data []byte) error {
+	// The file contains password hashes, so it's only readable by the owner (which CreateTemp takes care of)
+	file, err := os.CreateTemp(filepath.Dir(db.filepath), filepath.Base(db.filepath)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(data)
+	if err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(file.Name(), db.filepath)
+	}
+	if err != nil {
+		_ = os.Remove(file.Name())
+	}
+	return err
+}
+
+func (db *flatFileRepo) readAll() (map[string]Account, error) {
+	records := map[string]Account{}
+	bytes, err := os.ReadFile(db.filepath)
+	if errors.Is(err, os.ErrNotExist) {
+		return records, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to read user accounts from file: %w", err)
+	}
+	if len(bytes) == 0 {
+		return records, nil
+	}
+	if err = json.Unmarshal(bytes, &records); err != nil {
+		return nil, fmt.Errorf("unable to unmarshal user accounts from file: %w", err)
+	}
+	return records, nil
+}
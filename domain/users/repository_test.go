@@ -0,0 +1,77 @@
+package users
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFlatFileRepo(t *testing.T) {
+	t.Run("file doesn't exist yet", func(t *testing.T) {
+		repository := NewFlatFileRepository(filepath.Join(t.TempDir(), "users.json"))
+
+		all, err := repository.All()
+		require.NoError(t, err)
+		assert.Empty(t, all)
+		account, err := repository.Find("admin")
+		require.NoError(t, err)
+		assert.Nil(t, account)
+	})
+	t.Run("saved accounts are sorted by username", func(t *testing.T) {
+		file := filepath.Join(t.TempDir(), "users.json")
+		repository := NewFlatFileRepository(file)
+
+		require.NoError(t, repository.Save(Account{Username: "zoe", PasswordHash: "hash1"}))
+		require.NoError(t, repository.Save(Account{Username: "admin", PasswordHash: "hash2"}))
+
+		// Read by another repository, like the server does for accounts added using the command line
+		all, err := NewFlatFileRepository(file).All()
+		require.NoError(t, err)
+		assert.Equal(t, []Account{{Username: "admin", PasswordHash: "hash2"}, {Username: "zoe", PasswordHash: "hash1"}}, all)
+	})
+	t.Run("save replaces the account", func(t *testing.T) {
+		repository := NewFlatFileRepository(filepath.Join(t.TempDir(), "users.json"))
+		require.NoError(t, repository.Save(Account{Username: "admin", PasswordHash: "old"}))
+
+		require.NoError(t, repository.Save(Account{Username: "admin", PasswordHash: "new"}))
+
+		account, err := repository.Find("admin")
+		require.NoError(t, err)
+		assert.Equal(t, "new", account.PasswordHash)
+	})
+	t.Run("file is only readable by the owner and no temporary files are left", func(t *testing.T) {
+		dir := t.TempDir()
+		repository := NewFlatFileRepository(filepath.Join(dir, "users.json"))
+
+		require.NoError(t, repository.Save(Account{Username: "admin", PasswordHash: "hash"}))
+		require.NoError(t, repository.Save(Account{Username: "other", PasswordHash: "hash"}))
+
+		info, err := os.Stat(filepath.Join(dir, "users.json"))
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+		entries, err := os.ReadDir(dir)
+		require.NoError(t, err)
+		assert.Len(t, entries, 1)
+	})
+	t.Run("write fails", func(t *testing.T) {
+		repository := NewFlatFileRepository(filepath.Join(t.TempDir(), "missing", "users.json"))
+
+		err := repository.Save(Account{Username: "admin", PasswordHash: "hash"})
+
+		assert.ErrorContains(t, err, "unable to write user accounts to file")
+	})
+	t.Run("invalid file isn't overwritten", func(t *testing.T) {
+		file := filepath.Join(t.TempDir(), "users.json")
+		require.NoError(t, os.WriteFile(file, []byte("not json"), 0600))
+		repository := NewFlatFileRepository(file)
+
+		err := repository.Save(Account{Username: "admin", PasswordHash: "hash"})
+
+		assert.ErrorContains(t, err, "unable to unmarshal user accounts from file")
+		data, _ := os.ReadFile(file)
+		assert.Equal(t, "not json", string(data))
+	})
+}
@@ -0,0 +1,85 @@
+package users
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var ErrNotFound = errors.New("user not found")
+
+var ErrExists = errors.New("user already exists")
+
+var ErrInvalidAccount = errors.New("invalid user account")
+
+// minPasswordLength is the minimum number of characters of a password.
+const minPasswordLength = 8
+
+// Service manages the user accounts which are stored besides the account in the config.
+type Service struct {
+	Repository Repository
+}
+
+// Add creates an account with the given username and password.
+func (s Service) Add(username, password string) error {
+	if err := validateAccount(username, password); err != nil {
+		return err
+	}
+	existing, err := s.Repository.Find(username)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		return fmt.Errorf("%w: %s", ErrExists, username)
+	}
+	return s.save(username, password)
+}
+
+// SetPassword changes the password of an existing account.
+func (s Service) SetPassword(username, password string) error {
+	if err := validateAccount(username, password); err != nil {
+		return err
+	}
+	existing, err := s.Repository.Find(username)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return fmt.Errorf("%w: %s", ErrNotFound, username)
+	}
+	return s.save(username, password)
+}
+
+// CheckCredentials returns whether an account with the given username and password exists.
+func (s Service) CheckCredentials(username, password string) bool {
+	account, err := s.Repository.Find(username)
+	if err != nil {
+		logrus.Errorf("Unable to find user account (username=%s): %v", username, err)
+		return false
+	}
+	if account == nil {
+		return false
+	}
+	return bcrypt.CompareHashAndPassword([]byte(account.PasswordHash), []byte(password)) == nil
+}
+
+func (s Service) save(username, password string) error {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	return s.Repository.Save(Account{Username: username, PasswordHash: string(hash)})
+}
+
+func validateAccount(username, password string) error {
+	if len(strings.TrimSpace(username)) == 0 {
+		return fmt.Errorf("%w: username must be given", ErrInvalidAccount)
+	}
+	if len(password) < minPasswordLength {
+		return fmt.Errorf("%w: password must have at least %d characters", ErrInvalidAccount, minPasswordLength)
+	}
+	return nil
+}
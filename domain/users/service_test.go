@@ -0,0 +1,92 @@
+package users
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testService(t *testing.T) Service {
+	return Service{Repository: NewFlatFileRepository(filepath.Join(t.TempDir(), "users.json"))}
+}
+
+func TestService_Add(t *testing.T) {
+	tests := []struct {
+		name        string
+		username    string
+		password    string
+		expectedErr error
+	}{
+		{name: "added", username: "zoe", password: "correct horse"},
+		{name: "already exists", username: "admin", password: "correct horse", expectedErr: ErrExists},
+		{name: "no username", username: " ", password: "correct horse", expectedErr: ErrInvalidAccount},
+		{name: "password too short", username: "zoe", password: "short", expectedErr: ErrInvalidAccount},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			service := testService(t)
+			require.NoError(t, service.Add("admin", "battery staple"))
+
+			err := service.Add(test.username, test.password)
+
+			if test.expectedErr != nil {
+				assert.ErrorIs(t, err, test.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.True(t, service.CheckCredentials(test.username, test.password))
+			account, err := service.Repository.Find(test.username)
+			require.NoError(t, err)
+			assert.NotEqual(t, test.password, account.PasswordHash, "password must be hashed")
+		})
+	}
+}
+
+func TestService_SetPassword(t *testing.T) {
+	t.Run("changed", func(t *testing.T) {
+		service := testService(t)
+		require.NoError(t, service.Add("admin", "battery staple"))
+
+		require.NoError(t, service.SetPassword("admin", "correct horse"))
+
+		assert.True(t, service.CheckCredentials("admin", "correct horse"))
+		assert.False(t, service.CheckCredentials("admin", "battery staple"))
+	})
+	t.Run("unknown user", func(t *testing.T) {
+		service := testService(t)
+
+		err := service.SetPassword("admin", "correct horse")
+
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+	t.Run("password too short", func(t *testing.T) {
+		service := testService(t)
+		require.NoError(t, service.Add("admin", "battery staple"))
+
+		err := service.SetPassword("admin", "short")
+
+		assert.ErrorIs(t, err, ErrInvalidAccount)
+		assert.True(t, service.CheckCredentials("admin", "battery staple"))
+	})
+}
+
+func TestService_CheckCredentials(t *testing.T) {
+	service := testService(t)
+	require.NoError(t, service.Add("admin", "battery staple"))
+
+	assert.True(t, service.CheckCredentials("admin", "battery staple"))
+	assert.False(t, service.CheckCredentials("admin", "Battery staple"))
+	assert.False(t, service.CheckCredentials("other", "battery staple"))
+	assert.False(t, service.CheckCredentials("", ""))
+
+	t.Run("file can't be read", func(t *testing.T) {
+		file := filepath.Join(t.TempDir(), "users.json")
+		require.NoError(t, os.WriteFile(file, []byte("not json"), 0600))
+		service := Service{Repository: NewFlatFileRepository(file)}
+
+		assert.False(t, service.CheckCredentials("admin", "battery staple"))
+	})
+}
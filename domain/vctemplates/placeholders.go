@@ -0,0 +1,125 @@
+package vctemplates
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/nuts-foundation/nuts-registry-admin-demo/domain"
+)
+
+var ErrUnresolvedPlaceholders = errors.New("placeholders can't be replaced")
+
+var ErrCustomerWithoutDID = errors.New("customer isn't connected to a DID")
+
+// placeholderPattern matches placeholders like <name> or <Name of the organization> in string values of a credentialSubject.
+// Colons and slashes aren't allowed, so IRIs like <http://example.com> (e.g. in SPARQL queries) aren't taken for placeholders.
+var placeholderPattern = regexp.MustCompile(`<\s*([A-Za-z0-9_.-]+(?: [A-Za-z0-9_.-]+)*)\s*>`)
+
+// placeholderAliases maps the descriptive placeholders of the built-in templates (in lower case) to the name of their value.
+var placeholderAliases = map[string]string{
+	"name of the organization":     "name",
+	"locality of the organization": "city",
+	"sparql query to be performed": "sparql",
+}
+
+// IssueRequest creates the request for issuing a VC from the template for the VC type, issued by the given issuer to the customer.
+// Placeholders in the credentialSubject of the template are replaced with the customer's fields (name, city, domain and did)
+// or the given parameters, which take precedence. The descriptive placeholders of the built-in templates, like
+// <Name of the organization>, are filled with the value they describe (e.g. name). The resulting credentialSubject is validated against the template's JSON Schema.
+func (s Service) IssueRequest(credentialType string, issuer string, customer domain.Customer, parameters map[string]interface{}) (*domain.IssueVCRequest, error) {
+	template, err := s.Get(credentialType)
+	if err != nil {
+		return nil, err
+	}
+	if customer.Did == nil {
+		return nil, ErrCustomerWithoutDID
+	}
+
+	values := customerValues(customer)
+	for name, value := range parameters {
+		values[name] = fmt.Sprint(value)
+	}
+	unresolved := map[string]bool{}
+	subject, _ := replacePlaceholders(template.CredentialSubject, values, unresolved).(map[string]interface{})
+	if len(unresolved) > 0 {
+		names := make([]string, 0, len(unresolved))
+		for name := range unresolved {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("%w: %s", ErrUnresolvedPlaceholders, strings.Join(names, ", "))
+	}
+	if subject == nil {
+		subject = map[string]interface{}{}
+	}
+	subject["id"] = *customer.Did
+
+	if err := s.ValidateSubject(credentialType, subject); err != nil {
+		return nil, err
+	}
+
+	visibility := domain.IssueVCRequestVisibility(template.Visibility)
+	publishToNetwork := template.PublishToNetwork
+	request := domain.IssueVCRequest{
+		Type:              template.Type,
+		Issuer:            issuer,
+		CredentialSubject: subject,
+		PublishToNetwork:  &publishToNetwork,
+		Visibility:        &visibility,
+	}
+	if len(template.Context) > 0 {
+		request.Context = &template.Context
+	}
+	return &request, nil
+}
+
+// customerValues returns the values of the placeholders which are filled from the customer's fields.
+func customerValues(customer domain.Customer) map[string]string {
+	result := map[string]string{"name": customer.Name}
+	if customer.City != nil {
+		result["city"] = *customer.City
+	}
+	if customer.Domain != nil {
+		result["domain"] = *customer.Domain
+	}
+	if customer.Did != nil {
+		result["did"] = *customer.Did
+	}
+	return result
+}
+
+// replacePlaceholders returns a copy of the value in which the placeholders in all strings are replaced.
+// The names of placeholders without value are added to unresolved.
+func replacePlaceholders(value interface{}, values map[string]string, unresolved map[string]bool) interface{} {
+	switch typed := value.(type) {
+	case string:
+		return placeholderPattern.ReplaceAllStringFunc(typed, func(placeholder string) string {
+			name := placeholderPattern.FindStringSubmatch(placeholder)[1]
+			replacement, ok := values[name]
+			if !ok {
+				replacement, ok = values[placeholderAliases[strings.ToLower(name)]]
+			}
+			if !ok {
+				unresolved[name] = true
+				return placeholder
+			}
+			return replacement
+		})
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(typed))
+		for key, curr := range typed {
+			result[key] = replacePlaceholders(curr, values, unresolved)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(typed))
+		for i, curr := range typed {
+			result[i] = replacePlaceholders(curr, values, unresolved)
+		}
+		return result
+	}
+	return value
+}
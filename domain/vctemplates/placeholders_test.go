@@ -0,0 +1,97 @@
+package vctemplates
+
+import (
+	"testing"
+
+	"github.com/nuts-foundation/nuts-registry-admin-demo/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReplacePlaceholders(t *testing.T) {
+	values := map[string]string{"name": "Care", "city": "Utrecht", "sparql": "SELECT ?s WHERE { ?s ?p ?o }"}
+	tests := []struct {
+		name               string
+		value              interface{}
+		expected           interface{}
+		expectedUnresolved []string
+	}{
+		{name: "named placeholder", value: "<name>", expected: "Care"},
+		{name: "with spaces", value: "< name >", expected: "Care"},
+		{name: "within text", value: "<name> in <city>", expected: "Care in Utrecht"},
+		{name: "descriptive placeholder", value: "<Name of the organization>", expected: "Care"},
+		{name: "descriptive placeholder is case insensitive", value: "<locality of the organization>", expected: "Utrecht"},
+		{name: "IRI isn't a placeholder", value: "SELECT ?s WHERE { ?s a <http://example.com/Organization> }", expected: "SELECT ?s WHERE { ?s a <http://example.com/Organization> }"},
+		{name: "unresolved", value: "<unknown> in <city>", expected: "<unknown> in Utrecht", expectedUnresolved: []string{"unknown"}},
+		{name: "not a string", value: 42, expected: 42},
+		{
+			name:     "nested",
+			value:    map[string]interface{}{"organization": map[string]interface{}{"name": "<name>", "tags": []interface{}{"<city>", true}}},
+			expected: map[string]interface{}{"organization": map[string]interface{}{"name": "Care", "tags": []interface{}{"Utrecht", true}}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			unresolved := map[string]bool{}
+
+			result := replacePlaceholders(test.value, values, unresolved)
+
+			assert.Equal(t, test.expected, result)
+			var names []string
+			for name := range unresolved {
+				names = append(names, name)
+			}
+			assert.Equal(t, test.expectedUnresolved, names)
+		})
+	}
+}
+
+func TestService_IssueRequest(t *testing.T) {
+	stringPtr := func(value string) *string {
+		return &value
+	}
+	customer := domain.Customer{Id: 1, Name: "Care", City: stringPtr("Utrecht"), Did: stringPtr("did:nuts:1")}
+
+	t.Run("built-in template", func(t *testing.T) {
+		request, err := testService(t).IssueRequest("NutsOrganizationCredential", "did:nuts:sp", customer, nil)
+
+		require.NoError(t, err)
+		assert.Equal(t, "did:nuts:sp", request.Issuer)
+		assert.Equal(t, domain.CredentialSubject{
+			"id":           "did:nuts:1",
+			"organization": map[string]interface{}{"name": "Care", "city": "Utrecht"},
+		}, request.CredentialSubject)
+		assert.Equal(t, domain.IssueVCRequestVisibilityPublic, *request.Visibility)
+	})
+	t.Run("parameters take precedence", func(t *testing.T) {
+		request, err := testService(t).IssueRequest("NutsOrganizationCredential", "did:nuts:sp", customer, map[string]interface{}{"city": "Amsterdam"})
+
+		require.NoError(t, err)
+		assert.Equal(t, "Amsterdam", request.CredentialSubject["organization"].(map[string]interface{})["city"])
+	})
+	t.Run("unresolved placeholder", func(t *testing.T) {
+		_, err := testService(t).IssueRequest("ValidatedQueryCredential", "did:nuts:sp", customer, nil)
+
+		assert.ErrorIs(t, err, ErrUnresolvedPlaceholders)
+		assert.EqualError(t, err, "placeholders can't be replaced: SPARQL query to be performed")
+	})
+	t.Run("customer without DID", func(t *testing.T) {
+		_, err := testService(t).IssueRequest("NutsOrganizationCredential", "did:nuts:sp", domain.Customer{Id: 2, Name: "Care"}, nil)
+
+		assert.ErrorIs(t, err, ErrCustomerWithoutDID)
+	})
+	t.Run("customer without city", func(t *testing.T) {
+		withoutCity := domain.Customer{Id: 2, Name: "Care", Did: stringPtr("did:nuts:2")}
+
+		_, err := testService(t).IssueRequest("NutsOrganizationCredential", "did:nuts:sp", withoutCity, nil)
+
+		assert.EqualError(t, err, "placeholders can't be replaced: Locality of the organization")
+	})
+	t.Run("subject doesn't conform to the schema", func(t *testing.T) {
+		_, err := testService(t).IssueRequest("NutsOrganizationCredential", "did:nuts:sp", customer, map[string]interface{}{"name": ""})
+
+		var subjectErr SubjectError
+		require.ErrorAs(t, err, &subjectErr)
+		assert.Equal(t, []domain.FieldError{{Field: "/organization/name", Message: "length must be >= 1, but got 0"}}, subjectErr.Fields)
+	})
+}
@@ -0,0 +1,88 @@
+package vctemplates
+
+import (
+	"encoding/json"
+
+	"github.com/nuts-foundation/nuts-registry-admin-demo/domain"
+	"go.etcd.io/bbolt"
+)
+
+const templateBucketName = "VCTemplates"
+
+type Repository interface {
+	// All returns all templates, sorted by VC type.
+	All() ([]domain.VCTemplate, error)
+	// Get returns the template for the given VC type. Returns nil when it doesn't exist.
+	Get(credentialType string) (*domain.VCTemplate, error)
+	Save(template domain.VCTemplate) error
+	Delete(credentialType string) error
+}
+
+type bboltRepository struct {
+	DB *bbolt.DB
+}
+
+func NewBBoltRepository(db *bbolt.DB) Repository {
+	return &bboltRepository{DB: db}
+}
+
+func (b bboltRepository) All() ([]domain.VCTemplate, error) {
+	result := []domain.VCTemplate{}
+	err := b.DB.View(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte(templateBucketName))
+		if b == nil {
+			return nil
+		}
+		// bbolt iterates in key order, so the templates are sorted by VC type
+		return b.ForEach(func(_, data []byte) error {
+			var template domain.VCTemplate
+			if err := json.Unmarshal(data, &template); err != nil {
+				return err
+			}
+			result = append(result, template)
+			return nil
+		})
+	})
+	return result, err
+}
+
+func (b bboltRepository) Get(credentialType string) (*domain.VCTemplate, error) {
+	var result *domain.VCTemplate
+	err := b.DB.View(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte(templateBucketName))
+		if b == nil {
+			return nil
+		}
+		data := b.Get([]byte(credentialType))
+		if data == nil {
+			return nil
+		}
+		result = &domain.VCTemplate{}
+		return json.Unmarshal(data, result)
+	})
+	return result, err
+}
+
+func (b bboltRepository) Save(template domain.VCTemplate) error {
+	data, err := json.Marshal(template)
+	if err != nil {
+		return err
+	}
+	return b.DB.Update(func(tx *bbolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists([]byte(templateBucketName))
+		if err != nil {
+			return err
+		}
+		return b.Put([]byte(template.Type), data)
+	})
+}
+
+func (b bboltRepository) Delete(credentialType string) error {
+	return b.DB.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte(templateBucketName))
+		if b == nil {
+			return nil
+		}
+		return b.Delete([]byte(credentialType))
+	})
+}
@@ -0,0 +1,213 @@
+package vctemplates
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/knadh/koanf"
+	"github.com/knadh/koanf/parsers/yaml"
+	"github.com/knadh/koanf/providers/rawbytes"
+	"github.com/nuts-foundation/nuts-registry-admin-demo/domain"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+//go:embed templates.yaml
+var defaultTemplates []byte
+
+// schemaURL is the URL under which the JSON Schema of a template is compiled. It's never resolved.
+const schemaURL = "credentialSubject.schema.json"
+
+var ErrNotFound = errors.New("VC template not found")
+
+var ErrExists = errors.New("VC template already exists")
+
+var ErrInvalidTemplate = errors.New("invalid VC template")
+
+// SubjectError is returned when a credentialSubject doesn't conform to the JSON Schema of the template for its VC type.
+type SubjectError struct {
+	CredentialType string
+	Fields         []domain.FieldError
+}
+
+func (e SubjectError) Error() string {
+	descriptions := make([]string, len(e.Fields))
+	for i, field := range e.Fields {
+		descriptions[i] = fmt.Sprintf("%s (%s)", field.Field, field.Message)
+	}
+	return fmt.Sprintf("credentialSubject doesn't conform to the template of %s: %s", e.CredentialType, strings.Join(descriptions, ", "))
+}
+
+// Service manages the templates of the VCs that can be issued.
+type Service struct {
+	Repository Repository
+}
+
+// LoadDefaults stores the built-in templates when no templates have been stored yet.
+func (s Service) LoadDefaults() error {
+	existing, err := s.Repository.All()
+	if err != nil {
+		return err
+	}
+	if len(existing) > 0 {
+		return nil
+	}
+	k := koanf.New(".")
+	if err := k.Load(rawbytes.Provider(defaultTemplates), yaml.Parser()); err != nil {
+		return fmt.Errorf("unable to load default VC templates: %w", err)
+	}
+	templates := struct {
+		Templates []domain.VCTemplate `json:"templates"`
+	}{}
+	if err := k.UnmarshalWithConf("", &templates, koanf.UnmarshalConf{Tag: "json"}); err != nil {
+		return fmt.Errorf("unable to unmarshal default VC templates: %w", err)
+	}
+	for _, template := range templates.Templates {
+		if err := s.Create(template); err != nil {
+			return fmt.Errorf("unable to store default VC template (type=%s): %w", template.Type, err)
+		}
+	}
+	return nil
+}
+
+func (s Service) All() ([]domain.VCTemplate, error) {
+	return s.Repository.All()
+}
+
+func (s Service) Get(credentialType string) (*domain.VCTemplate, error) {
+	template, err := s.Repository.Get(credentialType)
+	if err != nil {
+		return nil, err
+	}
+	if template == nil {
+		return nil, ErrNotFound
+	}
+	return template, nil
+}
+
+func (s Service) Create(template domain.VCTemplate) error {
+	if err := validateTemplate(template); err != nil {
+		return err
+	}
+	existing, err := s.Repository.Get(template.Type)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		return ErrExists
+	}
+	return s.Repository.Save(template)
+}
+
+func (s Service) Update(credentialType string, template domain.VCTemplate) error {
+	if template.Type != credentialType {
+		return fmt.Errorf("%w: type can't be changed", ErrInvalidTemplate)
+	}
+	if err := validateTemplate(template); err != nil {
+		return err
+	}
+	if _, err := s.Get(credentialType); err != nil {
+		return err
+	}
+	return s.Repository.Save(template)
+}
+
+func (s Service) Delete(credentialType string) error {
+	if _, err := s.Get(credentialType); err != nil {
+		return err
+	}
+	return s.Repository.Delete(credentialType)
+}
+
+// ValidateSubject validates the credentialSubject against the JSON Schema of the template for the VC type.
+// VC types without template or without JSON Schema aren't validated.
+func (s Service) ValidateSubject(credentialType string, credentialSubject interface{}) error {
+	template, err := s.Repository.Get(credentialType)
+	if err != nil {
+		return err
+	}
+	if template == nil || template.CredentialSubjectSchema == nil {
+		return nil
+	}
+	schema, err := compileSchema(*template.CredentialSubjectSchema)
+	if err != nil {
+		return err
+	}
+	// The schema validates JSON values, so convert structs to maps first
+	data, err := json.Marshal(credentialSubject)
+	if err != nil {
+		return err
+	}
+	var subject interface{}
+	if err := json.Unmarshal(data, &subject); err != nil {
+		return err
+	}
+
+	var validationErr *jsonschema.ValidationError
+	if err := schema.Validate(subject); errors.As(err, &validationErr) {
+		return SubjectError{CredentialType: credentialType, Fields: fieldErrors(validationErr)}
+	} else if err != nil {
+		return err
+	}
+	return nil
+}
+
+func validateTemplate(template domain.VCTemplate) error {
+	if len(template.Type) == 0 {
+		return fmt.Errorf("%w: type must be given", ErrInvalidTemplate)
+	}
+	if template.Visibility != domain.VCTemplateVisibilityPublic && template.Visibility != domain.VCTemplateVisibilityPrivate {
+		return fmt.Errorf("%w: visibility must be public or private", ErrInvalidTemplate)
+	}
+	if template.CredentialSubjectSchema != nil {
+		if _, err := compileSchema(*template.CredentialSubjectSchema); err != nil {
+			return fmt.Errorf("%w: invalid credentialSubjectSchema: %s", ErrInvalidTemplate, err)
+		}
+	}
+	return nil
+}
+
+func compileSchema(schema map[string]interface{}) (*jsonschema.Schema, error) {
+	data, err := json.Marshal(schema)
+	if err != nil {
+		return nil, err
+	}
+	compiler := jsonschema.NewCompiler()
+	// Don't resolve references to other schemas, since those could point to local files or other servers
+	compiler.LoadURL = func(url string) (io.ReadCloser, error) {
+		return nil, fmt.Errorf("references to other schemas aren't supported: %s", url)
+	}
+	if err := compiler.AddResource(schemaURL, bytes.NewReader(data)); err != nil {
+		return nil, err
+	}
+	return compiler.Compile(schemaURL)
+}
+
+// fieldErrors returns the causes of the validation error which don't have causes themselves, sorted by field.
+func fieldErrors(validationErr *jsonschema.ValidationError) []domain.FieldError {
+	var result []domain.FieldError
+	var collect func(curr *jsonschema.ValidationError)
+	collect = func(curr *jsonschema.ValidationError) {
+		if len(curr.Causes) == 0 {
+			field := curr.InstanceLocation
+			if len(field) == 0 {
+				field = "/"
+			}
+			result = append(result, domain.FieldError{Field: field, Message: curr.Message})
+			return
+		}
+		for _, cause := range curr.Causes {
+			collect(cause)
+		}
+	}
+	collect(validationErr)
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Field < result[j].Field
+	})
+	return result
+}
@@ -0,0 +1,125 @@
+package vctemplates
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/nuts-foundation/nuts-registry-admin-demo/domain"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.etcd.io/bbolt"
+)
+
+// testService returns a service with the built-in templates, and a template without JSON Schema.
+func testService(t *testing.T) Service {
+	db, err := bbolt.Open(filepath.Join(t.TempDir(), "test.db"), 0600, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+	service := Service{Repository: NewBBoltRepository(db)}
+	require.NoError(t, service.LoadDefaults())
+	require.NoError(t, service.Create(domain.VCTemplate{Type: "WithoutSchema", Visibility: domain.VCTemplateVisibilityPrivate}))
+	return service
+}
+
+func TestService_ValidateSubject(t *testing.T) {
+	tests := []struct {
+		name           string
+		credentialType string
+		subject        interface{}
+		expectedFields []domain.FieldError
+	}{
+		{
+			name:           "valid",
+			credentialType: "NutsOrganizationCredential",
+			subject:        map[string]interface{}{"id": "did:nuts:1", "organization": map[string]interface{}{"name": "Care", "city": "Utrecht"}},
+		},
+		{
+			name:           "struct",
+			credentialType: "NutsOrganizationCredential",
+			subject:        domain.NutsOrganizationCredentialSubject{ID: "did:nuts:1", Organization: domain.Organization{Name: "Care", City: "Utrecht"}},
+		},
+		{
+			name:           "missing field",
+			credentialType: "NutsOrganizationCredential",
+			subject:        map[string]interface{}{"id": "did:nuts:1", "organization": map[string]interface{}{"name": "Care"}},
+			expectedFields: []domain.FieldError{{Field: "/organization", Message: "missing properties: 'city'"}},
+		},
+		{
+			name:           "invalid fields are sorted",
+			credentialType: "NutsOrganizationCredential",
+			subject:        map[string]interface{}{"id": "did:web:1", "organization": map[string]interface{}{"name": "", "city": 42}},
+			expectedFields: []domain.FieldError{
+				{Field: "/id", Message: "does not match pattern '^did:nuts:'"},
+				{Field: "/organization/city", Message: "expected string, but got number"},
+				{Field: "/organization/name", Message: "length must be >= 1, but got 0"},
+			},
+		},
+		{
+			name:           "not an object",
+			credentialType: "NutsOrganizationCredential",
+			subject:        "subject",
+			expectedFields: []domain.FieldError{{Field: "/", Message: "expected object, but got string"}},
+		},
+		{
+			name:           "template without schema",
+			credentialType: "WithoutSchema",
+			subject:        "subject",
+		},
+		{
+			name:           "no template",
+			credentialType: "UnknownCredential",
+			subject:        "subject",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := testService(t).ValidateSubject(test.credentialType, test.subject)
+
+			if len(test.expectedFields) == 0 {
+				assert.NoError(t, err)
+				return
+			}
+			var subjectErr SubjectError
+			require.True(t, errors.As(err, &subjectErr), "expected SubjectError, got %v", err)
+			assert.Equal(t, test.credentialType, subjectErr.CredentialType)
+			assert.Equal(t, test.expectedFields, subjectErr.Fields)
+		})
+	}
+}
+
+func TestFieldErrors(t *testing.T) {
+	validationErr := &jsonschema.ValidationError{
+		Message: "doesn't validate",
+		Causes: []*jsonschema.ValidationError{
+			{InstanceLocation: "/b", Message: "second"},
+			{
+				Message: "nested",
+				Causes: []*jsonschema.ValidationError{
+					{InstanceLocation: "/a/c", Message: "first"},
+					{InstanceLocation: "", Message: "root"},
+				},
+			},
+		},
+	}
+
+	fields := fieldErrors(validationErr)
+
+	assert.Equal(t, []domain.FieldError{
+		{Field: "/", Message: "root"},
+		{Field: "/a/c", Message: "first"},
+		{Field: "/b", Message: "second"},
+	}, fields)
+}
+
+func TestSubjectError_Error(t *testing.T) {
+	err := SubjectError{CredentialType: "NutsOrganizationCredential", Fields: []domain.FieldError{
+		{Field: "/id", Message: "invalid"},
+		{Field: "/organization", Message: "missing properties: 'city'"},
+	}}
+
+	assert.EqualError(t, err, "credentialSubject doesn't conform to the template of NutsOrganizationCredential: /id (invalid), /organization (missing properties: 'city')")
+}
@@ -3,9 +3,8 @@ package main
 import (
 	"crypto"
 	"crypto/ecdsa"
-	"crypto/elliptic"
+	"crypto/rand"
 	"crypto/rsa"
-	"crypto/sha1"
 	"embed"
 	"encoding/hex"
 	"errors"
@@ -15,7 +14,6 @@ import (
 	"github.com/lestrrat-go/jwx/jwt"
 	"github.com/nuts-foundation/go-did/did"
 	"github.com/nuts-foundation/nuts-node/core"
-	vcrApi "github.com/nuts-foundation/nuts-node/vcr/api/vcr/v2"
 	"io/fs"
 	"log"
 	"net/http"
@@ -23,16 +21,22 @@ import (
 	"strings"
 	"time"
 
-	didmanAPI "github.com/nuts-foundation/nuts-node/didman/api/v1"
-	vdrAPI "github.com/nuts-foundation/nuts-node/vdr/api/v1"
 	"github.com/nuts-foundation/nuts-registry-admin-demo/domain/sp"
+	"github.com/nuts-foundation/nuts-registry-admin-demo/domain/trust"
 
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 	"github.com/lestrrat-go/jwx/jwa"
 	"github.com/nuts-foundation/nuts-registry-admin-demo/api"
+	"github.com/nuts-foundation/nuts-registry-admin-demo/domain"
 	"github.com/nuts-foundation/nuts-registry-admin-demo/domain/credentials"
 	"github.com/nuts-foundation/nuts-registry-admin-demo/domain/customers"
+	"github.com/nuts-foundation/nuts-registry-admin-demo/domain/directory"
+	"github.com/nuts-foundation/nuts-registry-admin-demo/domain/jobs"
+	"github.com/nuts-foundation/nuts-registry-admin-demo/domain/reconcile"
+	"github.com/nuts-foundation/nuts-registry-admin-demo/domain/rollout"
+	"github.com/nuts-foundation/nuts-registry-admin-demo/domain/users"
+	"github.com/nuts-foundation/nuts-registry-admin-demo/domain/vctemplates"
 	bolt "go.etcd.io/bbolt"
 )
 
@@ -41,8 +45,6 @@ const assetPath = "web/dist"
 //go:embed web/dist/*
 var embeddedFiles embed.FS
 
-const apiTimeout = 10 * time.Second
-
 func getFileSystem(useFS bool) http.FileSystem {
 	if useFS {
 		log.Print("using live mode")
@@ -59,8 +61,18 @@ func getFileSystem(useFS bool) http.FileSystem {
 }
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "validate-config" {
+		os.Exit(validateConfigCommand(os.Args[2:], os.Stdout))
+	}
+	if len(os.Args) > 1 && cliCommands[os.Args[1]] != nil {
+		os.Exit(runCLI(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+	}
 	config := loadConfig()
 	config.Print(log.Writer())
+	applyLogLevel(config.LogLevel)
+	if err := checkNutsNode(config.NutsNodeAddress); err != nil {
+		log.Printf("Startup self-check failed, the Nuts node might not be started yet: %v", err)
+	}
 	// load bbolt db
 	db, err := bolt.Open(config.DBFile, 0600, nil)
 	if err != nil {
@@ -68,14 +80,6 @@ func main() {
 	}
 	defer db.Close()
 
-	var vendorDID *did.DID
-	if config.VendorDID != "" {
-		vendorDID, err = did.ParseDID(config.VendorDID)
-		if err != nil {
-			log.Fatal(err)
-		}
-	}
-
 	e := echo.New()
 	e.HideBanner = true
 	loggerConfig := middleware.DefaultLoggerConfig
@@ -100,15 +104,96 @@ func main() {
 	}))
 	e.HTTPErrorHandler = httpErrorHandler
 
+	svcs, err := newServices(config, db)
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	// Initialize Auth
-	var account api.UserAccount
-	if config.Credentials.Empty() {
-		account = generateDefaultAccount(config)
-		log.Printf("Authentication credentials not configured, so they were generated (user=%s, password=%s)", account.Username, account.Password)
-	} else {
-		account = api.UserAccount{Username: config.Credentials.Username, Password: config.Credentials.Password}
+	account, err := configuredAccount(config)
+	if err != nil {
+		log.Fatal(err)
+	}
+	auth := api.NewAuth(config.sessionKey, []api.UserAccount{account}, svcs.users)
+
+	if len(config.Trust.PolicyFile) > 0 {
+		if err := svcs.trust.ApplyFile(config.Trust.PolicyFile); err != nil {
+			log.Printf("Unable to apply trust policy file (file=%s): %v", config.Trust.PolicyFile, err)
+		}
+	}
+
+	// Initialize background jobs
+	jobQueue := jobs.NewQueue(db, jobs.DefaultWorkers)
+	jobQueue.Register(customers.SyncNutsCommJobType, svcs.customers.HandleSyncNutsCommJob)
+	jobQueue.Register(credentials.ActivateCustomersJobType, svcs.credentials.HandleActivateCustomersJob)
+	jobQueue.Register(credentials.RenewCredentialsJobType, svcs.credentials.HandleRenewCredentialsJob)
+	jobQueue.Register(directory.RefreshIndexJobType, svcs.directory.HandleRefreshIndexJob)
+	rolloutService := rollout.NewService(rollout.NewBBoltRepository(db), svcs.customers, svcs.sp, jobQueue)
+	reconcileService := reconcile.NewService(svcs.customers, svcs.credentials, svcs.sp, jobQueue)
+	if err := reconcileService.MigrateActive(db); err != nil {
+		log.Printf("Unable to store whether customers are active, it's retried on the next start: %v", err)
+	}
+	if err := jobQueue.Start(); err != nil {
+		log.Fatal(err)
+	}
+	if config.Reconcile.Interval > 0 {
+		reconcileService.Schedule(config.Reconcile.Interval, config.Reconcile.AutoRepair)
+	}
+	if config.Renewal.Interval > 0 {
+		svcs.credentials.ScheduleRenewal(jobQueue, config.Renewal.Interval)
+	}
+	if config.Directory.IndexInterval > 0 {
+		svcs.directory.ScheduleIndexRefresh(jobQueue, config.Directory.IndexInterval)
+	}
+
+	// Initialize wrapper
+	apiWrapper := api.Wrapper{Auth: auth, SPService: svcs.sp, CustomerService: svcs.customers, CredentialService: svcs.credentials, RolloutService: rolloutService, ReconcileService: reconcileService, VCTemplateService: svcs.vcTemplates, TrustService: svcs.trust, DirectoryService: svcs.directory, Jobs: jobQueue}
+
+	api.RegisterHandlers(e, apiWrapper)
+
+	// Setup asset serving:
+	// Check if we use live mode from the file system or using embedded files
+	useFS := len(os.Args) > 1 && os.Args[1] == "live"
+	assetHandler := http.FileServer(getFileSystem(useFS))
+	logoHandler := api.NewLogoHandler(config.Branding.Logo)
+	e.GET("/branding/logo", logoHandler.Handle)
+	e.GET("/status", func(context echo.Context) error {
+		return context.String(http.StatusOK, "OK")
+	})
+	e.GET("/*", echo.WrapHandler(assetHandler))
+
+	// Reload the config when it changes
+	reloader := &configReloader{auth: auth, logo: logoHandler, nodeClients: svcs.nodeClients, current: config}
+	if err := reloader.Start(); err != nil {
+		log.Printf("Config is not reloaded when it changes: %v", err)
+	}
+
+	// Start server
+	e.Logger.Fatal(e.Start(fmt.Sprintf(":%d", config.HTTPPort)))
+}
+
+// services contains the domain services, which are shared by the server and the CLI commands.
+type services struct {
+	nodeClients *domain.NodeClients
+	sp          sp.Service
+	customers   customers.Service
+	credentials credentials.Service
+	vcTemplates vctemplates.Service
+	trust       trust.Service
+	directory   directory.Service
+	users       users.Service
+}
+
+// newServices creates the domain services using the given config and database.
+func newServices(config Config, db *bolt.DB) (*services, error) {
+	var vendorDID *did.DID
+	if config.VendorDID != "" {
+		var err error
+		vendorDID, err = did.ParseDID(config.VendorDID)
+		if err != nil {
+			return nil, err
+		}
 	}
-	auth := api.NewAuth(config.sessionKey, []api.UserAccount{account})
 
 	// API security
 	tokenGenerator := func() (string, error) {
@@ -119,32 +204,19 @@ func main() {
 	}
 
 	// Initialize repos
-	vdrClient := vdrAPI.HTTPClient{
-		ClientConfig: core.ClientConfig{
-			Address: config.NutsNodeAddress,
-			Timeout: apiTimeout,
-		},
-		TokenGenerator: tokenGenerator,
-	}
-	didmanClient := didmanAPI.HTTPClient{
-		ClientConfig: core.ClientConfig{
-			Address: config.NutsNodeAddress,
-			Timeout: apiTimeout,
-		},
-		TokenGenerator: tokenGenerator,
-	}
-	vcrClient := vcrApi.HTTPClient{
-		ClientConfig: core.ClientConfig{
-			Address: config.NutsNodeAddress,
-			Timeout: apiTimeout,
-		},
-		TokenGenerator: tokenGenerator,
+	nodeClients := domain.NewNodeClients(config.NutsNodeAddress, config.NutsNodeTimeout, tokenGenerator)
+	vdrClient := nodeClients.VDR()
+	didmanClient := nodeClients.DIDMan()
+	serviceCatalog, err := sp.LoadCatalog(config.ServiceCatalogFile)
+	if err != nil {
+		return nil, err
 	}
 	spService := sp.Service{
 		Repository:   sp.NewBBoltRepository(db),
 		VDRClient:    vdrClient,
 		DIDManClient: didmanClient,
 		VendorDID:    vendorDID,
+		Catalog:      serviceCatalog,
 	}
 
 	// Initialize services
@@ -154,37 +226,64 @@ func main() {
 		DIDManClient: didmanClient,
 	}
 	credentialService := credentials.Service{
-		NutsNodeAddr: config.NutsNodeAddress,
-		SPService:    spService,
-		DIDManClient: didmanClient,
-		VCRClient:    vcrClient,
+		SPService:            spService,
+		DIDManClient:         didmanClient,
+		VDRClient:            vdrClient,
+		NodeClients:          nodeClients,
+		CustomerRepository:   customerService.Repository,
+		ValidityDays:         config.Renewal.ValidityDays,
+		RenewalDays:          config.Renewal.Days,
+		TrustCredentialTypes: config.Trust.CredentialTypes,
+	}
+	if config.Trust.IssuerCacheTTL > 0 {
+		credentialService.IssuerCache = credentials.NewIssuerCache(config.Trust.IssuerCacheTTL)
 	}
 
-	// Initialize wrapper
-	apiWrapper := api.Wrapper{Auth: auth, SPService: spService, CustomerService: customerService, CredentialService: credentialService}
+	vcTemplateService := vctemplates.Service{Repository: vctemplates.NewBBoltRepository(db)}
+	if err := vcTemplateService.LoadDefaults(); err != nil {
+		return nil, err
+	}
 
-	api.RegisterHandlers(e, apiWrapper)
+	directoryService := directory.Service{CredentialService: credentialService, VDRClient: vdrClient}
+	if config.Directory.IndexInterval > 0 {
+		directoryService.Index = directory.NewBBoltIndexRepository(db)
+	}
 
-	// Setup asset serving:
-	// Check if we use live mode from the file system or using embedded files
-	useFS := len(os.Args) > 1 && os.Args[1] == "live"
-	assetHandler := http.FileServer(getFileSystem(useFS))
-	e.GET("/branding/logo", (&api.LogoHandler{FilePath: config.Branding.Logo}).Handle)
-	e.GET("/status", func(context echo.Context) error {
-		return context.String(http.StatusOK, "OK")
-	})
-	e.GET("/*", echo.WrapHandler(assetHandler))
+	return &services{
+		nodeClients: nodeClients,
+		sp:          spService,
+		customers:   customerService,
+		credentials: credentialService,
+		vcTemplates: vcTemplateService,
+		trust:       trust.Service{CredentialService: credentialService, Repository: trust.NewBBoltRepository(db), ManagedCredentialTypes: credentialService.TrustCredentialTypes},
+		directory:   directoryService,
+		users:       users.Service{Repository: users.NewFlatFileRepository(config.UsersFile)},
+	}, nil
+}
 
-	// Start server
-	e.Logger.Fatal(e.Start(fmt.Sprintf(":%d", config.HTTPPort)))
+// configuredAccount returns the account from the config. If not configured, an account with a random password is generated.
+func configuredAccount(config Config) (api.UserAccount, error) {
+	if !config.Credentials.Empty() {
+		return api.UserAccount{Username: config.Credentials.Username, Password: config.Credentials.Password}, nil
+	}
+	account, err := generateDefaultAccount()
+	if err != nil {
+		return api.UserAccount{}, err
+	}
+	log.Printf("Authentication credentials not configured, so they were generated (user=%s, password=%s)", account.Username, account.Password)
+	return account, nil
 }
 
-func generateDefaultAccount(config Config) api.UserAccount {
-	pkHashBytes := sha1.Sum(elliptic.Marshal(config.sessionKey.Curve, config.sessionKey.X, config.sessionKey.Y))
-	return api.UserAccount{Username: "demo@nuts.nl", Password: hex.EncodeToString(pkHashBytes[:])}
+func generateDefaultAccount() (api.UserAccount, error) {
+	password := make([]byte, 16)
+	if _, err := rand.Read(password); err != nil {
+		return api.UserAccount{}, fmt.Errorf("unable to generate password: %w", err)
+	}
+	return api.UserAccount{Username: "demo@nuts.nl", Password: hex.EncodeToString(password)}, nil
 }
 
-// httpErrorHandler includes the err.Err() string in a { "error": "msg" } json hash
+// httpErrorHandler includes the err.Err() string in a { "error": "msg" } json hash.
+// Errors returned by the Nuts node get a status matching the node's response, see nodeErrorStatus.
 func httpErrorHandler(err error, c echo.Context) {
 	var (
 		code = http.StatusInternalServerError
@@ -199,6 +298,7 @@ func httpErrorHandler(err error, c echo.Context) {
 			err = fmt.Errorf("%v, %v", err, he.Internal)
 		}
 	} else {
+		code = nodeErrorStatus(err)
 		msg = err.Error()
 	}
 
@@ -219,6 +319,22 @@ func httpErrorHandler(err error, c echo.Context) {
 	}
 }
 
+// nodeErrorStatus returns the HTTP status for an error which occurred calling the Nuts node.
+// Problems with the node itself result in 502 Bad Gateway rather than 401, since the user is authenticated fine.
+func nodeErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, domain.ErrNutsNodeUnreachable):
+		return http.StatusServiceUnavailable
+	case errors.Is(err, domain.ErrNutsNodeNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, domain.ErrNutsNodeInvalidRequest):
+		return http.StatusBadRequest
+	case errors.Is(err, domain.ErrNutsNodeUnauthorized), errors.Is(err, domain.ErrNutsNodeFailed):
+		return http.StatusBadGateway
+	}
+	return http.StatusInternalServerError
+}
+
 func requestsStatusEndpoint(context echo.Context) bool {
 	return context.Request().RequestURI == "/status"
 }
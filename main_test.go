@@ -0,0 +1,32 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/nuts-foundation/nuts-registry-admin-demo/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNodeErrorStatus(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected int
+	}{
+		{name: "unreachable", err: domain.ErrNutsNodeUnreachable, expected: http.StatusServiceUnavailable},
+		{name: "not found", err: domain.ErrNutsNodeNotFound, expected: http.StatusNotFound},
+		{name: "invalid request", err: domain.ErrNutsNodeInvalidRequest, expected: http.StatusBadRequest},
+		{name: "unauthorized", err: domain.ErrNutsNodeUnauthorized, expected: http.StatusBadGateway},
+		{name: "failed", err: domain.ErrNutsNodeFailed, expected: http.StatusBadGateway},
+		{name: "wrapped", err: fmt.Errorf("unable to issue: %w", domain.ErrNutsNodeNotFound), expected: http.StatusNotFound},
+		{name: "other error", err: errors.New("other"), expected: http.StatusInternalServerError},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, nodeErrorStatus(test.err))
+		})
+	}
+}
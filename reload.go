@@ -0,0 +1,214 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/nuts-foundation/nuts-registry-admin-demo/api"
+	"github.com/nuts-foundation/nuts-registry-admin-demo/domain"
+	"github.com/sirupsen/logrus"
+)
+
+// reloadDelay is how long to wait for more changes of the config file before reloading it,
+// since editors often write a file in multiple steps.
+const reloadDelay = 500 * time.Millisecond
+
+// reloadableKeys contains the keys of the config values which are applied without a restart.
+var reloadableKeys = map[string]bool{
+	"credentials.username":     true,
+	"credentials.password":     true,
+	"credentials.passwordfile": true,
+	"branding.logo":            true,
+	"nutsnodetimeout":          true,
+	"loglevel":                 true,
+	// insecure only affects the validation of the config
+	"insecure": true,
+}
+
+// accountSetter replaces the user accounts which can log in to the web interface.
+type accountSetter interface {
+	SetUserAccounts(accounts []api.UserAccount)
+}
+
+// configReloader reloads the config when the config file changes or the process receives SIGHUP.
+// It applies the reloadable values and logs the changes which require a restart. Invalid configs are rejected,
+// keeping the current config.
+type configReloader struct {
+	auth        accountSetter
+	logo        *api.LogoHandler
+	nodeClients *domain.NodeClients
+	mutex       sync.Mutex
+	// current contains the config the server is running with, including the reloaded values.
+	current Config
+}
+
+// Start watches the config file and SIGHUP in the background.
+func (r *configReloader) Start() error {
+	flagset, err := loadFlagSet(os.Args[1:])
+	if err != nil {
+		return err
+	}
+	configFile, err := filepath.Abs(resolveConfigFile(flagset))
+	if err != nil {
+		return err
+	}
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("unable to watch config file: %w", err)
+	}
+	// The directory is watched instead of the file, so the file is still watched after an editor replaced it
+	if err := watcher.Add(filepath.Dir(configFile)); err != nil {
+		_ = watcher.Close()
+		return fmt.Errorf("unable to watch config file: %w", err)
+	}
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+
+	go func() {
+		var timer *time.Timer
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if filepath.Clean(event.Name) != configFile || event.Op&(fsnotify.Write|fsnotify.Create) == 0 {
+					continue
+				}
+				if timer != nil {
+					timer.Stop()
+				}
+				timer = time.AfterFunc(reloadDelay, func() {
+					log.Printf("Config file changed, reloading: %s", configFile)
+					r.Reload()
+				})
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				log.Printf("Error while watching config file: %v", err)
+			case <-signals:
+				log.Print("Received SIGHUP, reloading config")
+				r.Reload()
+			}
+		}
+	}()
+	return nil
+}
+
+// Reload reads and validates the config, and applies the reloadable values when it's valid.
+func (r *configReloader) Reload() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	flagset, err := loadFlagSet(os.Args[1:])
+	config, errs := readConfig(flagset)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	errs = append(errs, validateConfig(config)...)
+	if len(errs) > 0 {
+		log.Printf("Config change rejected, keeping the current config:\n%v", errors.Join(errs...))
+		return
+	}
+
+	var applied, restartRequired []string
+	for _, change := range configChanges(r.current, config) {
+		if reloadableKeys[change.key] {
+			applied = append(applied, change.description)
+		} else {
+			restartRequired = append(restartRequired, change.description)
+		}
+	}
+	if len(restartRequired) > 0 {
+		log.Printf("Config changed, but a restart is required to apply: %s", strings.Join(restartRequired, ", "))
+	}
+	if len(applied) == 0 {
+		log.Print("Config reloaded, no changes to apply")
+		return
+	}
+
+	// Only the reloadable values are copied, so the session key is kept and existing sessions stay valid
+	if config.Credentials != r.current.Credentials {
+		account, err := configuredAccount(config)
+		if err != nil {
+			log.Printf("Config change rejected, keeping the current config: %v", err)
+			return
+		}
+		r.current.Credentials = config.Credentials
+		r.auth.SetUserAccounts([]api.UserAccount{account})
+	}
+	r.current.Branding = config.Branding
+	r.current.NutsNodeTimeout = config.NutsNodeTimeout
+	r.current.LogLevel = config.LogLevel
+	r.current.Insecure = config.Insecure
+	r.logo.SetFilePath(r.current.Branding.Logo)
+	r.nodeClients.SetTimeout(r.current.NutsNodeTimeout)
+	applyLogLevel(r.current.LogLevel)
+	log.Printf("Config reloaded, applied: %s", strings.Join(applied, ", "))
+}
+
+// applyLogLevel sets the level of the log messages of the domain services. The level must be valid.
+func applyLogLevel(level string) {
+	parsed, err := logrus.ParseLevel(level)
+	if err != nil {
+		log.Printf("Invalid log level (level=%s): %v", level, err)
+		return
+	}
+	logrus.SetLevel(parsed)
+}
+
+// configChange describes a config value which changed. The description doesn't contain the values of secrets.
+type configChange struct {
+	key         string
+	description string
+}
+
+// configChanges returns the config values which differ between the old and the new config, sorted by key.
+func configChanges(old, new Config) []configChange {
+	oldValues := map[string]string{}
+	newValues := map[string]string{}
+	flattenConfig(reflect.ValueOf(old), "", oldValues)
+	flattenConfig(reflect.ValueOf(new), "", newValues)
+	secrets := new.secrets()
+	var result []configChange
+	for _, key := range sortedKeys(newValues) {
+		if oldValues[key] == newValues[key] {
+			continue
+		}
+		description := fmt.Sprintf("%s (%s -> %s)", key, oldValues[key], newValues[key])
+		if _, isSecret := secrets[key]; isSecret {
+			description = key + " (changed)"
+		}
+		result = append(result, configChange{key: key, description: description})
+	}
+	return result
+}
+
+// flattenConfig collects the config values in the given struct by their key, using their koanf tags.
+func flattenConfig(value reflect.Value, prefix string, values map[string]string) {
+	t := value.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("koanf")
+		if len(tag) == 0 {
+			continue
+		}
+		key := prefix + tag
+		if field.Type.Kind() == reflect.Struct {
+			flattenConfig(value.Field(i), key+defaultDelimiter, values)
+			continue
+		}
+		values[key] = fmt.Sprint(value.Field(i).Interface())
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigChanges(t *testing.T) {
+	tests := []struct {
+		name     string
+		change   func(config *Config)
+		expected []configChange
+	}{
+		{name: "no changes", change: func(*Config) {}},
+		{
+			name:     "changed value",
+			change:   func(config *Config) { config.LogLevel = "debug" },
+			expected: []configChange{{key: "loglevel", description: "loglevel (info -> debug)"}},
+		},
+		{
+			name:     "nested value",
+			change:   func(config *Config) { config.Trust.IssuerCacheTTL = time.Minute },
+			expected: []configChange{{key: "trust.issuercachettl", description: "trust.issuercachettl (10m0s -> 1m0s)"}},
+		},
+		{
+			name:     "map value",
+			change:   func(config *Config) { config.Renewal.ValidityDays = map[string]int{"NutsOrganizationCredential": 30} },
+			expected: []configChange{{key: "renewal.validitydays", description: "renewal.validitydays (map[NutsOrganizationCredential:365] -> map[NutsOrganizationCredential:30])"}},
+		},
+		{
+			name: "secrets aren't described",
+			change: func(config *Config) {
+				config.Credentials.Username = "admin"
+				config.Credentials.Password = "correct horse"
+			},
+			expected: []configChange{
+				{key: "credentials.password", description: "credentials.password (changed)"},
+				{key: "credentials.username", description: "credentials.username ( -> admin)"},
+			},
+		},
+		{
+			name: "sorted by key",
+			change: func(config *Config) {
+				config.VendorDID = "did:nuts:vendor"
+				config.HTTPPort = 8080
+				config.DBFile = "other.db"
+			},
+			expected: []configChange{
+				{key: "dbfile", description: "dbfile (registry-admin.db -> other.db)"},
+				{key: "port", description: "port (1303 -> 8080)"},
+				{key: "vendordid", description: "vendordid ( -> did:nuts:vendor)"},
+			},
+		},
+		{
+			name:   "unexported fields are ignored",
+			change: func(config *Config) { config.sessionKey, _ = generateSessionKey() },
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			old := defaultConfig()
+			new := defaultConfig()
+			test.change(&new)
+
+			assert.Equal(t, test.expected, configChanges(old, new))
+		})
+	}
+}